@@ -0,0 +1,15 @@
+package assets
+
+import "io"
+
+// Open opens an asset from the Default filesystem, returning
+// a NotFoundError if it can't be opened
+func Open(path string) (io.ReadCloser, error) {
+	return Default.OpenAsset(path)
+}
+
+// ReadFile reads the whole contents of an asset from the
+// Default filesystem
+func ReadFile(path string) ([]byte, error) {
+	return Default.ReadAsset(path)
+}
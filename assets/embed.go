@@ -0,0 +1,10 @@
+package assets
+
+import "embed"
+
+// Embedded contains the engine's default assets: the default
+// texture, the fonts and the TropicalSunnyDay skybox. It is
+// mounted at "engine" in the Default filesystem.
+//
+//go:embed abstract.jpg fonts skybox/TropicalSunnyDay
+var Embedded embed.FS
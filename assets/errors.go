@@ -0,0 +1,60 @@
+package assets
+
+import "fmt"
+
+//  --------------------------------------------------
+//  Errors.go contains the errors returned by the asset
+//  loaders. Each one carries the path of the asset, so
+//  a bad file can be tracked down from a log message.
+//  --------------------------------------------------
+
+// NotFoundError is returned when an asset can't be opened
+type NotFoundError struct {
+	Path string
+	Err  error
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("asset not found: %v: %v", e.Path, e.Err)
+}
+
+func (e *NotFoundError) Unwrap() error {
+	return e.Err
+}
+
+// DecodeError is returned when an asset is found but its
+// contents are malformed. Context describes where in the file
+// the problem is, e.g. a line number, and may be empty.
+type DecodeError struct {
+	Path    string
+	Format  string
+	Context string
+	Err     error
+}
+
+func (e *DecodeError) Error() string {
+	if e.Context != "" {
+		return fmt.Sprintf("failed to decode %v %v (%v): %v", e.Format, e.Path, e.Context, e.Err)
+	}
+	return fmt.Sprintf("failed to decode %v %v: %v", e.Format, e.Path, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// CompileError is returned when a shader stage fails to compile, or
+// with Stage "link" when a program fails to link, where Path is its
+// vertex shader. Log contains the info log from the driver.
+type CompileError struct {
+	Path  string
+	Stage string
+	Log   string
+}
+
+func (e *CompileError) Error() string {
+	if e.Stage == "link" {
+		return fmt.Sprintf("failed to link shader program %v: %v", e.Path, e.Log)
+	}
+	return fmt.Sprintf("failed to compile %v shader %v: %v", e.Stage, e.Path, e.Log)
+}
@@ -0,0 +1,159 @@
+package assets
+
+import (
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+
+	"rapidengine/material/shaders"
+)
+
+//  --------------------------------------------------
+//  FS.go contains the asset filesystem. Every loader
+//  resolves its paths through a FileSystem, which
+//  searches a list of mounted io/fs filesystems in
+//  order and returns the first match. Each engine has
+//  its own, cloned from the Default filesystem.
+//  --------------------------------------------------
+
+// Mount is a filesystem mounted under a path prefix. A mount with an
+// empty prefix is a search path, and is tried for every asset.
+type Mount struct {
+	Prefix string
+	FS     fs.FS
+}
+
+// FileSystem resolves asset paths through its mounts. Paths that
+// aren't valid io/fs paths, such as "../game/tex.png" or absolute
+// paths, are opened directly from disk.
+type FileSystem struct {
+	mounts []Mount
+	mu     sync.RWMutex
+}
+
+// Default is the filesystem engines are cloned from, and is used by
+// the package level loaders. It contains the embedded engine assets under "engine", the
+// embedded shaders under "engine/shaders", and the working
+// directory as a search path.
+//
+// To use the full engine asset folder from disk instead, e.g. for
+// the other skyboxes, mount it over the embedded one:
+//
+//	assets.Default.Mount("engine", os.DirFS("../rapidengine/assets"))
+var Default = NewFileSystem()
+
+func init() {
+	Default.AddSearchPath(os.DirFS("."))
+	Default.Mount("engine", Embedded)
+	Default.Mount("engine/shaders", shaders.FS)
+}
+
+func NewFileSystem() *FileSystem {
+	return &FileSystem{}
+}
+
+// Clone returns a filesystem with the same mounts, which can
+// be mounted on without changing f
+func (f *FileSystem) Clone() *FileSystem {
+	return &FileSystem{mounts: f.Mounts()}
+}
+
+// Mount mounts fsys under prefix. It is searched before
+// every filesystem mounted before it.
+func (f *FileSystem) Mount(prefix string, fsys fs.FS) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.mounts = append([]Mount{{Prefix: strings.Trim(prefix, "/"), FS: fsys}}, f.mounts...)
+}
+
+// AddSearchPath adds fsys as a search path. It is searched
+// after every filesystem mounted before it.
+func (f *FileSystem) AddSearchPath(fsys fs.FS) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.mounts = append(f.mounts, Mount{FS: fsys})
+}
+
+// Unmount removes every filesystem mounted under prefix
+func (f *FileSystem) Unmount(prefix string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	prefix = strings.Trim(prefix, "/")
+	mounts := []Mount{}
+	for _, m := range f.mounts {
+		if m.Prefix != prefix {
+			mounts = append(mounts, m)
+		}
+	}
+	f.mounts = mounts
+}
+
+// Mounts returns the mounts in search order
+func (f *FileSystem) Mounts() []Mount {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	return append([]Mount{}, f.mounts...)
+}
+
+// Open opens the first match for name in search order
+func (f *FileSystem) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return os.Open(name)
+	}
+
+	for _, m := range f.Mounts() {
+		rel, ok := trimPrefix(name, m.Prefix)
+		if !ok {
+			continue
+		}
+
+		file, err := m.FS.Open(rel)
+		if err == nil {
+			return file, nil
+		}
+	}
+
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+// OpenAsset opens an asset, returning a NotFoundError
+// if it can't be opened
+func (f *FileSystem) OpenAsset(path string) (io.ReadCloser, error) {
+	file, err := f.Open(path)
+	if err != nil {
+		return nil, &NotFoundError{Path: path, Err: err}
+	}
+	return file, nil
+}
+
+// ReadAsset reads the whole contents of an asset
+func (f *FileSystem) ReadAsset(path string) ([]byte, error) {
+	file, err := f.OpenAsset(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return ioutil.ReadAll(file)
+}
+
+// trimPrefix returns name relative to a mount prefix, if it is under it
+func trimPrefix(name, prefix string) (string, bool) {
+	if prefix == "" {
+		return name, true
+	}
+	if name == prefix {
+		return ".", true
+	}
+	if strings.HasPrefix(name, prefix+"/") {
+		return name[len(prefix)+1:], true
+	}
+	return "", false
+}
@@ -0,0 +1,114 @@
+package backend
+
+//  --------------------------------------------------
+//  Backend.go defines the interface between the engine
+//  and the windowing / graphics layer. Every window,
+//  input and OpenGL call the engine makes goes through
+//  the Current backend, so the engine can run against
+//  a real GLFW window or headlessly with NullBackend.
+//  --------------------------------------------------
+
+import (
+	"rapidengine/configuration"
+)
+
+// Current is the backend used by the whole engine. It is
+// set by the renderer during engine construction.
+var Current Backend
+
+// Backend is implemented by GLFWBackend, which drives a real
+// window and OpenGL context, and NullBackend, which records calls.
+// Enum arguments use the OpenGL constant values from the gl package.
+type Backend interface {
+	// Window
+	Init(config *configuration.EngineConfig) error
+	Terminate()
+	ShouldClose() bool
+	SetShouldClose(bool)
+	SwapBuffers()
+	PollEvents()
+	GetTime() float64
+	KeyPressed(key string) bool
+	SetCursorEnabled(enabled bool)
+	SetResizeCallback(f func(width, height int))
+
+	// Global state
+	Enable(capability uint32)
+	Disable(capability uint32)
+	BlendFunc(src, dst uint32)
+	DepthMask(flag bool)
+	PolygonMode(face, mode uint32)
+	Viewport(x, y, width, height int32)
+	Scissor(x, y, width, height int32)
+	ClearColor(r, g, b, a float32)
+	Clear(mask uint32)
+	GetError() uint32
+	Version() string
+
+	// Shaders
+	CreateProgram() uint32
+	CompileShader(source string, shaderType uint32) (uint32, error)
+	AttachShader(program, shader uint32)
+	LinkProgram(program uint32) error
+	UseProgram(program uint32)
+	GetUniformLocation(program uint32, name string) int32
+	BindAttribLocation(program, index uint32, name string)
+
+	// Uniforms
+	Uniform1i(location int32, v int32)
+	Uniform1f(location int32, v float32)
+	Uniform2fv(location int32, count int32, v *float32)
+	Uniform3fv(location int32, count int32, v *float32)
+	Uniform4fv(location int32, count int32, v *float32)
+	UniformMatrix4fv(location int32, count int32, transpose bool, v *float32)
+
+	// Textures
+	GenTexture() uint32
+	ActiveTexture(unit uint32)
+	BindTexture(target, texture uint32)
+	TexParameteri(target, name uint32, param int32)
+	TexImage2D(target uint32, level, internalFormat, width, height int32, format, xtype uint32, pixels []uint8)
+	GenerateMipmap(target uint32)
+
+	// Vertex data
+	GenVertexArray() uint32
+	BindVertexArray(vao uint32)
+	GenBuffer() uint32
+	BindBuffer(target, buffer uint32)
+	BufferFloats(target uint32, data []float32, usage uint32)
+	BufferUints(target uint32, data []uint32, usage uint32)
+	VertexAttribPointer(index uint32, size int32, xtype uint32, normalized bool, stride int32, offset int)
+	EnableVertexAttribArray(index uint32)
+	DisableVertexAttribArray(index uint32)
+	VertexAttribDivisor(index, divisor uint32)
+
+	// Framebuffers
+	GenFramebuffer() uint32
+	BindFramebuffer(target, framebuffer uint32)
+	FramebufferTexture(target, attachment, texture uint32, level int32)
+	GenRenderbuffer() uint32
+	BindRenderbuffer(target, renderbuffer uint32)
+	RenderbufferStorage(target, format uint32, width, height int32)
+	FramebufferRenderbuffer(target, attachment, renderbufferTarget, renderbuffer uint32)
+	DrawBuffers(buffers []uint32)
+	CheckFramebufferStatus(target uint32) uint32
+
+	// Drawing
+	DrawElements(mode uint32, count int32, xtype uint32, offset int)
+	DrawElementsInstanced(mode uint32, count int32, xtype uint32, offset int, instances int32)
+
+	// Queries
+	GenQuery() uint32
+	BeginQuery(target, query uint32)
+	EndQuery(target uint32)
+	QueryResultAvailable(query uint32) bool
+	QueryResult(query uint32) uint64
+}
+
+// New creates the backend requested by the engine configuration
+func New(config *configuration.EngineConfig) Backend {
+	if config.Headless {
+		return NewNullBackend(config)
+	}
+	return NewGLFWBackend()
+}
@@ -0,0 +1,434 @@
+package backend
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/glfw/v3.2/glfw"
+
+	"rapidengine/configuration"
+	"rapidengine/input"
+)
+
+// GLFWBackend renders into a GLFW window through OpenGL 4.1
+type GLFWBackend struct {
+	Window *glfw.Window
+}
+
+func NewGLFWBackend() *GLFWBackend {
+	return &GLFWBackend{}
+}
+
+//  --------------------------------------------------
+//  Window
+//  --------------------------------------------------
+
+// Init creates the window and OpenGL context, and hooks
+// the mouse callbacks into the input package
+func (b *GLFWBackend) Init(config *configuration.EngineConfig) error {
+	if err := glfw.Init(); err != nil {
+		return err
+	}
+
+	glfw.WindowHint(glfw.Samples, 4)
+	glfw.WindowHint(glfw.ContextVersionMajor, 4)
+	glfw.WindowHint(glfw.ContextVersionMinor, 1)
+	glfw.WindowHint(glfw.Resizable, glfw.False)
+	glfw.WindowHint(glfw.OpenGLProfile, glfw.OpenGLCoreProfile)
+	glfw.WindowHint(glfw.OpenGLForwardCompatible, glfw.True)
+	glfw.WindowHint(glfw.OpenGLDebugContext, glfw.True)
+
+	var m *glfw.Monitor
+	if config.FullScreen {
+		m = glfw.GetPrimaryMonitor()
+	} else {
+		m = nil
+	}
+
+	window, err := glfw.CreateWindow(config.ScreenWidth, config.ScreenHeight, config.WindowTitle, m, nil)
+	if err != nil {
+		return err
+	}
+
+	window.MakeContextCurrent()
+
+	if config.AntiAliasing {
+		glfw.WindowHint(glfw.Samples, 8)
+	}
+
+	if !config.VSync {
+		glfw.SwapInterval(0)
+	}
+
+	window.SetCursorPosCallback(input.MouseCallback)
+	window.SetMouseButtonCallback(input.MouseButtonCallback)
+	window.SetScrollCallback(input.ScrollCallback)
+
+	b.Window = window
+
+	return gl.Init()
+}
+
+func (b *GLFWBackend) Terminate() {
+	glfw.Terminate()
+}
+
+func (b *GLFWBackend) ShouldClose() bool {
+	return b.Window.ShouldClose()
+}
+
+func (b *GLFWBackend) SetShouldClose(c bool) {
+	b.Window.SetShouldClose(c)
+}
+
+func (b *GLFWBackend) SwapBuffers() {
+	b.Window.SwapBuffers()
+}
+
+func (b *GLFWBackend) PollEvents() {
+	glfw.PollEvents()
+}
+
+func (b *GLFWBackend) GetTime() float64 {
+	return glfw.GetTime()
+}
+
+func (b *GLFWBackend) KeyPressed(key string) bool {
+	return b.Window.GetKey(input.KeyMap[key]) == glfw.Press
+}
+
+func (b *GLFWBackend) SetCursorEnabled(enabled bool) {
+	if enabled {
+		b.Window.SetInputMode(glfw.CursorMode, glfw.CursorNormal)
+	} else {
+		b.Window.SetInputMode(glfw.CursorMode, glfw.CursorDisabled)
+	}
+}
+
+// SetResizeCallback calls f with the new framebuffer size
+// whenever the window is resized
+func (b *GLFWBackend) SetResizeCallback(f func(width, height int)) {
+	b.Window.SetFramebufferSizeCallback(func(w *glfw.Window, width, height int) {
+		f(width, height)
+	})
+}
+
+//  --------------------------------------------------
+//  Global state
+//  --------------------------------------------------
+
+func (b *GLFWBackend) Enable(capability uint32) {
+	gl.Enable(capability)
+}
+
+func (b *GLFWBackend) Disable(capability uint32) {
+	gl.Disable(capability)
+}
+
+func (b *GLFWBackend) BlendFunc(src, dst uint32) {
+	gl.BlendFunc(src, dst)
+}
+
+func (b *GLFWBackend) DepthMask(flag bool) {
+	gl.DepthMask(flag)
+}
+
+func (b *GLFWBackend) PolygonMode(face, mode uint32) {
+	gl.PolygonMode(face, mode)
+}
+
+func (b *GLFWBackend) Viewport(x, y, width, height int32) {
+	gl.Viewport(x, y, width, height)
+}
+
+func (b *GLFWBackend) Scissor(x, y, width, height int32) {
+	gl.Scissor(x, y, width, height)
+}
+
+func (b *GLFWBackend) ClearColor(r, g, bl, a float32) {
+	gl.ClearColor(r, g, bl, a)
+}
+
+func (b *GLFWBackend) Clear(mask uint32) {
+	gl.Clear(mask)
+}
+
+func (b *GLFWBackend) GetError() uint32 {
+	return gl.GetError()
+}
+
+func (b *GLFWBackend) Version() string {
+	return gl.GoStr(gl.GetString(gl.VERSION))
+}
+
+//  --------------------------------------------------
+//  Shaders
+//  --------------------------------------------------
+
+func (b *GLFWBackend) CreateProgram() uint32 {
+	return gl.CreateProgram()
+}
+
+// CompileShader compiles a single shader stage, returning
+// the info log as an error if compilation fails
+func (b *GLFWBackend) CompileShader(source string, shaderType uint32) (uint32, error) {
+	shader := gl.CreateShader(shaderType)
+
+	csources, free := gl.Strs(source + "\x00")
+	gl.ShaderSource(shader, 1, csources, nil)
+	free()
+	gl.CompileShader(shader)
+
+	var status int32
+	gl.GetShaderiv(shader, gl.COMPILE_STATUS, &status)
+	if status == gl.FALSE {
+		var logLength int32
+		gl.GetShaderiv(shader, gl.INFO_LOG_LENGTH, &logLength)
+
+		log := strings.Repeat("\x00", int(logLength+1))
+		gl.GetShaderInfoLog(shader, logLength, nil, gl.Str(log))
+
+		return 0, fmt.Errorf("%v", strings.TrimRight(log, "\x00"))
+	}
+	return shader, nil
+}
+
+func (b *GLFWBackend) AttachShader(program, shader uint32) {
+	gl.AttachShader(program, shader)
+}
+
+// LinkProgram links the attached stages of a program,
+// returning the info log as an error if linking fails
+func (b *GLFWBackend) LinkProgram(program uint32) error {
+	gl.LinkProgram(program)
+
+	var status int32
+	gl.GetProgramiv(program, gl.LINK_STATUS, &status)
+	if status == gl.FALSE {
+		var logLength int32
+		gl.GetProgramiv(program, gl.INFO_LOG_LENGTH, &logLength)
+
+		log := strings.Repeat("\x00", int(logLength+1))
+		gl.GetProgramInfoLog(program, logLength, nil, gl.Str(log))
+
+		return fmt.Errorf("%v", strings.TrimRight(log, "\x00"))
+	}
+	return nil
+}
+
+func (b *GLFWBackend) UseProgram(program uint32) {
+	gl.UseProgram(program)
+}
+
+func (b *GLFWBackend) GetUniformLocation(program uint32, name string) int32 {
+	return gl.GetUniformLocation(program, gl.Str(name+"\x00"))
+}
+
+func (b *GLFWBackend) BindAttribLocation(program, index uint32, name string) {
+	gl.BindAttribLocation(program, index, gl.Str(name+"\x00"))
+}
+
+//  --------------------------------------------------
+//  Uniforms
+//  --------------------------------------------------
+
+func (b *GLFWBackend) Uniform1i(location int32, v int32) {
+	gl.Uniform1i(location, v)
+}
+
+func (b *GLFWBackend) Uniform1f(location int32, v float32) {
+	gl.Uniform1f(location, v)
+}
+
+func (b *GLFWBackend) Uniform2fv(location int32, count int32, v *float32) {
+	gl.Uniform2fv(location, count, v)
+}
+
+func (b *GLFWBackend) Uniform3fv(location int32, count int32, v *float32) {
+	gl.Uniform3fv(location, count, v)
+}
+
+func (b *GLFWBackend) Uniform4fv(location int32, count int32, v *float32) {
+	gl.Uniform4fv(location, count, v)
+}
+
+func (b *GLFWBackend) UniformMatrix4fv(location int32, count int32, transpose bool, v *float32) {
+	gl.UniformMatrix4fv(location, count, transpose, v)
+}
+
+//  --------------------------------------------------
+//  Textures
+//  --------------------------------------------------
+
+func (b *GLFWBackend) GenTexture() uint32 {
+	var texture uint32
+	gl.GenTextures(1, &texture)
+	return texture
+}
+
+func (b *GLFWBackend) ActiveTexture(unit uint32) {
+	gl.ActiveTexture(unit)
+}
+
+func (b *GLFWBackend) BindTexture(target, texture uint32) {
+	gl.BindTexture(target, texture)
+}
+
+func (b *GLFWBackend) TexParameteri(target, name uint32, param int32) {
+	gl.TexParameteri(target, name, param)
+}
+
+func (b *GLFWBackend) TexImage2D(target uint32, level, internalFormat, width, height int32, format, xtype uint32, pixels []uint8) {
+	if len(pixels) == 0 {
+		gl.TexImage2D(target, level, internalFormat, width, height, 0, format, xtype, nil)
+		return
+	}
+	gl.TexImage2D(target, level, internalFormat, width, height, 0, format, xtype, gl.Ptr(pixels))
+}
+
+func (b *GLFWBackend) GenerateMipmap(target uint32) {
+	gl.GenerateMipmap(target)
+}
+
+//  --------------------------------------------------
+//  Vertex data
+//  --------------------------------------------------
+
+func (b *GLFWBackend) GenVertexArray() uint32 {
+	var vao uint32
+	gl.GenVertexArrays(1, &vao)
+	return vao
+}
+
+func (b *GLFWBackend) BindVertexArray(vao uint32) {
+	gl.BindVertexArray(vao)
+}
+
+func (b *GLFWBackend) GenBuffer() uint32 {
+	var buffer uint32
+	gl.GenBuffers(1, &buffer)
+	return buffer
+}
+
+func (b *GLFWBackend) BindBuffer(target, buffer uint32) {
+	gl.BindBuffer(target, buffer)
+}
+
+func (b *GLFWBackend) BufferFloats(target uint32, data []float32, usage uint32) {
+	if len(data) == 0 {
+		gl.BufferData(target, 0, nil, usage)
+		return
+	}
+	gl.BufferData(target, 4*len(data), gl.Ptr(data), usage)
+}
+
+func (b *GLFWBackend) BufferUints(target uint32, data []uint32, usage uint32) {
+	if len(data) == 0 {
+		gl.BufferData(target, 0, nil, usage)
+		return
+	}
+	gl.BufferData(target, 4*len(data), gl.Ptr(data), usage)
+}
+
+func (b *GLFWBackend) VertexAttribPointer(index uint32, size int32, xtype uint32, normalized bool, stride int32, offset int) {
+	gl.VertexAttribPointer(index, size, xtype, normalized, stride, gl.PtrOffset(offset))
+}
+
+func (b *GLFWBackend) EnableVertexAttribArray(index uint32) {
+	gl.EnableVertexAttribArray(index)
+}
+
+func (b *GLFWBackend) DisableVertexAttribArray(index uint32) {
+	gl.DisableVertexAttribArray(index)
+}
+
+func (b *GLFWBackend) VertexAttribDivisor(index, divisor uint32) {
+	gl.VertexAttribDivisor(index, divisor)
+}
+
+//  --------------------------------------------------
+//  Framebuffers
+//  --------------------------------------------------
+
+func (b *GLFWBackend) GenFramebuffer() uint32 {
+	var fbo uint32
+	gl.GenFramebuffers(1, &fbo)
+	return fbo
+}
+
+func (b *GLFWBackend) BindFramebuffer(target, framebuffer uint32) {
+	gl.BindFramebuffer(target, framebuffer)
+}
+
+func (b *GLFWBackend) FramebufferTexture(target, attachment, texture uint32, level int32) {
+	gl.FramebufferTexture(target, attachment, texture, level)
+}
+
+func (b *GLFWBackend) GenRenderbuffer() uint32 {
+	var rbo uint32
+	gl.GenRenderbuffers(1, &rbo)
+	return rbo
+}
+
+func (b *GLFWBackend) BindRenderbuffer(target, renderbuffer uint32) {
+	gl.BindRenderbuffer(target, renderbuffer)
+}
+
+func (b *GLFWBackend) RenderbufferStorage(target, format uint32, width, height int32) {
+	gl.RenderbufferStorage(target, format, width, height)
+}
+
+func (b *GLFWBackend) FramebufferRenderbuffer(target, attachment, renderbufferTarget, renderbuffer uint32) {
+	gl.FramebufferRenderbuffer(target, attachment, renderbufferTarget, renderbuffer)
+}
+
+func (b *GLFWBackend) DrawBuffers(buffers []uint32) {
+	gl.DrawBuffers(int32(len(buffers)), &buffers[0])
+}
+
+func (b *GLFWBackend) CheckFramebufferStatus(target uint32) uint32 {
+	return gl.CheckFramebufferStatus(target)
+}
+
+//  --------------------------------------------------
+//  Drawing
+//  --------------------------------------------------
+
+func (b *GLFWBackend) DrawElements(mode uint32, count int32, xtype uint32, offset int) {
+	gl.DrawElements(mode, count, xtype, gl.PtrOffset(offset))
+}
+
+func (b *GLFWBackend) DrawElementsInstanced(mode uint32, count int32, xtype uint32, offset int, instances int32) {
+	gl.DrawElementsInstanced(mode, count, xtype, gl.PtrOffset(offset), instances)
+}
+
+//  --------------------------------------------------
+//  Queries
+//  --------------------------------------------------
+
+func (b *GLFWBackend) GenQuery() uint32 {
+	var query uint32
+	gl.GenQueries(1, &query)
+	return query
+}
+
+func (b *GLFWBackend) BeginQuery(target, query uint32) {
+	gl.BeginQuery(target, query)
+}
+
+func (b *GLFWBackend) EndQuery(target uint32) {
+	gl.EndQuery(target)
+}
+
+func (b *GLFWBackend) QueryResultAvailable(query uint32) bool {
+	var available int32
+	gl.GetQueryObjectiv(query, gl.QUERY_RESULT_AVAILABLE, &available)
+	return available != 0
+}
+
+func (b *GLFWBackend) QueryResult(query uint32) uint64 {
+	var result uint64
+	gl.GetQueryObjectui64v(query, gl.QUERY_RESULT, &result)
+	return result
+}
@@ -0,0 +1,362 @@
+package backend
+
+import (
+	"unsafe"
+
+	"github.com/sirupsen/logrus"
+
+	"rapidengine/configuration"
+)
+
+//  --------------------------------------------------
+//  NullBackend runs the engine without a window or GPU.
+//  It hands out fake object names, keeps a simulated
+//  clock and records the draw calls, uniform sets and
+//  texture binds it receives so they can be inspected.
+//  --------------------------------------------------
+
+// Call kinds recorded by the NullBackend
+const (
+	CallDraw    = "draw"
+	CallUniform = "uniform"
+	CallTexture = "texture"
+	CallBuffer  = "buffer"
+)
+
+// Call is a single recorded backend call
+type Call struct {
+	Kind string
+	Name string
+	Args []interface{}
+}
+
+type NullBackend struct {
+	// Recorded calls, in order, while Recording is set. It is off by
+	// default, since a long run would record calls without limit.
+	Calls     []Call
+	Recording bool
+
+	// Call counters, kept even when recording is disabled
+	DrawCalls     int
+	UniformSets   int
+	TextureBinds  int
+	BufferUploads int
+
+	// Simulated clock, advanced by FrameTime on every SwapBuffers
+	Time      float64
+	FrameTime float64
+
+	// Keys reported as pressed to the InputControl
+	Keys map[string]bool
+
+	Logger *logrus.Logger
+
+	closed  bool
+	nextID  uint32
+	program uint32
+	resize  func(width, height int)
+}
+
+func NewNullBackend(config *configuration.EngineConfig) *NullBackend {
+	frameTime := 1 / float64(60)
+	if config.MaxFPS > 0 {
+		frameTime = 1 / float64(config.MaxFPS)
+	}
+	return &NullBackend{
+		FrameTime: frameTime,
+		Keys:      make(map[string]bool),
+		Logger:    config.Logger,
+	}
+}
+
+// Reset clears all recorded calls and counters
+func (b *NullBackend) Reset() {
+	b.Calls = nil
+	b.DrawCalls = 0
+	b.UniformSets = 0
+	b.TextureBinds = 0
+	b.BufferUploads = 0
+}
+
+// CallsOfKind returns every recorded call of the given kind
+func (b *NullBackend) CallsOfKind(kind string) []Call {
+	calls := []Call{}
+	for _, c := range b.Calls {
+		if c.Kind == kind {
+			calls = append(calls, c)
+		}
+	}
+	return calls
+}
+
+func (b *NullBackend) record(kind, name string, args ...interface{}) {
+	switch kind {
+	case CallDraw:
+		b.DrawCalls++
+	case CallUniform:
+		b.UniformSets++
+	case CallTexture:
+		b.TextureBinds++
+	case CallBuffer:
+		b.BufferUploads++
+	}
+
+	if !b.Recording {
+		return
+	}
+
+	b.Calls = append(b.Calls, Call{Kind: kind, Name: name, Args: args})
+	if b.Logger != nil {
+		b.Logger.WithField("args", args).Debug("null backend: ", name)
+	}
+}
+
+func (b *NullBackend) newID() uint32 {
+	b.nextID++
+	return b.nextID
+}
+
+func floats(v *float32, n int32) []float32 {
+	if v == nil || n <= 0 {
+		return nil
+	}
+	out := make([]float32, n)
+	copy(out, unsafe.Slice(v, n))
+	return out
+}
+
+//  --------------------------------------------------
+//  Window
+//  --------------------------------------------------
+
+func (b *NullBackend) Init(config *configuration.EngineConfig) error {
+	return nil
+}
+
+func (b *NullBackend) Terminate() {}
+
+func (b *NullBackend) ShouldClose() bool {
+	return b.closed
+}
+
+func (b *NullBackend) SetShouldClose(c bool) {
+	b.closed = c
+}
+
+func (b *NullBackend) SwapBuffers() {
+	b.Time += b.FrameTime
+}
+
+func (b *NullBackend) PollEvents() {}
+
+func (b *NullBackend) GetTime() float64 {
+	return b.Time
+}
+
+func (b *NullBackend) KeyPressed(key string) bool {
+	return b.Keys[key]
+}
+
+func (b *NullBackend) SetCursorEnabled(enabled bool) {}
+
+func (b *NullBackend) SetResizeCallback(f func(width, height int)) {
+	b.resize = f
+}
+
+// Resize simulates the window being resized
+func (b *NullBackend) Resize(width, height int) {
+	if b.resize != nil {
+		b.resize(width, height)
+	}
+}
+
+//  --------------------------------------------------
+//  Global state
+//  --------------------------------------------------
+
+func (b *NullBackend) Enable(capability uint32)           {}
+func (b *NullBackend) Disable(capability uint32)          {}
+func (b *NullBackend) BlendFunc(src, dst uint32)          {}
+func (b *NullBackend) DepthMask(flag bool)                {}
+func (b *NullBackend) PolygonMode(face, mode uint32)      {}
+func (b *NullBackend) Viewport(x, y, width, height int32) {}
+func (b *NullBackend) Scissor(x, y, width, height int32)  {}
+func (b *NullBackend) ClearColor(r, g, bl, a float32)     {}
+func (b *NullBackend) Clear(mask uint32)                  {}
+
+func (b *NullBackend) GetError() uint32 {
+	return 0
+}
+
+func (b *NullBackend) Version() string {
+	return "null"
+}
+
+//  --------------------------------------------------
+//  Shaders
+//  --------------------------------------------------
+
+func (b *NullBackend) CreateProgram() uint32 {
+	return b.newID()
+}
+
+func (b *NullBackend) CompileShader(source string, shaderType uint32) (uint32, error) {
+	return b.newID(), nil
+}
+
+func (b *NullBackend) AttachShader(program, shader uint32) {}
+func (b *NullBackend) LinkProgram(program uint32) error    { return nil }
+
+func (b *NullBackend) UseProgram(program uint32) {
+	b.program = program
+}
+
+// GetUniformLocation hands out a fresh location for every
+// lookup, so uniform sets can be told apart in the call log
+func (b *NullBackend) GetUniformLocation(program uint32, name string) int32 {
+	return int32(b.newID())
+}
+
+func (b *NullBackend) BindAttribLocation(program, index uint32, name string) {}
+
+//  --------------------------------------------------
+//  Uniforms
+//  --------------------------------------------------
+
+func (b *NullBackend) Uniform1i(location int32, v int32) {
+	b.record(CallUniform, "Uniform1i", b.program, location, v)
+}
+
+func (b *NullBackend) Uniform1f(location int32, v float32) {
+	b.record(CallUniform, "Uniform1f", b.program, location, v)
+}
+
+func (b *NullBackend) Uniform2fv(location int32, count int32, v *float32) {
+	b.record(CallUniform, "Uniform2fv", b.program, location, floats(v, 2*count))
+}
+
+func (b *NullBackend) Uniform3fv(location int32, count int32, v *float32) {
+	b.record(CallUniform, "Uniform3fv", b.program, location, floats(v, 3*count))
+}
+
+func (b *NullBackend) Uniform4fv(location int32, count int32, v *float32) {
+	b.record(CallUniform, "Uniform4fv", b.program, location, floats(v, 4*count))
+}
+
+func (b *NullBackend) UniformMatrix4fv(location int32, count int32, transpose bool, v *float32) {
+	b.record(CallUniform, "UniformMatrix4fv", b.program, location, floats(v, 16*count))
+}
+
+//  --------------------------------------------------
+//  Textures
+//  --------------------------------------------------
+
+func (b *NullBackend) GenTexture() uint32 {
+	return b.newID()
+}
+
+func (b *NullBackend) ActiveTexture(unit uint32) {}
+
+func (b *NullBackend) BindTexture(target, texture uint32) {
+	b.record(CallTexture, "BindTexture", target, texture)
+}
+
+func (b *NullBackend) TexParameteri(target, name uint32, param int32) {}
+
+func (b *NullBackend) TexImage2D(target uint32, level, internalFormat, width, height int32, format, xtype uint32, pixels []uint8) {
+}
+
+func (b *NullBackend) GenerateMipmap(target uint32) {}
+
+//  --------------------------------------------------
+//  Vertex data
+//  --------------------------------------------------
+
+func (b *NullBackend) GenVertexArray() uint32 {
+	return b.newID()
+}
+
+func (b *NullBackend) BindVertexArray(vao uint32) {}
+
+func (b *NullBackend) GenBuffer() uint32 {
+	return b.newID()
+}
+
+func (b *NullBackend) BindBuffer(target, buffer uint32) {}
+func (b *NullBackend) BufferFloats(target uint32, data []float32, usage uint32) {
+	b.record(CallBuffer, "BufferFloats", target, len(data), usage)
+}
+
+func (b *NullBackend) BufferUints(target uint32, data []uint32, usage uint32) {
+	b.record(CallBuffer, "BufferUints", target, len(data), usage)
+}
+
+func (b *NullBackend) VertexAttribPointer(index uint32, size int32, xtype uint32, normalized bool, stride int32, offset int) {
+}
+
+func (b *NullBackend) EnableVertexAttribArray(index uint32) {}
+
+func (b *NullBackend) DisableVertexAttribArray(index uint32) {}
+
+func (b *NullBackend) VertexAttribDivisor(index, divisor uint32) {}
+
+//  --------------------------------------------------
+//  Framebuffers
+//  --------------------------------------------------
+
+func (b *NullBackend) GenFramebuffer() uint32 {
+	return b.newID()
+}
+
+func (b *NullBackend) BindFramebuffer(target, framebuffer uint32)                         {}
+func (b *NullBackend) FramebufferTexture(target, attachment, texture uint32, level int32) {}
+
+func (b *NullBackend) GenRenderbuffer() uint32 {
+	return b.newID()
+}
+
+func (b *NullBackend) BindRenderbuffer(target, renderbuffer uint32)                   {}
+func (b *NullBackend) RenderbufferStorage(target, format uint32, width, height int32) {}
+
+func (b *NullBackend) FramebufferRenderbuffer(target, attachment, renderbufferTarget, renderbuffer uint32) {
+}
+
+func (b *NullBackend) DrawBuffers(buffers []uint32) {}
+
+// CheckFramebufferStatus always reports a complete framebuffer
+// (GL_FRAMEBUFFER_COMPLETE)
+func (b *NullBackend) CheckFramebufferStatus(target uint32) uint32 {
+	return 0x8CD5
+}
+
+//  --------------------------------------------------
+//  Drawing
+//  --------------------------------------------------
+
+func (b *NullBackend) DrawElements(mode uint32, count int32, xtype uint32, offset int) {
+	b.record(CallDraw, "DrawElements", b.program, mode, count)
+}
+
+func (b *NullBackend) DrawElementsInstanced(mode uint32, count int32, xtype uint32, offset int, instances int32) {
+	b.record(CallDraw, "DrawElementsInstanced", b.program, mode, count, instances)
+}
+
+//  --------------------------------------------------
+//  Queries
+//  --------------------------------------------------
+
+func (b *NullBackend) GenQuery() uint32 {
+	return b.newID()
+}
+
+func (b *NullBackend) BeginQuery(target, query uint32) {}
+func (b *NullBackend) EndQuery(target uint32)          {}
+
+// QueryResultAvailable is always true, and every result is 0
+func (b *NullBackend) QueryResultAvailable(query uint32) bool {
+	return true
+}
+
+func (b *NullBackend) QueryResult(query uint32) uint64 {
+	return 0
+}
@@ -22,11 +22,29 @@ type Camera interface {
 	ChangeRoll(float32)
 
 	GetFirstViewIndex() *float32
+	GetView() mgl32.Mat4
 	GetStaticView() mgl32.Mat4
 
+	// GetProjection returns the projection children are drawn
+	// with, which is rebuilt whenever its settings change
+	GetProjection() mgl32.Mat4
+
+	// SetAspect sets the width over height of the area the
+	// camera draws to. The renderer sets it from each view.
+	SetAspect(float32)
+
 	SetPosition(float32, float32, float32)
 	GetPosition() (float32, float32, float32)
 
+	// ScreenToWorld returns the point in the world under a position on
+	// the screen, in pixels from the top left like the mouse. depth is
+	// 0 at the near plane and 1 at the far plane.
+	ScreenToWorld(x, y, depth float32) mgl32.Vec3
+
+	// ScreenRay returns the origin and normalized direction of the
+	// ray from the camera through a position on the screen
+	ScreenRay(x, y float32) (mgl32.Vec3, mgl32.Vec3)
+
 	SetSpeed(float32)
 	SetSmoothSpeed(float32)
 
@@ -34,3 +52,31 @@ type Camera interface {
 
 	ProcessMouse(float64, float64, float64, float64)
 }
+
+// FarProjector is implemented by cameras that can build their
+// projection with another far plane, for children that are
+// drawn further away than the camera's own far plane
+type FarProjector interface {
+	GetProjectionFar(far float32) mgl32.Mat4
+}
+
+// Positioner is anything with a 2D position that a camera can
+// follow, such as a Child2D
+type Positioner interface {
+	GetX() float32
+	GetY() float32
+}
+
+// Positioner3D is anything with a 3D position that a camera
+// can follow, such as a Child3D
+type Positioner3D interface {
+	Positioner
+	GetZ() float32
+}
+
+// Raycaster finds the distance along a ray to the nearest
+// obstacle, for cameras that avoid clipping into the scene.
+// dir must be normalized.
+type Raycaster interface {
+	Raycast(origin, dir mgl32.Vec3, maxDist float32) (float32, bool)
+}
@@ -25,6 +25,10 @@ type Camera2D struct {
 	View       mgl32.Mat4
 	StaticView mgl32.Mat4
 
+	// Zoom scales the view around its center. 1 shows the
+	// whole screen, and 2 shows half of it at twice the size.
+	Zoom float32
+
 	config *configuration.EngineConfig
 }
 
@@ -42,6 +46,7 @@ func NewCamera2D(position mgl32.Vec3, speed float32, config *configuration.Engin
 
 		Speed:       speed,
 		SmoothSpeed: 1.0,
+		Zoom:        1,
 
 		config: config,
 	}
@@ -124,6 +129,24 @@ func (camera2D *Camera2D) GetFirstViewIndex() *float32 {
 	return &camera2D.View[0]
 }
 
+func (camera2D *Camera2D) GetView() mgl32.Mat4 {
+	return camera2D.View
+}
+
+// GetProjection returns the zoomed projection. Static
+// children don't use it, so they aren't zoomed.
+func (camera2D *Camera2D) GetProjection() mgl32.Mat4 {
+	z := camera2D.Zoom
+	if z <= 0 {
+		z = 1
+	}
+	return mgl32.Ortho2D(-1/z, 1/z, -1/z, 1/z)
+}
+
+// SetAspect does nothing, since 2D positions are
+// already relative to the size of the screen
+func (camera2D *Camera2D) SetAspect(aspect float32) {}
+
 func (camera2D *Camera2D) GetPosition() (float32, float32, float32) {
 	return ((camera2D.Position.X() / 2) * float32(camera2D.config.ScreenWidth)) + float32(camera2D.config.ScreenWidth/2),
 		((camera2D.Position.Y() / 2) * float32(camera2D.config.ScreenHeight)) + float32(camera2D.config.ScreenHeight/2), 0
@@ -137,6 +160,23 @@ func (camera2D *Camera2D) SetPosition(x, y, z float32) {
 	}
 }
 
+// ScreenToWorld returns the position under a point on the screen, in
+// the same pixels as the positions of children. depth is ignored.
+func (camera2D *Camera2D) ScreenToWorld(x, y, depth float32) mgl32.Vec3 {
+	sw, sh := float32(camera2D.config.ScreenWidth), float32(camera2D.config.ScreenHeight)
+	nx, ny := ScreenToNDC(x, y, sw, sh)
+
+	// Children are drawn from -1 to 1 across the screen
+	p := Unproject(camera2D.View, camera2D.GetProjection(), nx, ny, 0)
+	return mgl32.Vec3{(p.X() + 1) * sw / 2, (p.Y() + 1) * sh / 2, 0}
+}
+
+// ScreenRay returns the position under a point on the screen,
+// pointing into the screen
+func (camera2D *Camera2D) ScreenRay(x, y float32) (mgl32.Vec3, mgl32.Vec3) {
+	return camera2D.ScreenToWorld(x, y, 0), camera2D.FrontAxis
+}
+
 func (camera2D *Camera2D) SetSpeed(s float32) {
 	camera2D.Speed = s
 }
@@ -2,8 +2,10 @@ package camera
 
 import (
 	"math"
+	"math/rand"
 	"rapidengine/configuration"
 	"rapidengine/input"
+	"rapidengine/procedural"
 
 	"github.com/go-gl/mathgl/mgl32"
 )
@@ -20,6 +22,32 @@ type Camera3D struct {
 	Yaw   float32
 	Roll  float32
 
+	// Position and front axis the camera moves toward. SmoothSpeed is
+	// how much of the way it moves each frame at 60fps, so 1 moves
+	// straight there and lower values move smoothly. Writing Position
+	// or FrontAxis directly moves the targets with them.
+	SmoothSpeed     float32
+	TargetPosition  mgl32.Vec3
+	TargetFrontAxis mgl32.Vec3
+
+	// Position and front axis as Look left them, to tell
+	// when they've been written directly
+	lookedPosition  mgl32.Vec3
+	lookedFrontAxis mgl32.Vec3
+
+	// Trauma shakes the camera by up to MaxShakeOffset units and
+	// MaxShakeAngle degrees, scaled by its square so small amounts
+	// barely move it. It falls by TraumaDecay per second, and
+	// ShakeFrequency is how quickly the shake changes direction.
+	Trauma         float32
+	TraumaDecay    float32
+	MaxShakeOffset float32
+	MaxShakeAngle  float32
+	ShakeFrequency float64
+
+	shakeNoise procedural.SimplexGenerator
+	shakeTime  float64
+
 	MouseX float64
 	MouseY float64
 
@@ -28,28 +56,103 @@ type Camera3D struct {
 
 	FirstMouse bool
 
-	View   mgl32.Mat4
-	Config *configuration.EngineConfig
+	// Projection settings. FOV is vertical, in degrees, and
+	// OrthoSize is half the height seen in orthographic mode.
+	FOV          float32
+	Near         float32
+	Far          float32
+	Aspect       float32
+	Orthographic bool
+	OrthoSize    float32
+
+	View       mgl32.Mat4
+	Projection mgl32.Mat4
+	Config     *configuration.EngineConfig
 }
 
 func NewCamera3D(position mgl32.Vec3, speed float32, config *configuration.EngineConfig) *Camera3D {
-	return &Camera3D{
-		Position:    position,
-		UpAxis:      mgl32.Vec3{0, 1, 0},
-		FrontAxis:   mgl32.Vec3{0, 0, -1},
-		Speed:       speed,
-		Sensitivity: 0.2,
-		Yaw:         0,
-		Pitch:       0,
-		Config:      config,
+	c := &Camera3D{
+		Position:        position,
+		UpAxis:          mgl32.Vec3{0, 1, 0},
+		FrontAxis:       mgl32.Vec3{0, 0, -1},
+		Speed:           speed,
+		Sensitivity:     0.2,
+		Yaw:             0,
+		Pitch:           0,
+		SmoothSpeed:     1,
+		TargetPosition:  position,
+		TargetFrontAxis: mgl32.Vec3{0, 0, -1},
+		lookedPosition:  position,
+		lookedFrontAxis: mgl32.Vec3{0, 0, -1},
+		TraumaDecay:     1,
+		MaxShakeOffset:  0.5,
+		MaxShakeAngle:   3,
+		ShakeFrequency:  15,
+		shakeNoise:      procedural.NewSimplexGenerator(1, 1, 0.5, 2, rand.Int63()),
+		FOV:             45,
+		Near:            0.1,
+		Far:             100000,
+		Aspect:          float32(config.ScreenWidth) / float32(config.ScreenHeight),
+		OrthoSize:       10,
+		Config:          config,
 	}
+	c.UpdateProjection()
+	return c
 }
 
 func (camera3D *Camera3D) Look(delta float64) {
+	// Keep direct writes since the last frame
+	camera3D.TargetPosition = camera3D.TargetPosition.Add(camera3D.Position.Sub(camera3D.lookedPosition))
+	if camera3D.FrontAxis != camera3D.lookedFrontAxis {
+		camera3D.TargetFrontAxis = camera3D.FrontAxis
+	}
+
+	// Move toward target position and direction
+	amount := float32(1)
+	if camera3D.SmoothSpeed < 1 {
+		amount = mgl32.Clamp(camera3D.SmoothSpeed*float32(delta)*60, 0, 1)
+	}
+	camera3D.Position = LerpPosition(camera3D.Position, camera3D.TargetPosition, amount)
+	if front := LerpPosition(camera3D.FrontAxis, camera3D.TargetFrontAxis, amount); front.Len() > 0 {
+		camera3D.FrontAxis = front.Normalize()
+	}
+	camera3D.lookedPosition, camera3D.lookedFrontAxis = camera3D.Position, camera3D.FrontAxis
+
+	camera3D.lookAt(delta, camera3D.Position, camera3D.Position.Add(camera3D.FrontAxis))
+}
+
+// lookAt builds the view from eye toward center, shaken by the trauma
+func (camera3D *Camera3D) lookAt(delta float64, eye, center mgl32.Vec3) {
+	front := center.Sub(eye).Normalize()
+	roll := camera3D.Roll
+
+	if camera3D.Trauma > 0 {
+		camera3D.shakeTime += delta * camera3D.ShakeFrequency
+		amount := camera3D.Trauma * camera3D.Trauma
+
+		// Smooth noise from -1 to 1, with a separate channel for each axis
+		noise := func(channel float64) float32 {
+			return float32(camera3D.shakeNoise.Noise2D(camera3D.shakeTime, channel*10+0.5)*2-1) * amount
+		}
+
+		right := front.Cross(camera3D.UpAxis).Normalize()
+		up := right.Cross(front)
+		eye = eye.
+			Add(right.Mul(noise(0) * camera3D.MaxShakeOffset)).
+			Add(up.Mul(noise(1) * camera3D.MaxShakeOffset)).
+			Add(front.Mul(noise(2) * camera3D.MaxShakeOffset))
+
+		front = mgl32.HomogRotate3D(mgl32.DegToRad(noise(3)*camera3D.MaxShakeAngle), up).Mul4x1(front.Vec4(0)).Vec3()
+		front = mgl32.HomogRotate3D(mgl32.DegToRad(noise(4)*camera3D.MaxShakeAngle), right).Mul4x1(front.Vec4(0)).Vec3()
+		roll += mgl32.DegToRad(noise(5) * camera3D.MaxShakeAngle)
+
+		camera3D.Trauma = mgl32.Clamp(camera3D.Trauma-camera3D.TraumaDecay*float32(delta), 0, 1)
+	}
+
 	camera3D.View = mgl32.LookAtV(
-		camera3D.Position,
-		camera3D.Position.Add(camera3D.FrontAxis),
-		mgl32.HomogRotate3D(camera3D.Roll, camera3D.FrontAxis).Mul4x1(mgl32.Vec4{0, 1, 0, 1.0}).Vec3(),
+		eye,
+		eye.Add(front),
+		mgl32.HomogRotate3D(roll, front).Mul4x1(mgl32.Vec4{0, 1, 0, 1.0}).Vec3(),
 	)
 }
 
@@ -92,8 +195,8 @@ func (camera3D *Camera3D) ProcessMouse(mouseX, mouseY, lastMouseX, lastMouseY fl
 	if camera3D.Pitch < -89 {
 		camera3D.Pitch = -89
 	}
-	camera3D.FrontAxis = CalculateDirection(camera3D.Pitch, camera3D.Yaw).Normalize()
-	camera3D.FrontAxis = mgl32.HomogRotate3D(camera3D.Roll, camera3D.FrontAxis).Mul4x1(camera3D.FrontAxis.Vec4(1.0)).Vec3()
+	camera3D.TargetFrontAxis = CalculateDirection(camera3D.Pitch, camera3D.Yaw).Normalize()
+	camera3D.TargetFrontAxis = mgl32.HomogRotate3D(camera3D.Roll, camera3D.TargetFrontAxis).Mul4x1(camera3D.TargetFrontAxis.Vec4(1.0)).Vec3()
 }
 
 func CalculateDirection(pitch, yaw float32) mgl32.Vec3 {
@@ -105,27 +208,27 @@ func CalculateDirection(pitch, yaw float32) mgl32.Vec3 {
 }
 
 func (camera3D *Camera3D) MoveForward() {
-	camera3D.Position = camera3D.Position.Add(camera3D.FrontAxis.Mul(camera3D.Speed))
+	camera3D.TargetPosition = camera3D.TargetPosition.Add(camera3D.FrontAxis.Mul(camera3D.Speed))
 }
 
 func (camera3D *Camera3D) MoveBackward() {
-	camera3D.Position = camera3D.Position.Sub(camera3D.FrontAxis.Mul(camera3D.Speed))
+	camera3D.TargetPosition = camera3D.TargetPosition.Sub(camera3D.FrontAxis.Mul(camera3D.Speed))
 }
 
 func (camera3D *Camera3D) MoveUp() {
-	camera3D.Position = camera3D.Position.Add(camera3D.UpAxis.Mul(camera3D.Speed))
+	camera3D.TargetPosition = camera3D.TargetPosition.Add(camera3D.UpAxis.Mul(camera3D.Speed))
 }
 
 func (camera3D *Camera3D) MoveDown() {
-	camera3D.Position = camera3D.Position.Sub(camera3D.UpAxis.Mul(camera3D.Speed))
+	camera3D.TargetPosition = camera3D.TargetPosition.Sub(camera3D.UpAxis.Mul(camera3D.Speed))
 }
 
 func (camera3D *Camera3D) MoveLeft() {
-	camera3D.Position = camera3D.Position.Sub(camera3D.FrontAxis.Cross(camera3D.UpAxis).Normalize().Mul(camera3D.Speed))
+	camera3D.TargetPosition = camera3D.TargetPosition.Sub(camera3D.FrontAxis.Cross(camera3D.UpAxis).Normalize().Mul(camera3D.Speed))
 }
 
 func (camera3D *Camera3D) MoveRight() {
-	camera3D.Position = camera3D.Position.Add(camera3D.FrontAxis.Cross(camera3D.UpAxis).Normalize().Mul(camera3D.Speed))
+	camera3D.TargetPosition = camera3D.TargetPosition.Add(camera3D.FrontAxis.Cross(camera3D.UpAxis).Normalize().Mul(camera3D.Speed))
 }
 
 func (camera3D *Camera3D) ChangeRoll(r float32) {
@@ -138,16 +241,38 @@ func (camera3D *Camera3D) ChangeRoll(r float32) {
 
 func (camera3D *Camera3D) ChangeYaw(y float32) {
 	camera3D.Yaw += y
-	camera3D.FrontAxis = CalculateDirection(camera3D.Pitch, camera3D.Yaw).Normalize()
+	camera3D.TargetFrontAxis = CalculateDirection(camera3D.Pitch, camera3D.Yaw).Normalize()
 }
 
 func (camera3D *Camera3D) ChangePitch(p float32) {
 	camera3D.Pitch += p
-	camera3D.FrontAxis = CalculateDirection(camera3D.Pitch, camera3D.Yaw).Normalize()
+	camera3D.TargetFrontAxis = CalculateDirection(camera3D.Pitch, camera3D.Yaw).Normalize()
 }
 
+// SetPosition sets the position the camera moves toward
 func (camera3D *Camera3D) SetPosition(x, y, z float32) {
-	camera3D.Position = mgl32.Vec3{x, y, z}
+	camera3D.TargetPosition = mgl32.Vec3{x, y, z}
+}
+
+// LookAt turns the camera toward a point, from the
+// position it's moving toward
+func (camera3D *Camera3D) LookAt(target mgl32.Vec3) {
+	dir := target.Sub(camera3D.TargetPosition)
+	if dir.Len() == 0 {
+		return
+	}
+	dir = dir.Normalize()
+
+	camera3D.TargetFrontAxis = dir
+	camera3D.Pitch = mgl32.RadToDeg(float32(math.Asin(float64(dir.Y()))))
+	camera3D.Yaw = mgl32.RadToDeg(float32(math.Atan2(float64(dir.Z()), float64(dir.X()))))
+}
+
+// Snap moves the camera straight to its target position and direction
+func (camera3D *Camera3D) Snap() {
+	camera3D.Position = camera3D.TargetPosition
+	camera3D.FrontAxis = camera3D.TargetFrontAxis
+	camera3D.lookedPosition, camera3D.lookedFrontAxis = camera3D.Position, camera3D.FrontAxis
 }
 
 func (camera3D *Camera3D) SetSpeed(s float32) {
@@ -155,11 +280,79 @@ func (camera3D *Camera3D) SetSpeed(s float32) {
 }
 
 func (camera3D *Camera3D) SetSmoothSpeed(s float32) {
-	//camera3D.SmoothSpeed = s
+	camera3D.SmoothSpeed = s
 }
 
+// Shake adds strength trauma, from 0 to 1, which wears
+// off over the duration in seconds
 func (camera3D *Camera3D) Shake(duration float64, strength float32) {
-	//camera3D.SmoothSpeed = s
+	camera3D.AddTrauma(strength)
+	if duration > 0 {
+		camera3D.TraumaDecay = camera3D.Trauma / float32(duration)
+	}
+}
+
+// AddTrauma adds to the trauma, which is kept from 0 to 1
+func (camera3D *Camera3D) AddTrauma(t float32) {
+	camera3D.Trauma = mgl32.Clamp(camera3D.Trauma+t, 0, 1)
+}
+
+//  --------------------------------------------------
+//  Projection
+//  --------------------------------------------------
+
+// UpdateProjection rebuilds the projection. It must be called
+// after the projection settings are changed directly.
+func (camera3D *Camera3D) UpdateProjection() {
+	camera3D.Projection = camera3D.GetProjectionFar(camera3D.Far)
+}
+
+// GetProjectionFar returns the camera's projection with another far plane
+func (camera3D *Camera3D) GetProjectionFar(far float32) mgl32.Mat4 {
+	if camera3D.Orthographic {
+		h := camera3D.OrthoSize
+		w := h * camera3D.Aspect
+		return mgl32.Ortho(-w, w, -h, h, camera3D.Near, far)
+	}
+	return mgl32.Perspective(mgl32.DegToRad(camera3D.FOV), camera3D.Aspect, camera3D.Near, far)
+}
+
+func (camera3D *Camera3D) GetProjection() mgl32.Mat4 {
+	return camera3D.Projection
+}
+
+func (camera3D *Camera3D) SetAspect(aspect float32) {
+	if aspect != camera3D.Aspect {
+		camera3D.Aspect = aspect
+		camera3D.UpdateProjection()
+	}
+}
+
+// SetFOV sets the vertical field of view, in degrees
+func (camera3D *Camera3D) SetFOV(fov float32) {
+	camera3D.FOV = fov
+	camera3D.UpdateProjection()
+}
+
+// SetClipPlanes sets the distance of the near and far planes
+func (camera3D *Camera3D) SetClipPlanes(near, far float32) {
+	camera3D.Near = near
+	camera3D.Far = far
+	camera3D.UpdateProjection()
+}
+
+// SetOrthographic switches to an orthographic projection, which
+// shows size units above and below the center of the view
+func (camera3D *Camera3D) SetOrthographic(size float32) {
+	camera3D.Orthographic = true
+	camera3D.OrthoSize = size
+	camera3D.UpdateProjection()
+}
+
+// SetPerspective switches back to a perspective projection
+func (camera3D *Camera3D) SetPerspective() {
+	camera3D.Orthographic = false
+	camera3D.UpdateProjection()
 }
 
 //  --------------------------------------------------
@@ -170,6 +363,10 @@ func (camera3D *Camera3D) GetFirstViewIndex() *float32 {
 	return &camera3D.View[0]
 }
 
+func (camera3D *Camera3D) GetView() mgl32.Mat4 {
+	return camera3D.View
+}
+
 func (camera3D *Camera3D) GetStaticView() mgl32.Mat4 {
 	return mgl32.LookAtV(
 		mgl32.Vec3{0, 0, 0},
@@ -181,3 +378,17 @@ func (camera3D *Camera3D) GetStaticView() mgl32.Mat4 {
 func (camera3D *Camera3D) GetPosition() (float32, float32, float32) {
 	return camera3D.Position.X(), camera3D.Position.Y(), camera3D.Position.Z()
 }
+
+//  --------------------------------------------------
+//  Unprojection
+//  --------------------------------------------------
+
+func (camera3D *Camera3D) ScreenToWorld(x, y, depth float32) mgl32.Vec3 {
+	nx, ny := ScreenToNDC(x, y, float32(camera3D.Config.ScreenWidth), float32(camera3D.Config.ScreenHeight))
+	return Unproject(camera3D.View, camera3D.Projection, nx, ny, depth*2-1)
+}
+
+func (camera3D *Camera3D) ScreenRay(x, y float32) (mgl32.Vec3, mgl32.Vec3) {
+	nx, ny := ScreenToNDC(x, y, float32(camera3D.Config.ScreenWidth), float32(camera3D.Config.ScreenHeight))
+	return Ray(camera3D.View, camera3D.Projection, nx, ny)
+}
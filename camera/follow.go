@@ -0,0 +1,129 @@
+package camera
+
+import (
+	"rapidengine/configuration"
+	"rapidengine/input"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+//  --------------------------------------------------
+//  Follow.go contains FollowCamera3D, a third person
+//  camera on a spring arm behind a target. The arm is
+//  cast against Obstacles every frame, so when something
+//  is between the camera and the target the arm snaps in
+//  to stay in front of it, and then eases back out once
+//  the way is clear.
+//  --------------------------------------------------
+
+type FollowCamera3D struct {
+	*Camera3D
+
+	// Followed target, and the offset of the point looked at from it
+	Target Positioner3D
+	Offset mgl32.Vec3
+
+	// Length the arm extends to when nothing is in the way,
+	// and the range scrolling can change it within
+	ArmLength    float32
+	MinArmLength float32
+	MaxArmLength float32
+
+	// How quickly the arm eases back out, like SmoothSpeed
+	ArmSpeed float32
+
+	// Distance kept between the camera and obstacles
+	Margin float32
+
+	// Scene the arm is cast against, or nil to never collide
+	Obstacles Raycaster
+
+	// Range of the pitch, in degrees
+	MinPitch float32
+	MaxPitch float32
+
+	arm        float32
+	lastScroll float64
+}
+
+func NewFollowCamera3D(target Positioner3D, armLength float32, config *configuration.EngineConfig) *FollowCamera3D {
+	c := &FollowCamera3D{
+		Camera3D:     NewCamera3D(mgl32.Vec3{}, 1, config),
+		Target:       target,
+		Offset:       mgl32.Vec3{0, 1, 0},
+		ArmLength:    armLength,
+		MinArmLength: 1,
+		MaxArmLength: armLength * 4,
+		ArmSpeed:     0.1,
+		Margin:       0.2,
+		MinPitch:     -80,
+		MaxPitch:     60,
+		arm:          armLength,
+	}
+	c.Yaw = -90
+	c.Pitch = -15
+	return c
+}
+
+func (follow *FollowCamera3D) Look(delta float64) {
+	pivot := follow.Offset
+	if follow.Target != nil {
+		pivot = pivot.Add(mgl32.Vec3{follow.Target.GetX(), follow.Target.GetY(), follow.Target.GetZ()})
+	}
+
+	follow.FrontAxis = CalculateDirection(follow.Pitch, follow.Yaw).Normalize()
+	back := follow.FrontAxis.Mul(-1)
+
+	// Longest the arm can be without passing through an obstacle
+	length := follow.ArmLength
+	if follow.Obstacles != nil {
+		if d, hit := follow.Obstacles.Raycast(pivot, back, follow.ArmLength+follow.Margin); hit {
+			length = mgl32.Clamp(d-follow.Margin, 0, follow.ArmLength)
+		}
+	}
+
+	// Snap in so the camera is never behind an obstacle, but ease out
+	if length < follow.arm {
+		follow.arm = length
+	} else {
+		follow.arm += (length - follow.arm) * mgl32.Clamp(follow.ArmSpeed*float32(delta)*60, 0, 1)
+	}
+
+	follow.Position = pivot.Add(back.Mul(follow.arm))
+	follow.lookAt(delta, follow.Position, pivot)
+}
+
+// DefaultControls turns the camera around the target with
+// the mouse, and changes the arm length with the scroll wheel
+func (follow *FollowCamera3D) DefaultControls(inputs *input.Input) {
+	follow.ProcessMouse(inputs.MouseX, inputs.MouseY, inputs.LastMouseX, inputs.LastMouseY)
+
+	follow.ArmLength -= float32(inputs.Scroll - follow.lastScroll)
+	follow.ArmLength = mgl32.Clamp(follow.ArmLength, follow.MinArmLength, follow.MaxArmLength)
+	follow.lastScroll = inputs.Scroll
+}
+
+func (follow *FollowCamera3D) ProcessMouse(mouseX, mouseY, lastMouseX, lastMouseY float64) {
+	follow.Yaw += float32((mouseX - lastMouseX) * follow.Sensitivity)
+	follow.Pitch -= float32((mouseY - lastMouseY) * follow.Sensitivity)
+	follow.clampPitch()
+}
+
+func (follow *FollowCamera3D) ChangeYaw(y float32) {
+	follow.Yaw += y
+}
+
+func (follow *FollowCamera3D) ChangePitch(p float32) {
+	follow.Pitch += p
+	follow.clampPitch()
+}
+
+func (follow *FollowCamera3D) clampPitch() {
+	follow.Pitch = mgl32.Clamp(follow.Pitch, follow.MinPitch, follow.MaxPitch)
+}
+
+// SetPosition stops following the target and looks at a fixed point
+func (follow *FollowCamera3D) SetPosition(x, y, z float32) {
+	follow.Target = nil
+	follow.Offset = mgl32.Vec3{x, y, z}
+}
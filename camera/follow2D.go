@@ -0,0 +1,155 @@
+package camera
+
+import (
+	"math"
+
+	"rapidengine/configuration"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+//  --------------------------------------------------
+//  Follow2D.go contains FollowCamera2D, which keeps a
+//  target such as a Child2D on screen. The target can
+//  move freely inside a dead zone around the center
+//  before the camera follows, the camera leads the
+//  target in the direction it's moving, and the view is
+//  kept inside the bounds of the world.
+//  --------------------------------------------------
+
+type FollowCamera2D struct {
+	*Camera2D
+
+	// Followed target, and the offset of the point followed from
+	// its position, such as half its size to follow its center
+	Target  Positioner
+	OffsetX float32
+	OffsetY float32
+
+	// Half the size of the area around the center the target
+	// can move in without the camera following, in pixels
+	DeadZoneX float32
+	DeadZoneY float32
+
+	// Pixels the camera leads the target by per pixel it moves
+	// each frame, how quickly the lead changes, and its limit
+	LookAhead      float32
+	LookAheadSpeed float32
+	MaxLookAhead   float32
+
+	// Area of the world the view is kept inside, in pixels
+	Bounded bool
+	MinX    float32
+	MinY    float32
+	MaxX    float32
+	MaxY    float32
+
+	focus       mgl32.Vec2
+	last        mgl32.Vec2
+	ahead       mgl32.Vec2
+	initialized bool
+}
+
+func NewFollowCamera2D(target Positioner, config *configuration.EngineConfig) *FollowCamera2D {
+	return &FollowCamera2D{
+		Camera2D:       NewCamera2D(mgl32.Vec3{0, 0, 0}, 1, config),
+		Target:         target,
+		DeadZoneX:      50,
+		DeadZoneY:      50,
+		LookAhead:      10,
+		LookAheadSpeed: 0.05,
+		MaxLookAhead:   150,
+	}
+}
+
+// SetBounds keeps the view inside an area of the world
+func (follow *FollowCamera2D) SetBounds(minX, minY, maxX, maxY float32) {
+	follow.Bounded = true
+	follow.MinX, follow.MinY = minX, minY
+	follow.MaxX, follow.MaxY = maxX, maxY
+}
+
+// ClearBounds lets the view go anywhere
+func (follow *FollowCamera2D) ClearBounds() {
+	follow.Bounded = false
+}
+
+func (follow *FollowCamera2D) Look(delta float64) {
+	if follow.Target == nil {
+		follow.Camera2D.Look(delta)
+		return
+	}
+
+	pos := mgl32.Vec2{follow.Target.GetX() + follow.OffsetX, follow.Target.GetY() + follow.OffsetY}
+	if !follow.initialized {
+		follow.focus = pos
+		follow.last = pos
+	}
+
+	// Drag the focus along once the target leaves the dead zone
+	follow.focus[0] = dragFocus(follow.focus[0], pos[0], follow.DeadZoneX)
+	follow.focus[1] = dragFocus(follow.focus[1], pos[1], follow.DeadZoneY)
+
+	// Ease the lead toward the target's velocity
+	step := mgl32.Clamp(follow.LookAheadSpeed*float32(delta)*60, 0, 1)
+	for i := 0; i < 2; i++ {
+		want := mgl32.Clamp((pos[i]-follow.last[i])*follow.LookAhead, -follow.MaxLookAhead, follow.MaxLookAhead)
+		follow.ahead[i] += (want - follow.ahead[i]) * step
+	}
+	follow.last = pos
+
+	center := follow.focus.Add(follow.ahead)
+	if follow.Bounded {
+		center = follow.clamp(center)
+	}
+
+	follow.SetPosition(center[0], center[1], 0)
+	if !follow.initialized {
+		follow.Position = follow.TargetPosition
+		follow.initialized = true
+	}
+
+	follow.Camera2D.Look(delta)
+}
+
+// dragFocus moves focus just far enough for target to be
+// within zone of it
+func dragFocus(focus, target, zone float32) float32 {
+	if target > focus+zone {
+		return target - zone
+	}
+	if target < focus-zone {
+		return target + zone
+	}
+	return focus
+}
+
+// clamp moves a center point so the view stays inside the bounds.
+// Bounds smaller than the view are centered instead.
+func (follow *FollowCamera2D) clamp(center mgl32.Vec2) mgl32.Vec2 {
+	zoom := follow.Zoom
+	if zoom <= 0 {
+		zoom = 1
+	}
+	half := mgl32.Vec2{
+		float32(follow.config.ScreenWidth) / 2 / zoom,
+		float32(follow.config.ScreenHeight) / 2 / zoom,
+	}
+	min := mgl32.Vec2{follow.MinX, follow.MinY}
+	max := mgl32.Vec2{follow.MaxX, follow.MaxY}
+
+	for i := 0; i < 2; i++ {
+		if max[i]-min[i] < half[i]*2 {
+			center[i] = (min[i] + max[i]) / 2
+		} else {
+			center[i] = float32(math.Max(float64(min[i]+half[i]), math.Min(float64(center[i]), float64(max[i]-half[i]))))
+		}
+	}
+	return center
+}
+
+// Snap moves the camera straight to the target, such as after a teleport
+func (follow *FollowCamera2D) Snap() {
+	follow.initialized = false
+	follow.ahead = mgl32.Vec2{}
+}
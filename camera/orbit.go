@@ -0,0 +1,139 @@
+package camera
+
+import (
+	"rapidengine/configuration"
+	"rapidengine/input"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+//  --------------------------------------------------
+//  Orbit.go contains OrbitCamera, which circles a target
+//  point at a distance. Dragging with the left mouse
+//  button orbits around the target, and scrolling zooms
+//  in and out.
+//  --------------------------------------------------
+
+type OrbitCamera struct {
+	*Camera3D
+
+	// Point orbited around, or the position of Follow if it's set
+	Target mgl32.Vec3
+	Follow Positioner3D
+
+	// Distance from the target, and the range scrolling can zoom within
+	Distance    float32
+	MinDistance float32
+	MaxDistance float32
+
+	// Fraction of the distance zoomed per scroll step
+	ZoomSpeed float32
+
+	// Range of the pitch, in degrees
+	MinPitch float32
+	MaxPitch float32
+
+	lastScroll float64
+}
+
+func NewOrbitCamera(target mgl32.Vec3, distance float32, config *configuration.EngineConfig) *OrbitCamera {
+	c := &OrbitCamera{
+		Camera3D:    NewCamera3D(target, 1, config),
+		Target:      target,
+		Distance:    distance,
+		MinDistance: 1,
+		MaxDistance: 1000,
+		ZoomSpeed:   0.1,
+		MinPitch:    -89,
+		MaxPitch:    89,
+	}
+	c.Yaw = -90
+	c.Pitch = -20
+	return c
+}
+
+func (orbit *OrbitCamera) Look(delta float64) {
+	if orbit.Follow != nil {
+		orbit.Target = mgl32.Vec3{orbit.Follow.GetX(), orbit.Follow.GetY(), orbit.Follow.GetZ()}
+	}
+
+	orbit.FrontAxis = CalculateDirection(orbit.Pitch, orbit.Yaw).Normalize()
+	orbit.Position = orbit.Target.Sub(orbit.FrontAxis.Mul(orbit.Distance))
+
+	orbit.lookAt(delta, orbit.Position, orbit.Target)
+}
+
+// DefaultControls orbits while the left mouse button is held,
+// and zooms with the scroll wheel
+func (orbit *OrbitCamera) DefaultControls(inputs *input.Input) {
+	if inputs.LeftMouseButton {
+		orbit.ProcessMouse(inputs.MouseX, inputs.MouseY, inputs.LastMouseX, inputs.LastMouseY)
+	}
+
+	orbit.Zoom(float32(inputs.Scroll - orbit.lastScroll))
+	orbit.lastScroll = inputs.Scroll
+}
+
+func (orbit *OrbitCamera) ProcessMouse(mouseX, mouseY, lastMouseX, lastMouseY float64) {
+	orbit.Yaw += float32((mouseX - lastMouseX) * orbit.Sensitivity)
+	orbit.Pitch -= float32((mouseY - lastMouseY) * orbit.Sensitivity)
+	orbit.clampPitch()
+}
+
+// Zoom moves toward the target by a number of scroll steps,
+// or away from it if steps is negative
+func (orbit *OrbitCamera) Zoom(steps float32) {
+	orbit.Distance *= 1 - steps*orbit.ZoomSpeed
+	orbit.Distance = mgl32.Clamp(orbit.Distance, orbit.MinDistance, orbit.MaxDistance)
+}
+
+func (orbit *OrbitCamera) clampPitch() {
+	orbit.Pitch = mgl32.Clamp(orbit.Pitch, orbit.MinPitch, orbit.MaxPitch)
+}
+
+//  --------------------------------------------------
+//  Movement
+//  --------------------------------------------------
+
+// MoveForward and MoveBackward zoom, and the other
+// movements orbit by Speed degrees
+
+func (orbit *OrbitCamera) MoveForward() {
+	orbit.Zoom(1)
+}
+
+func (orbit *OrbitCamera) MoveBackward() {
+	orbit.Zoom(-1)
+}
+
+func (orbit *OrbitCamera) MoveLeft() {
+	orbit.Yaw -= orbit.Speed
+}
+
+func (orbit *OrbitCamera) MoveRight() {
+	orbit.Yaw += orbit.Speed
+}
+
+func (orbit *OrbitCamera) MoveUp() {
+	orbit.Pitch += orbit.Speed
+	orbit.clampPitch()
+}
+
+func (orbit *OrbitCamera) MoveDown() {
+	orbit.Pitch -= orbit.Speed
+	orbit.clampPitch()
+}
+
+func (orbit *OrbitCamera) ChangeYaw(y float32) {
+	orbit.Yaw += y
+}
+
+func (orbit *OrbitCamera) ChangePitch(p float32) {
+	orbit.Pitch += p
+	orbit.clampPitch()
+}
+
+// SetPosition sets the point orbited around
+func (orbit *OrbitCamera) SetPosition(x, y, z float32) {
+	orbit.Target = mgl32.Vec3{x, y, z}
+}
@@ -0,0 +1,253 @@
+package camera
+
+import (
+	"math"
+	"sort"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+//  --------------------------------------------------
+//  Path.go contains CameraPath, which moves a camera
+//  through a list of keyframes for cutscenes and
+//  flythroughs. Positions and look-at points follow a
+//  smooth spline through the keyframes, and each
+//  keyframe has an easing for the stretch leading up
+//  to it.
+//
+//  Any camera can follow a path. 3D cameras are moved
+//  and turned toward the look-at points, and 2D cameras
+//  are centered on the X and Y of each position, in
+//  pixels. Update must be called every frame:
+//
+//    path.Play(camera)
+//    ...
+//    path.Update(delta)
+//  --------------------------------------------------
+
+// Easing maps the fraction of a stretch of path that's
+// passed, from 0 to 1, to how far along it the camera is
+type Easing func(t float32) float32
+
+func EaseLinear(t float32) float32 {
+	return t
+}
+
+func EaseInQuad(t float32) float32 {
+	return t * t
+}
+
+func EaseOutQuad(t float32) float32 {
+	return t * (2 - t)
+}
+
+func EaseInOutQuad(t float32) float32 {
+	if t < 0.5 {
+		return 2 * t * t
+	}
+	return -1 + (4-2*t)*t
+}
+
+func EaseInOutCubic(t float32) float32 {
+	if t < 0.5 {
+		return 4 * t * t * t
+	}
+	t = 2*t - 2
+	return 1 + t*t*t/2
+}
+
+func EaseInOutSine(t float32) float32 {
+	return float32(0.5 - math.Cos(float64(t)*math.Pi)/2)
+}
+
+// LookAter is implemented by cameras that can be turned toward a point
+type LookAter interface {
+	LookAt(target mgl32.Vec3)
+}
+
+type Keyframe struct {
+	// Seconds from the start of the path
+	Time float64
+
+	Position mgl32.Vec3
+	LookAt   mgl32.Vec3
+
+	// Easing from the previous keyframe, or nil for linear
+	Ease Easing
+}
+
+type CameraPath struct {
+	Keyframes []Keyframe
+
+	// Whether the path starts again after the last keyframe
+	Loop bool
+
+	// Playback rate, where 1 is normal speed
+	Speed float64
+
+	// Called when a path that doesn't loop reaches its end
+	OnFinish func()
+
+	camera  Camera
+	time    float64
+	playing bool
+}
+
+func NewCameraPath() *CameraPath {
+	return &CameraPath{
+		Speed: 1,
+	}
+}
+
+// AddKeyframe adds a keyframe, keeping the keyframes in order of time
+func (path *CameraPath) AddKeyframe(time float64, position, lookAt mgl32.Vec3, ease Easing) {
+	path.Keyframes = append(path.Keyframes, Keyframe{
+		Time:     time,
+		Position: position,
+		LookAt:   lookAt,
+		Ease:     ease,
+	})
+	sort.SliceStable(path.Keyframes, func(i, j int) bool {
+		return path.Keyframes[i].Time < path.Keyframes[j].Time
+	})
+}
+
+// Duration returns the time of the last keyframe
+func (path *CameraPath) Duration() float64 {
+	if len(path.Keyframes) == 0 {
+		return 0
+	}
+	return path.Keyframes[len(path.Keyframes)-1].Time
+}
+
+//  --------------------------------------------------
+//  Playback
+//  --------------------------------------------------
+
+// Play starts moving a camera along the path from the beginning
+func (path *CameraPath) Play(c Camera) {
+	path.camera = c
+	path.time = 0
+	path.playing = true
+	path.apply()
+}
+
+// Stop stops the path, leaving the camera where it is
+func (path *CameraPath) Stop() {
+	path.playing = false
+}
+
+// Resume continues a stopped path from where it was stopped
+func (path *CameraPath) Resume() {
+	if path.camera != nil {
+		path.playing = true
+	}
+}
+
+// Seek jumps to a time along the path
+func (path *CameraPath) Seek(time float64) {
+	path.time = math.Max(0, math.Min(time, path.Duration()))
+	if path.camera != nil {
+		path.apply()
+	}
+}
+
+func (path *CameraPath) IsPlaying() bool {
+	return path.playing
+}
+
+// GetTime returns how far along the path playback is, in seconds
+func (path *CameraPath) GetTime() float64 {
+	return path.time
+}
+
+// Update advances the path and moves the camera
+func (path *CameraPath) Update(delta float64) {
+	if !path.playing {
+		return
+	}
+
+	path.time += delta * path.Speed
+
+	finished := false
+	if duration := path.Duration(); path.time >= duration {
+		if path.Loop && duration > 0 {
+			path.time = math.Mod(path.time, duration)
+		} else {
+			path.time = duration
+			path.playing = false
+			finished = true
+		}
+	}
+
+	path.apply()
+
+	if finished && path.OnFinish != nil {
+		path.OnFinish()
+	}
+}
+
+func (path *CameraPath) apply() {
+	if len(path.Keyframes) == 0 {
+		return
+	}
+
+	position, lookAt := path.Sample(path.time)
+	path.camera.SetPosition(position.X(), position.Y(), position.Z())
+	if l, ok := path.camera.(LookAter); ok {
+		l.LookAt(lookAt)
+	}
+}
+
+//  --------------------------------------------------
+//  Sampling
+//  --------------------------------------------------
+
+// Sample returns the position and look-at point at a time along the path
+func (path *CameraPath) Sample(time float64) (mgl32.Vec3, mgl32.Vec3) {
+	keys := path.Keyframes
+	if len(keys) == 0 {
+		return mgl32.Vec3{}, mgl32.Vec3{}
+	}
+	if time <= keys[0].Time {
+		return keys[0].Position, keys[0].LookAt
+	}
+	if time >= keys[len(keys)-1].Time {
+		return keys[len(keys)-1].Position, keys[len(keys)-1].LookAt
+	}
+
+	// Keyframe at the end of the stretch containing time
+	i := sort.Search(len(keys), func(i int) bool { return keys[i].Time > time })
+	from, to := keys[i-1], keys[i]
+
+	t := float32(0)
+	if to.Time > from.Time {
+		t = float32((time - from.Time) / (to.Time - from.Time))
+	}
+	if to.Ease != nil {
+		t = to.Ease(t)
+	}
+
+	// Neighbouring keyframes shape the curve, repeating the ends
+	before, after := from, to
+	if i > 1 {
+		before = keys[i-2]
+	}
+	if i < len(keys)-1 {
+		after = keys[i+1]
+	}
+
+	return catmullRom(before.Position, from.Position, to.Position, after.Position, t),
+		catmullRom(before.LookAt, from.LookAt, to.LookAt, after.LookAt, t)
+}
+
+// catmullRom returns the point at t on the spline from p1 to p2
+func catmullRom(p0, p1, p2, p3 mgl32.Vec3, t float32) mgl32.Vec3 {
+	t2 := t * t
+	t3 := t2 * t
+	return p1.Mul(2).
+		Add(p2.Sub(p0).Mul(t)).
+		Add(p0.Mul(2).Sub(p1.Mul(5)).Add(p2.Mul(4)).Sub(p3).Mul(t2)).
+		Add(p1.Mul(3).Sub(p0).Sub(p2.Mul(3)).Add(p3).Mul(t3)).
+		Mul(0.5)
+}
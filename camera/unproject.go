@@ -0,0 +1,38 @@
+package camera
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+//  --------------------------------------------------
+//  Unproject.go converts positions on the screen back
+//  into the world, for picking and aiming with the
+//  mouse. Screen positions are in pixels from the top
+//  left, like the mouse, and normalized device
+//  coordinates are from -1 to 1 with Y pointing up.
+//  --------------------------------------------------
+
+// ScreenToNDC converts a position in pixels from the top left of an
+// area of width by height to normalized device coordinates
+func ScreenToNDC(x, y, width, height float32) (float32, float32) {
+	return 2*x/width - 1, 1 - 2*y/height
+}
+
+// Unproject returns the world position of a point in normalized device
+// coordinates, for a view and projection. ndcZ is -1 at the near plane
+// and 1 at the far plane.
+func Unproject(view, projection mgl32.Mat4, ndcX, ndcY, ndcZ float32) mgl32.Vec3 {
+	p := projection.Mul4(view).Inv().Mul4x1(mgl32.Vec4{ndcX, ndcY, ndcZ, 1})
+	if p.W() == 0 {
+		return p.Vec3()
+	}
+	return p.Vec3().Mul(1 / p.W())
+}
+
+// Ray returns the origin on the near plane and the normalized
+// direction of the ray through a point in normalized device coordinates
+func Ray(view, projection mgl32.Mat4, ndcX, ndcY float32) (mgl32.Vec3, mgl32.Vec3) {
+	near := Unproject(view, projection, ndcX, ndcY, -1)
+	far := Unproject(view, projection, ndcX, ndcY, 1)
+	return near, far.Sub(near).Normalize()
+}
@@ -1,9 +1,13 @@
 package child
 
 import "rapidengine/camera"
+import "rapidengine/geometry"
 import "rapidengine/material"
 
 import "rapidengine/physics"
+import "rapidengine/render"
+
+import "github.com/go-gl/mathgl/mgl32"
 
 type ChildCopy struct {
 	X        float32
@@ -12,6 +16,17 @@ type ChildCopy struct {
 	Material material.Material
 	Darkness float32
 
+	// Rotation and scale of 3D copies. The zero quaternion is no
+	// rotation. If every scale is zero, the copy uses the child's
+	// scale.
+	Rotation mgl32.Quat
+	ScaleX   float32
+	ScaleY   float32
+	ScaleZ   float32
+
+	// Tile of the material's texture atlas, used by instanced copies
+	AtlasIndex int
+
 	ID string
 }
 type Child interface {
@@ -48,7 +63,61 @@ type Child interface {
 
 	Update(camera.Camera, float64, float64)
 
+	SaveState()
+	Step(float64)
+	Interpolate(float32)
+
 	Activate()
 	Deactivate()
 	IsActive() bool
 }
+
+// Submitter is implemented by children that can add their
+// draws to a render queue, rather than drawing immediately
+type Submitter interface {
+	Submit(q *render.Queue, mainCamera camera.Camera, delta float64, totalTime float64)
+}
+
+// CopySubmitter is implemented by children that can add
+// the draws of their copies to a render queue
+type CopySubmitter interface {
+	SubmitCopy(q *render.Queue, config ChildCopy, mainCamera camera.Camera)
+}
+
+// Bounded is implemented by children with bounding volumes,
+// which the renderer uses to cull them against the camera
+type Bounded interface {
+	// GetWorldBounds returns the child's bounds in world
+	// space, and false if it has none
+	GetWorldBounds() (geometry.AABB, geometry.Sphere, bool)
+
+	// GetCopyBounds returns the bounds of a copy of the child
+	GetCopyBounds(ChildCopy) (geometry.AABB, geometry.Sphere, bool)
+
+	// GetProjection returns the projection the child is drawn with
+	// through a camera
+	GetProjection(camera.Camera) mgl32.Mat4
+}
+
+// CopyIndexer is implemented by children that index their copies by
+// position, so the copies in an area can be found without a full scan
+type CopyIndexer interface {
+	// QueryCopies appends the indices of copies in and around
+	// the rectangle to out, in ascending order
+	QueryCopies(minX, minY, maxX, maxY float32, out []int) []int
+}
+
+// Layered is implemented by children on a render layer, from 0 to 31.
+// Views only draw children on their layers. Other children are on
+// layer 0.
+type Layered interface {
+	GetLayer() int
+}
+
+// Instancer is implemented by children that can draw all
+// of their copies with a single instanced draw call
+type Instancer interface {
+	CheckInstancingEnabled() bool
+	RenderInstances(mainCamera camera.Camera)
+	SubmitInstances(q *render.Queue, mainCamera camera.Camera)
+}
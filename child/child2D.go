@@ -7,14 +7,19 @@ package child
 // --------------------------------------------------
 
 import (
+	"math"
+
 	"github.com/go-gl/gl/v4.1-core/gl"
 	"github.com/go-gl/mathgl/mgl32"
 
+	"rapidengine/backend"
 	"rapidengine/camera"
 	"rapidengine/configuration"
 	"rapidengine/geometry"
 	"rapidengine/material"
 	"rapidengine/physics"
+	"rapidengine/render"
+	"rapidengine/spatial"
 )
 
 type Child2D struct {
@@ -29,26 +34,66 @@ type Child2D struct {
 	projectionMatrix mgl32.Mat4
 	Static           bool
 
+	// Set when the projection is set with SetProjection,
+	// rather than read from the camera every frame
+	customProjection bool
+
 	numCopies      int
 	copies         []ChildCopy
 	currentCopies  []ChildCopy
 	copyingEnabled bool
 
+	// Index of copies by position, by their index in copies
+	copyIndex *spatial.Grid
+
 	instancingEnabled bool
 	numInstances      int
 
+	// Per-instance vertex buffer of copy offsets, atlas indices
+	// and darkness, re-uploaded when instancesDirty is set
+	instanceBuffer uint32
+	instanceData   []float32
+	instancesDirty bool
+
+	// Whether the child and its copies are drawn by the sprite batcher
+	batchingEnabled bool
+
 	specificRenderDistance float32
 
 	X float32
 	Y float32
 
+	// Position at the previous fixed step, and how far
+	// between it and the current position to render
+	prevX float32
+	prevY float32
+	alpha float32
+
+	// Rendered position when the node was last marked dirty
+	rendered mgl32.Vec2
+
 	VX float32
 	VY float32
 
 	Gravity float32
 
-	ScaleX float32
-	ScaleY float32
+	// Position, rotation and size of the child. The scale is its
+	// width and height in pixels, and it turns about its center,
+	// about Z. X and Y are the same position as Transform.Position,
+	// and moving the child through either is seen by the other when
+	// it's next stepped or drawn, or by GetX and GetY.
+	Transform geometry.Transform
+
+	// Position X, Y and Transform.Position last agreed on
+	syncedPosition mgl32.Vec3
+
+	// Render layer, see Layered
+	Layer int
+
+	// Place in the scene graph, see Node. Positions and rotations
+	// are passed down, but not scales, since a 2D child's scale
+	// is its size.
+	node *Node
 
 	Group          string
 	collider       physics.Collider
@@ -67,34 +112,37 @@ func NewChild2D(config *configuration.EngineConfig) *Child2D {
 		VX:                     0,
 		VY:                     0,
 		Gravity:                0,
-		ScaleX:                 1,
-		ScaleY:                 1,
+		Transform:              geometry.NewTransform(0, 0, 0, 1, 1, 1),
 		copyingEnabled:         false,
 		specificRenderDistance: 0,
 		Darkness:               1,
+		alpha:                  1,
+		copyIndex:              spatial.NewGrid(spatial.DefaultCellSize),
 	}
+	c.node = newNode(c, c.localMatrix)
 	return c
 }
 
 func (child2D *Child2D) PreRender(mainCamera camera.Camera) {
+	child2D.updateProjection(mainCamera)
 	child2D.BindChild()
 
-	gl.UniformMatrix4fv(
+	backend.Current.UniformMatrix4fv(
 		child2D.material.GetShader().GetUniform("modelMtx"),
 		1, false, &child2D.modelMatrix[0],
 	)
 
-	gl.UniformMatrix4fv(
+	backend.Current.UniformMatrix4fv(
 		child2D.material.GetShader().GetUniform("viewMtx"),
 		1, false, mainCamera.GetFirstViewIndex(),
 	)
 
-	gl.UniformMatrix4fv(
+	backend.Current.UniformMatrix4fv(
 		child2D.material.GetShader().GetUniform("projectionMtx"),
 		1, false, &child2D.projectionMatrix[0],
 	)
 
-	gl.BindVertexArray(0)
+	backend.Current.BindVertexArray(0)
 }
 
 func (child2D *Child2D) BindChild() {
@@ -102,7 +150,21 @@ func (child2D *Child2D) BindChild() {
 }
 
 func (child2D *Child2D) Update(mainCamera camera.Camera, delta float64, totalTime float64) {
-	//cx, cy, _ := mainCamera.GetPosition()
+	child2D.Render(mainCamera, delta, totalTime)
+}
+
+// SaveState stores the current position, which is interpolated
+// from when rendering until the next fixed step
+func (child2D *Child2D) SaveState() {
+	child2D.syncPosition()
+	child2D.prevX = child2D.X
+	child2D.prevY = child2D.Y
+	child2D.markMoved()
+}
+
+// Step advances the physics of the child by one fixed step
+func (child2D *Child2D) Step(delta float64) {
+	child2D.syncPosition()
 	child2D.VY -= child2D.Gravity
 
 	/*cols := child2D.collisioncontrol.CheckCollisionWithGroup(child2D, "ground", cx, cy)
@@ -115,16 +177,30 @@ func (child2D *Child2D) Update(mainCamera camera.Camera, delta float64, totalTim
 
 	//child2D.X += child2D.VX * -float32(delta)
 	//child2D.Y += child2D.VY * float32(delta)
+	child2D.markMoved()
+}
 
-	child2D.Render(mainCamera, delta, totalTime)
+// Interpolate sets how far between the previous and current
+// fixed step the child is rendered, from 0 to 1
+func (child2D *Child2D) Interpolate(alpha float32) {
+	child2D.alpha = alpha
+	child2D.markMoved()
 }
 
-func (child2D *Child2D) Render(mainCamera camera.Camera, delta float64, totalTime float64) {
-	sX, sY := ScaleTranslation(child2D.X, child2D.Y, float32(child2D.config.ScreenWidth), float32(child2D.config.ScreenHeight))
-	child2D.modelMatrix = mgl32.Translate3D(sX, sY, 0)
+// markMoved marks the node dirty if the rendered position
+// has changed since it was last marked
+func (child2D *Child2D) markMoved() {
+	child2D.syncPosition()
+	x, y := child2D.renderPosition()
+	if (mgl32.Vec2{x, y}) != child2D.rendered {
+		child2D.rendered = mgl32.Vec2{x, y}
+		child2D.node.MarkDirty()
+	}
+}
 
-	scaleX, scaleY := ScaleTransformation(child2D.ScaleX, child2D.ScaleY, float32(child2D.config.ScreenWidth), float32(child2D.config.ScreenHeight))
-	child2D.modelMatrix = child2D.modelMatrix.Mul4(mgl32.Scale3D(scaleX, scaleY, 0))
+func (child2D *Child2D) Render(mainCamera camera.Camera, delta float64, totalTime float64) {
+	child2D.updateProjection(mainCamera)
+	child2D.updateModelMatrix(child2D.node.World())
 
 	if !child2D.Static {
 		child2D.Mesh.Render(child2D.material, mainCamera.GetFirstViewIndex(), &child2D.modelMatrix[0], &child2D.projectionMatrix[0], delta, totalTime, 1)
@@ -135,15 +211,170 @@ func (child2D *Child2D) Render(mainCamera camera.Camera, delta float64, totalTim
 }
 
 func (child2D *Child2D) RenderCopy(config ChildCopy, mainCamera camera.Camera) {
-	sX, sY := ScaleTranslation(config.X, config.Y, float32(child2D.config.ScreenWidth), float32(child2D.config.ScreenHeight))
-	child2D.modelMatrix = mgl32.Translate3D(sX, sY, 0)
-
-	scaleX, scaleY := ScaleTransformation(child2D.ScaleX, child2D.ScaleY, float32(child2D.config.ScreenWidth), float32(child2D.config.ScreenHeight))
-	child2D.modelMatrix = child2D.modelMatrix.Mul4(mgl32.Scale3D(scaleX, scaleY, 0))
+	child2D.updateProjection(mainCamera)
+	child2D.updateModelMatrix(child2D.copyWorld(config.X, config.Y))
 
 	child2D.Mesh.Render(config.Material, mainCamera.GetFirstViewIndex(), &child2D.modelMatrix[0], &child2D.projectionMatrix[0], 0, 0, config.Darkness)
 }
 
+// Submit adds the child to a render queue instead of drawing it. 2D
+// children have no depth, so they're drawn in the order they're
+// submitted, and static children are drawn over everything else.
+func (child2D *Child2D) Submit(q *render.Queue, mainCamera camera.Camera, delta float64, totalTime float64) {
+	child2D.updateProjection(mainCamera)
+	if sm, ok := child2D.spriteSource(child2D.material); ok {
+		pass, view := render.PassTransparent, mainCamera.GetView()
+		if child2D.Static {
+			pass, view = render.PassOverlay, mainCamera.GetStaticView()
+		}
+		q.SubmitSprite(pass, view, child2D.projectionMatrix, 0, child2D.sprite(sm, child2D.node.World(), delta, 1, 0))
+		return
+	}
+
+	child2D.updateModelMatrix(child2D.node.World())
+
+	item := render.Item{
+		Pass:       render.PassTransparent,
+		Mesh:       &child2D.Mesh,
+		Material:   child2D.material,
+		View:       mainCamera.GetView(),
+		Model:      child2D.modelMatrix,
+		Projection: child2D.projectionMatrix,
+		Darkness:   1,
+		Delta:      delta,
+		TotalTime:  totalTime,
+	}
+	if child2D.Static {
+		item.Pass = render.PassOverlay
+		item.View = mainCamera.GetStaticView()
+	}
+
+	q.Submit(item)
+}
+
+// SubmitCopy adds a copy of the child to a render queue
+func (child2D *Child2D) SubmitCopy(q *render.Queue, config ChildCopy, mainCamera camera.Camera) {
+	child2D.updateProjection(mainCamera)
+	world := child2D.copyWorld(config.X, config.Y)
+	if sm, ok := child2D.spriteSource(config.Material); ok {
+		q.SubmitSprite(render.PassTransparent, mainCamera.GetView(), child2D.projectionMatrix, 0, child2D.sprite(sm, world, 0, config.Darkness, config.AtlasIndex))
+		return
+	}
+
+	child2D.updateModelMatrix(world)
+
+	q.Submit(render.Item{
+		Pass:       render.PassTransparent,
+		Mesh:       &child2D.Mesh,
+		Material:   config.Material,
+		View:       mainCamera.GetView(),
+		Model:      child2D.modelMatrix,
+		Projection: child2D.projectionMatrix,
+		Darkness:   config.Darkness,
+	})
+}
+
+// spriteSource returns the material as a sprite source, if batching
+// is enabled and the material can be drawn by the sprite batcher
+func (child2D *Child2D) spriteSource(m material.Material) (material.SpriteSource, bool) {
+	if !child2D.batchingEnabled {
+		return nil, false
+	}
+	sm, ok := m.(material.SpriteSource)
+	return sm, ok
+}
+
+// sprite returns the child, or a copy of it, as a sprite
+func (child2D *Child2D) sprite(sm material.SpriteSource, world mgl32.Mat4, delta float64, darkness float32, atlasIndex int) render.Sprite {
+	texture, tint, uv := sm.SpriteParams(delta, atlasIndex)
+	for i := 0; i < 3; i++ {
+		tint[i] *= darkness
+	}
+
+	w, h := child2D.size()
+	x, y := quadCorner(world, w, h)
+
+	return render.Sprite{
+		X:        x,
+		Y:        y,
+		Width:    w,
+		Height:   h,
+		Rotation: float32(math.Atan2(float64(world[1]), float64(world[0]))),
+		UV:       uv,
+		Tint:     tint,
+		Texture:  texture,
+	}
+}
+
+// renderPosition returns the position interpolated between fixed steps
+func (child2D *Child2D) renderPosition() (float32, float32) {
+	return child2D.prevX + (child2D.X-child2D.prevX)*child2D.alpha,
+		child2D.prevY + (child2D.Y-child2D.prevY)*child2D.alpha
+}
+
+// localMatrix returns the child's position and rotation
+// relative to its parent
+func (child2D *Child2D) localMatrix() mgl32.Mat4 {
+	child2D.syncPosition()
+	return child2D.pivotMatrix(child2D.renderPosition())
+}
+
+// syncPosition brings X, Y and Transform.Position back together,
+// keeping any move made through either since they last agreed
+func (child2D *Child2D) syncPosition() {
+	moved := child2D.Transform.Position.Sub(child2D.syncedPosition)
+	child2D.X += moved.X()
+	child2D.Y += moved.Y()
+
+	child2D.Transform.Position = mgl32.Vec3{child2D.X, child2D.Y, 0}
+	child2D.syncedPosition = child2D.Transform.Position
+}
+
+// pivotMatrix places a quad of the child's size at a position,
+// turned by the child's rotation about its center
+func (child2D *Child2D) pivotMatrix(x, y float32) mgl32.Mat4 {
+	w, h := child2D.size()
+	m := mgl32.Translate3D(x+w/2, y+h/2, 0)
+	m = m.Mul4(child2D.Transform.Rotation.Normalize().Mat4())
+	return m.Mul4(mgl32.Translate3D(-w/2, -h/2, 0))
+}
+
+// copyWorld returns the transform in the world of a copy at a
+// position relative to the child's parent
+func (child2D *Child2D) copyWorld(x, y float32) mgl32.Mat4 {
+	return child2D.node.ParentWorld().Mul4(child2D.pivotMatrix(x, y))
+}
+
+// size returns the width and height of the child
+func (child2D *Child2D) size() (float32, float32) {
+	return child2D.Transform.Scale.X(), child2D.Transform.Scale.Y()
+}
+
+// worldPosition returns the rendered position of the child in the
+// world, where its bottom left corner would be without rotation
+func (child2D *Child2D) worldPosition() (float32, float32) {
+	w, h := child2D.size()
+	return quadCorner(child2D.node.World(), w, h)
+}
+
+// quadCorner returns the bottom left corner of a w by h quad
+// placed by a world transform, before it's rotated
+func quadCorner(world mgl32.Mat4, w, h float32) (float32, float32) {
+	c := world.Mul4x1(mgl32.Vec4{w / 2, h / 2, 0, 1})
+	return c.X() - w/2, c.Y() - h/2
+}
+
+// updateModelMatrix sets the model matrix to draw the child's mesh,
+// sized to the child, with a transform in the world in pixels
+func (child2D *Child2D) updateModelMatrix(world mgl32.Mat4) {
+	sw, sh := float32(child2D.config.ScreenWidth), float32(child2D.config.ScreenHeight)
+	w, h := child2D.size()
+
+	// Pixels to the -1 to 1 range
+	m := mgl32.Translate3D(-1, -1, 0).Mul4(mgl32.Scale3D(2/sw, 2/sh, 1))
+	child2D.modelMatrix = m.Mul4(world).Mul4(mgl32.Scale3D(w, h, 0))
+}
+
 func (child2D *Child2D) CheckCollision(other Child) int {
 	return child2D.collider.CheckCollision(child2D.X, child2D.Y, child2D.VX, child2D.VY, other.GetX(), other.GetY(), other.GetCollider())
 }
@@ -162,6 +393,11 @@ func (child2D *Child2D) AttachCollider(x, y, w, h float32) {
 
 func (child2D *Child2D) AttachMesh(p geometry.Mesh) {
 	child2D.Mesh = p
+
+	// The instance buffer belongs to the old mesh's VAO
+	child2D.instanceBuffer = 0
+	child2D.instancesDirty = true
+	child2D.Mesh.InstancingEnabled = child2D.instancingEnabled
 }
 
 func (child2D *Child2D) AttachMaterial(m material.Material) {
@@ -193,9 +429,29 @@ func (child2D *Child2D) SetVelocity(vx, vy float32) {
 	child2D.VY = vy
 }
 
+// SetPosition moves the child without interpolating
+// from its previous position
 func (child2D *Child2D) SetPosition(x, y float32) {
 	child2D.X = x
 	child2D.Y = y
+	child2D.prevX = x
+	child2D.prevY = y
+	child2D.Transform.SetPosition(x, y, 0)
+	child2D.syncedPosition = child2D.Transform.Position
+	child2D.node.MarkDirty()
+}
+
+// SetRotation turns the child about its center, by an angle in
+// radians counterclockwise
+func (child2D *Child2D) SetRotation(angle float32) {
+	child2D.Transform.SetRotation(mgl32.QuatRotate(angle, mgl32.Vec3{0, 0, 1}))
+	child2D.node.MarkDirty()
+}
+
+// SetScale sets the width and height of the child, in pixels
+func (child2D *Child2D) SetScale(width, height float32) {
+	child2D.Transform.SetScale(width, height, 1)
+	child2D.node.MarkDirty()
 }
 
 func (child2D *Child2D) SetSpecificRenderDistance(d float32) {
@@ -210,22 +466,76 @@ func (child2D *Child2D) GetShaderProgram() *material.ShaderProgram {
 	return child2D.material.GetShader()
 }
 
+func (child2D *Child2D) GetMaterial() material.Material {
+	return child2D.material
+}
+
 func (child2D *Child2D) GetCollider() *physics.Collider {
 	return &child2D.collider
 }
 
 func (child2D *Child2D) GetX() float32 {
+	child2D.syncPosition()
 	return child2D.X
 }
 
 func (child2D *Child2D) GetY() float32 {
+	child2D.syncPosition()
 	return child2D.Y
 }
 
+// ScaleX and ScaleY return the child's width and height in
+// pixels, which are set with SetScale
+func (child2D *Child2D) ScaleX() float32 {
+	return child2D.Transform.Scale.X()
+}
+
+func (child2D *Child2D) ScaleY() float32 {
+	return child2D.Transform.Scale.Y()
+}
+
+// GetRotation returns the child's rotation about its
+// center, in radians counterclockwise
+func (child2D *Child2D) GetRotation() float32 {
+	_, _, z := child2D.Transform.Euler()
+	return z
+}
+
+//  --------------------------------------------------
+//  Scene Graph
+//  --------------------------------------------------
+
+func (child2D *Child2D) GetNode() *Node {
+	return child2D.node
+}
+
+// SetParent parents the child to another, or unparents it if p is
+// nil. Its position and rotation become relative to the parent's.
+func (child2D *Child2D) SetParent(p Parented) error {
+	if p == nil {
+		return child2D.node.SetParent(nil)
+	}
+	return child2D.node.SetParent(p.GetNode())
+}
+
+// GetParent returns the child's parent, or nil if it has none
+func (child2D *Child2D) GetParent() Parented {
+	return child2D.node.GetParent()
+}
+
+// GetWorldPosition returns where the child is in the world, in pixels
+func (child2D *Child2D) GetWorldPosition() (float32, float32) {
+	return child2D.worldPosition()
+}
+
 func (child2D *Child2D) GetSpecificRenderDistance() float32 {
 	return child2D.specificRenderDistance
 }
 
+func (child2D *Child2D) GetLayer() int {
+	return child2D.Layer
+}
+
 func (child2D *Child2D) GetDimensions() int {
 	return 2
 }
@@ -247,8 +557,50 @@ func (child2D *Child2D) DisableCopying() {
 }
 
 func (child2D *Child2D) AddCopy(config ChildCopy) {
+	child2D.copyIndex.Insert(len(child2D.copies), config.X, config.Y)
 	child2D.numCopies += 1
 	child2D.copies = append(child2D.copies, config)
+	child2D.instancesDirty = true
+}
+
+// MoveCopy moves the copy at index i
+func (child2D *Child2D) MoveCopy(i int, x, y float32) {
+	child2D.copies[i].X = x
+	child2D.copies[i].Y = y
+	child2D.copyIndex.Move(i, x, y)
+	child2D.instancesDirty = true
+}
+
+// SetCopy replaces the copy at index i
+func (child2D *Child2D) SetCopy(i int, config ChildCopy) {
+	child2D.copies[i] = config
+	child2D.copyIndex.Move(i, config.X, config.Y)
+	child2D.instancesDirty = true
+}
+
+// ReindexCopies rebuilds the index of copy positions, and the
+// instance buffer. It must be called after copies are changed
+// through GetCopies.
+func (child2D *Child2D) ReindexCopies() {
+	child2D.copyIndex.Clear()
+	for i, cpy := range child2D.copies {
+		child2D.copyIndex.Insert(i, cpy.X, cpy.Y)
+	}
+	child2D.numCopies = len(child2D.copies)
+	child2D.instancesDirty = true
+}
+
+// SetCopyCellSize sets the cell size of the copy index, in pixels.
+// It should be around the size of the area usually queried.
+func (child2D *Child2D) SetCopyCellSize(size float32) {
+	child2D.copyIndex = spatial.NewGrid(size)
+	child2D.ReindexCopies()
+}
+
+// QueryCopies appends the indices of copies in and around the
+// rectangle to out, in ascending order
+func (child2D *Child2D) QueryCopies(minX, minY, maxX, maxY float32, out []int) []int {
+	return child2D.copyIndex.Query(minX, minY, maxX, maxY, out)
 }
 
 func (child2D *Child2D) GetCopies() *[]ChildCopy {
@@ -295,6 +647,27 @@ func ScaleTransformation(x, y, sw, sh float32) (float32, float32) {
 	return (x / sw) * 2, (y / sh) * 2
 }
 
+//  --------------------------------------------------
+//  Sprite Batching
+//  --------------------------------------------------
+
+// EnableBatching draws the child and its copies with the renderer's
+// sprite batcher, which shares draw calls between every sprite with
+// the same texture. The child is drawn as a quad of its size,
+// whatever its mesh, and only materials implementing
+// material.SpriteSource are batched.
+func (child2D *Child2D) EnableBatching() {
+	child2D.batchingEnabled = true
+}
+
+func (child2D *Child2D) DisableBatching() {
+	child2D.batchingEnabled = false
+}
+
+func (child2D *Child2D) CheckBatchingEnabled() bool {
+	return child2D.batchingEnabled
+}
+
 //  --------------------------------------------------
 //  GL Instancing
 //  --------------------------------------------------
@@ -307,11 +680,108 @@ func (child2D *Child2D) GetNumInstances() int {
 	return child2D.numInstances
 }
 
+// EnableGLInstancing draws every copy of the child with a single
+// instanced draw call, using the child's material and the copies'
+// position, Darkness and AtlasIndex. Space is reserved for num
+// copies, but the instance buffer grows to fit all of them.
 func (child2D *Child2D) EnableGLInstancing(num int) {
 	child2D.instancingEnabled = true
-	child2D.numInstances = num
+	child2D.instanceData = make([]float32, 0, num*4)
+	child2D.instancesDirty = true
+	child2D.Mesh.InstancingEnabled = true
+}
+
+// DisableGLInstancing draws copies one by one again
+func (child2D *Child2D) DisableGLInstancing() {
+	child2D.instancingEnabled = false
+	child2D.Mesh.InstancingEnabled = false
+}
+
+// RenderInstances draws every copy with a single draw call
+func (child2D *Child2D) RenderInstances(mainCamera camera.Camera) {
+	child2D.updateProjection(mainCamera)
+	if !child2D.prepareInstances() {
+		return
+	}
+	child2D.Mesh.Render(child2D.material, mainCamera.GetFirstViewIndex(), &child2D.modelMatrix[0], &child2D.projectionMatrix[0], 0, 0, 1)
+}
+
+// SubmitInstances adds a single draw of every copy to a render queue
+func (child2D *Child2D) SubmitInstances(q *render.Queue, mainCamera camera.Camera) {
+	child2D.updateProjection(mainCamera)
+	if !child2D.prepareInstances() {
+		return
+	}
+	q.Submit(render.Item{
+		Pass:       render.PassTransparent,
+		Mesh:       &child2D.Mesh,
+		Material:   child2D.material,
+		View:       mainCamera.GetView(),
+		Model:      child2D.modelMatrix,
+		Projection: child2D.projectionMatrix,
+		Darkness:   1,
+	})
+}
+
+// prepareInstances uploads the copies if they've changed, and sets
+// the model matrix to the scale shared by every instance. It returns
+// false if there is nothing to draw.
+func (child2D *Child2D) prepareInstances() bool {
+	if child2D.instancesDirty {
+		child2D.uploadInstances()
+	}
+
+	// The copies' offsets already start from -1, so instances
+	// are moved back by half the screen, then by the parent
+	sw, sh := float32(child2D.config.ScreenWidth), float32(child2D.config.ScreenHeight)
+	child2D.updateModelMatrix(mgl32.Translate3D(sw/2, sh/2, 0).Mul4(child2D.copyWorld(0, 0)))
+
+	return child2D.numInstances > 0
+}
+
+func (child2D *Child2D) uploadInstances() {
+	if child2D.instanceBuffer == 0 {
+		backend.Current.BindVertexArray(child2D.Mesh.VAO.GetID())
+		child2D.instanceBuffer = backend.Current.GenBuffer()
+		backend.Current.BindBuffer(gl.ARRAY_BUFFER, child2D.instanceBuffer)
+		backend.Current.VertexAttribPointer(5, 4, gl.FLOAT, false, 0, 0)
+		backend.Current.EnableVertexAttribArray(5)
+		backend.Current.VertexAttribDivisor(5, 1)
+	}
+
+	sw, sh := float32(child2D.config.ScreenWidth), float32(child2D.config.ScreenHeight)
+	data := child2D.instanceData[:0]
+	for _, cpy := range child2D.copies {
+		sX, sY := ScaleTranslation(cpy.X, cpy.Y, sw, sh)
+		data = append(data, sX, sY, float32(cpy.AtlasIndex), cpy.Darkness)
+	}
+	child2D.instanceData = data
+
+	backend.Current.BindBuffer(gl.ARRAY_BUFFER, child2D.instanceBuffer)
+	backend.Current.BufferFloats(gl.ARRAY_BUFFER, data, gl.DYNAMIC_DRAW)
+
+	child2D.numInstances = len(child2D.copies)
+	child2D.Mesh.NumInstances = child2D.numInstances
+	child2D.instancesDirty = false
 }
 
+// SetProjection sets the child's own projection, which
+// is used instead of the camera's
 func (child2D *Child2D) SetProjection(proj mgl32.Mat4) {
 	child2D.projectionMatrix = proj
+	child2D.customProjection = true
+}
+
+// updateProjection reads the camera's projection, so camera zoom
+// applies to the child. Static children, and children in a 3D
+// scene, keep the whole screen.
+func (child2D *Child2D) updateProjection(mainCamera camera.Camera) {
+	if child2D.customProjection {
+		return
+	}
+	if child2D.Static || child2D.config.Dimensions != 2 {
+		child2D.projectionMatrix = mgl32.Ortho2D(-1, 1, -1, 1)
+		return
+	}
+	child2D.projectionMatrix = mainCamera.GetProjection()
 }
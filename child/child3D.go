@@ -1,7 +1,6 @@
 package child
 
 import (
-	"github.com/go-gl/gl/v4.1-core/gl"
 	"github.com/go-gl/mathgl/mgl32"
 
 	"rapidengine/camera"
@@ -9,6 +8,7 @@ import (
 	"rapidengine/geometry"
 	"rapidengine/material"
 	"rapidengine/physics"
+	"rapidengine/render"
 )
 
 type Child3D struct {
@@ -23,52 +23,85 @@ type Child3D struct {
 	modelMatrix      mgl32.Mat4
 	projectionMatrix mgl32.Mat4
 
+	// Far plane used instead of the camera's, if set
+	farPlane float32
+
+	// Bounds of the model in world space, updated with the model matrix
+	worldBounds geometry.AABB
+	worldSphere geometry.Sphere
+
 	copies         []ChildCopy
 	currentCopies  []ChildCopy
 	copyingEnabled bool
 
+	// Copies grouped by material override, each drawn with
+	// one instanced call per mesh. Rebuilt when copiesDirty is set.
+	instancingEnabled bool
+	copyBatches       []*copyBatch
+	batchLookup       map[material.Material]*copyBatch
+	copiesDirty       bool
+
 	X float32
 	Y float32
 	Z float32
 
+	// Position at the previous fixed step, and how far
+	// between it and the current position to render
+	prevX float32
+	prevY float32
+	prevZ float32
+	alpha float32
+
+	// Rendered position when the node was last marked dirty
+	rendered mgl32.Vec3
+
 	VX float32
 	VY float32
 	VZ float32
 
-	RX float32
-	RY float32
-	RZ float32
+	// Position, rotation and scale of the child. X, Y and Z
+	// are the same position as Transform.Position, and moving
+	// the child through either is seen by the other when it's
+	// next stepped or drawn, or by GetX, GetY and GetZ.
+	Transform geometry.Transform
 
-	ScaleX float32
-	ScaleY float32
-	ScaleZ float32
+	// Position X, Y, Z and Transform.Position last agreed on
+	syncedPosition mgl32.Vec3
 
 	Gravity float32
 
+	// Render layer, see Layered
+	Layer int
+
+	// Place in the scene graph, see Node
+	node *Node
+
 	Group    string
 	collider physics.Collider
 
+	// Called when the mouse moves onto or off the child
+	mouseCollision func(bool)
+
 	specificRenderDistance float32
 
 	config *configuration.EngineConfig
 }
 
 func NewChild3D(config *configuration.EngineConfig) *Child3D {
-	return &Child3D{
-		modelMatrix: mgl32.Ident4(),
-		projectionMatrix: mgl32.Perspective(
-			mgl32.DegToRad(45),
-			float32(config.ScreenWidth)/float32(config.ScreenHeight),
-			0.1, 100000,
-		),
+	c := &Child3D{
+		modelMatrix:            mgl32.Ident4(),
+		projectionMatrix:       mgl32.Ident4(),
 		config:                 config,
 		Gravity:                0,
 		copyingEnabled:         false,
+		instancingEnabled:      true,
+		batchLookup:            make(map[material.Material]*copyBatch),
 		specificRenderDistance: 0,
-		ScaleX:                 1,
-		ScaleY:                 1,
-		ScaleZ:                 1,
+		Transform:              geometry.NewTransform(0, 0, 0, 1, 1, 1),
+		alpha:                  1,
 	}
+	c.node = newNode(c, c.localMatrix)
+	return c
 }
 
 func (child3D *Child3D) PreRender(mainCamera camera.Camera) {
@@ -76,56 +109,246 @@ func (child3D *Child3D) PreRender(mainCamera camera.Camera) {
 }
 
 func (child3D *Child3D) Update(mainCamera camera.Camera, delta float64, totalTime float64) {
+	child3D.Render(mainCamera, totalTime)
+}
+
+// SaveState stores the current position, which is interpolated
+// from when rendering until the next fixed step
+func (child3D *Child3D) SaveState() {
+	child3D.syncPosition()
+	child3D.prevX = child3D.X
+	child3D.prevY = child3D.Y
+	child3D.prevZ = child3D.Z
+	child3D.markMoved()
+}
+
+// Step advances the physics of the child by one fixed step
+func (child3D *Child3D) Step(delta float64) {
+	child3D.syncPosition()
 	child3D.VY -= child3D.Gravity
 
 	child3D.X += child3D.VX
 	child3D.Y += child3D.VY
-	child3D.X += child3D.VZ
+	child3D.Z += child3D.VZ
+	child3D.markMoved()
+}
 
-	child3D.Render(mainCamera, totalTime)
+// Interpolate sets how far between the previous and current
+// fixed step the child is rendered, from 0 to 1
+func (child3D *Child3D) Interpolate(alpha float32) {
+	child3D.alpha = alpha
+	child3D.markMoved()
 }
 
-func (child3D *Child3D) Render(mainCamera camera.Camera, totalTime float64) {
-	child3D.modelMatrix = mgl32.Translate3D(child3D.X, child3D.Y, child3D.Z)
-	child3D.modelMatrix = child3D.modelMatrix.Mul4(mgl32.Scale3D(child3D.ScaleX, child3D.ScaleY, child3D.ScaleZ))
+// markMoved marks the node dirty if the rendered position
+// has changed since it was last marked
+func (child3D *Child3D) markMoved() {
+	child3D.syncPosition()
+	if p := child3D.renderPosition(); p != child3D.rendered {
+		child3D.rendered = p
+		child3D.node.MarkDirty()
+	}
+}
 
-	child3D.modelMatrix = child3D.modelMatrix.Mul4(mgl32.HomogRotate3DX(child3D.RX))
-	child3D.modelMatrix = child3D.modelMatrix.Mul4(mgl32.HomogRotate3DY(child3D.RY))
-	child3D.modelMatrix = child3D.modelMatrix.Mul4(mgl32.HomogRotate3DZ(child3D.RZ))
+func (child3D *Child3D) Render(mainCamera camera.Camera, totalTime float64) {
+	child3D.updateModelMatrix()
+	child3D.GetProjection(mainCamera)
 
 	child3D.Model.Render(mainCamera.GetFirstViewIndex(), &child3D.modelMatrix[0], &child3D.projectionMatrix[0], totalTime)
 }
 
-func (child3D *Child3D) RenderCopy(config ChildCopy, mainCamera camera.Camera) {
-	child3D.modelMatrix = mgl32.Translate3D(config.X, config.Y, config.Z)
+// Submit adds each mesh of the child's model to a render queue
+// instead of drawing it. Meshes with a transparent material
+// are drawn in the transparent pass.
+func (child3D *Child3D) Submit(q *render.Queue, mainCamera camera.Camera, delta float64, totalTime float64) {
+	child3D.updateModelMatrix()
+	child3D.GetProjection(mainCamera)
+
+	view := mainCamera.GetView()
+	depth := render.ViewDepth(view, child3D.modelMatrix)
+
+	for i := range child3D.Model.Meshes {
+		mesh := &child3D.Model.Meshes[i]
+		mat := child3D.Model.Materials[mesh.ModelMaterial]
+
+		q.Submit(render.Item{
+			Pass:       passOf(mat),
+			Mesh:       mesh,
+			Material:   mat,
+			View:       view,
+			Model:      child3D.modelMatrix,
+			Projection: child3D.projectionMatrix,
+			Depth:      depth,
+			Darkness:   1,
+			TotalTime:  totalTime,
+		})
+	}
+}
 
-	gl.UniformMatrix4fv(
-		child3D.Material.GetShader().GetUniform("viewMtx"),
-		1, false, mainCamera.GetFirstViewIndex(),
-	)
+func (child3D *Child3D) updateModelMatrix() {
+	child3D.modelMatrix = child3D.node.World()
 
-	gl.UniformMatrix4fv(
-		child3D.Material.GetShader().GetUniform("modelMtx"),
-		1, false, &child3D.modelMatrix[0],
-	)
+	if box, sphere, ok := child3D.Model.GetBounds(); ok {
+		child3D.worldBounds = box.Transform(child3D.modelMatrix)
+		child3D.worldSphere = sphere.Transform(child3D.modelMatrix)
+	}
+}
 
-	c := []float32{1, 0, 0}
-	gl.Uniform3fv(
-		child3D.Material.GetShader().GetUniform("copyingEnabled"),
-		1, &c[0],
-	)
+// localMatrix returns the child's transform relative to its parent,
+// at its rendered position
+func (child3D *Child3D) localMatrix() mgl32.Mat4 {
+	child3D.syncPosition()
+
+	t := child3D.Transform
+	t.Position = child3D.renderPosition()
+	return t.Matrix()
+}
+
+// renderPosition returns the position between the previous
+// and current fixed step that the child is rendered at
+func (child3D *Child3D) renderPosition() mgl32.Vec3 {
+	return mgl32.Vec3{
+		child3D.prevX + (child3D.X-child3D.prevX)*child3D.alpha,
+		child3D.prevY + (child3D.Y-child3D.prevY)*child3D.alpha,
+		child3D.prevZ + (child3D.Z-child3D.prevZ)*child3D.alpha,
+	}
+}
+
+// syncPosition brings X, Y, Z and Transform.Position back together,
+// keeping any move made through either since they last agreed
+func (child3D *Child3D) syncPosition() {
+	moved := child3D.Transform.Position.Sub(child3D.syncedPosition)
+	child3D.X += moved.X()
+	child3D.Y += moved.Y()
+	child3D.Z += moved.Z()
+
+	child3D.Transform.Position = mgl32.Vec3{child3D.X, child3D.Y, child3D.Z}
+	child3D.syncedPosition = child3D.Transform.Position
+}
+
+// GetWorldBounds returns the bounds of the child at its rendered
+// position, and false if its model has no bounds
+func (child3D *Child3D) GetWorldBounds() (geometry.AABB, geometry.Sphere, bool) {
+	if _, _, ok := child3D.Model.GetBounds(); !ok {
+		return geometry.AABB{}, geometry.Sphere{}, false
+	}
+	child3D.updateModelMatrix()
+	return child3D.worldBounds, child3D.worldSphere, true
+}
+
+// GetCopyBounds returns the bounds of a copy of the child
+func (child3D *Child3D) GetCopyBounds(config ChildCopy) (geometry.AABB, geometry.Sphere, bool) {
+	box, sphere, ok := child3D.Model.GetBounds()
+	if !ok {
+		return geometry.AABB{}, geometry.Sphere{}, false
+	}
+	m := child3D.copyMatrix(config)
+	return box.Transform(m), sphere.Transform(m), true
+}
+
+// copyMatrix returns the model matrix of a copy
+func (child3D *Child3D) copyMatrix(config ChildCopy) mgl32.Mat4 {
+	scale := mgl32.Vec3{config.ScaleX, config.ScaleY, config.ScaleZ}
+	if scale == (mgl32.Vec3{}) {
+		scale = child3D.Transform.Scale
+	}
 
-	config.Material.Render(0, 1, 0)
+	// Copies are placed relative to the child's parent, like the child
+	m := child3D.node.ParentWorld().Mul4(mgl32.Translate3D(config.X, config.Y, config.Z))
+	if config.Rotation != (mgl32.Quat{}) {
+		m = m.Mul4(config.Rotation.Normalize().Mat4())
+	}
+	return m.Mul4(mgl32.Scale3D(scale.X(), scale.Y(), scale.Z()))
+}
+
+// copyMaterial returns the material a mesh of a copy is drawn
+// with: the copy's override, or else the model's own material
+func (child3D *Child3D) copyMaterial(override material.Material, mesh *geometry.Mesh) material.Material {
+	if override != nil {
+		return override
+	}
+	return child3D.Model.Materials[mesh.ModelMaterial]
+}
+
+// RenderCopy draws a copy of the child with its own transform and material
+func (child3D *Child3D) RenderCopy(config ChildCopy, mainCamera camera.Camera) {
+	m := child3D.copyMatrix(config)
+	child3D.GetProjection(mainCamera)
+
+	for i := range child3D.Model.Meshes {
+		mesh := &child3D.Model.Meshes[i]
+		mesh.Render(child3D.copyMaterial(config.Material, mesh), mainCamera.GetFirstViewIndex(), &m[0], &child3D.projectionMatrix[0], 0, 0, 1)
+	}
+}
+
+// SubmitCopy adds each mesh of a copy to a render queue
+func (child3D *Child3D) SubmitCopy(q *render.Queue, config ChildCopy, mainCamera camera.Camera) {
+	m := child3D.copyMatrix(config)
+	child3D.GetProjection(mainCamera)
+
+	view := mainCamera.GetView()
+	depth := render.ViewDepth(view, m)
+
+	for i := range child3D.Model.Meshes {
+		mesh := &child3D.Model.Meshes[i]
+		mat := child3D.copyMaterial(config.Material, mesh)
+
+		q.Submit(render.Item{
+			Pass:       passOf(mat),
+			Mesh:       mesh,
+			Material:   mat,
+			View:       view,
+			Model:      m,
+			Projection: child3D.projectionMatrix,
+			Depth:      depth,
+			Darkness:   1,
+		})
+	}
+}
+
+// passOf returns the render pass for a 3D material
+func passOf(mat material.Material) render.Pass {
+	if t, ok := mat.(material.Transparent); ok && t.IsTransparent() {
+		return render.PassTransparent
+	}
+	return render.PassOpaque
 }
 
 func (child3D *Child3D) BindChild() {
 
 }
 
+// SetPosition moves the child without interpolating
+// from its previous position
 func (child3D *Child3D) SetPosition(x, y, z float32) {
 	child3D.X = x
 	child3D.Y = y
 	child3D.Z = z
+	child3D.prevX = x
+	child3D.prevY = y
+	child3D.prevZ = z
+	child3D.Transform.SetPosition(x, y, z)
+	child3D.syncedPosition = child3D.Transform.Position
+	child3D.node.MarkDirty()
+}
+
+// SetRotation sets the child's rotation from Euler angles in
+// radians, see geometry.QuatFromEuler
+func (child3D *Child3D) SetRotation(x, y, z float32) {
+	child3D.Transform.SetEuler(x, y, z)
+	child3D.node.MarkDirty()
+}
+
+func (child3D *Child3D) SetScale(x, y, z float32) {
+	child3D.Transform.SetScale(x, y, z)
+	child3D.node.MarkDirty()
+}
+
+// LookAt turns the child to face a point, with its up towards +Y
+func (child3D *Child3D) LookAt(target mgl32.Vec3) {
+	child3D.syncPosition()
+	child3D.Transform.LookAt(target, mgl32.Vec3{0, 1, 0})
+	child3D.node.MarkDirty()
 }
 
 func (child3D *Child3D) AttachMaterial(m material.Material) {
@@ -134,6 +357,12 @@ func (child3D *Child3D) AttachMaterial(m material.Material) {
 
 func (child3D *Child3D) AttachModel(m geometry.Model) {
 	child3D.Model = m
+	child3D.Model.ComputeBounds()
+
+	// Batches hold copies of the old model's meshes
+	child3D.copyBatches = nil
+	child3D.batchLookup = make(map[material.Material]*copyBatch)
+	child3D.copiesDirty = true
 }
 
 func (child3D *Child3D) AttachMesh(m geometry.Mesh) {}
@@ -151,21 +380,74 @@ func (child3D *Child3D) IsActive() bool {
 }
 
 func (child3D *Child3D) GetX() float32 {
+	child3D.syncPosition()
 	return child3D.X
 }
 
 func (child3D *Child3D) GetY() float32 {
+	child3D.syncPosition()
 	return child3D.Y
 }
 
 func (child3D *Child3D) GetZ() float32 {
+	child3D.syncPosition()
 	return child3D.Z
 }
 
+// ScaleX, ScaleY and ScaleZ return the child's scale, which
+// is set with SetScale
+func (child3D *Child3D) ScaleX() float32 {
+	return child3D.Transform.Scale.X()
+}
+
+func (child3D *Child3D) ScaleY() float32 {
+	return child3D.Transform.Scale.Y()
+}
+
+func (child3D *Child3D) ScaleZ() float32 {
+	return child3D.Transform.Scale.Z()
+}
+
+//  --------------------------------------------------
+//  Scene Graph
+//  --------------------------------------------------
+
+func (child3D *Child3D) GetNode() *Node {
+	return child3D.node
+}
+
+// SetParent parents the child to another, or unparents it if p is nil.
+// Its position, rotation and scale become relative to the parent.
+func (child3D *Child3D) SetParent(p Parented) error {
+	if p == nil {
+		return child3D.node.SetParent(nil)
+	}
+	return child3D.node.SetParent(p.GetNode())
+}
+
+// GetParent returns the child's parent, or nil if it has none
+func (child3D *Child3D) GetParent() Parented {
+	return child3D.node.GetParent()
+}
+
+// GetWorldMatrix returns the child's transform in the world
+func (child3D *Child3D) GetWorldMatrix() mgl32.Mat4 {
+	return child3D.node.World()
+}
+
+// GetWorldPosition returns where the child is in the world
+func (child3D *Child3D) GetWorldPosition() mgl32.Vec3 {
+	return child3D.node.World().Col(3).Vec3()
+}
+
 func (child3D *Child3D) GetShaderProgram() *material.ShaderProgram {
 	return child3D.Material.GetShader()
 }
 
+func (child3D *Child3D) GetMaterial() material.Material {
+	return child3D.Material
+}
+
 func (child3D *Child3D) GetNumVertices() int32 {
 	return child3D.numVertices
 }
@@ -174,6 +456,22 @@ func (child3D *Child3D) GetCollider() *physics.Collider {
 	return nil
 }
 
+// GetProjection updates the child's projection from a camera, and
+// returns it. The camera's far plane is replaced if the child has
+// its own, see SetInstanceRenderDistance.
+func (child3D *Child3D) GetProjection(mainCamera camera.Camera) mgl32.Mat4 {
+	if fp, ok := mainCamera.(camera.FarProjector); ok && child3D.farPlane > 0 {
+		child3D.projectionMatrix = fp.GetProjectionFar(child3D.farPlane)
+	} else {
+		child3D.projectionMatrix = mainCamera.GetProjection()
+	}
+	return child3D.projectionMatrix
+}
+
+func (child3D *Child3D) GetLayer() int {
+	return child3D.Layer
+}
+
 func (child3D *Child3D) GetDimensions() int {
 	return 3
 }
@@ -192,6 +490,27 @@ func (child3D *Child3D) DisableCopying() {
 
 func (child3D *Child3D) AddCopy(config ChildCopy) {
 	child3D.copies = append(child3D.copies, config)
+	child3D.copiesDirty = true
+}
+
+// MoveCopy moves the copy at index i
+func (child3D *Child3D) MoveCopy(i int, x, y, z float32) {
+	child3D.copies[i].X = x
+	child3D.copies[i].Y = y
+	child3D.copies[i].Z = z
+	child3D.copiesDirty = true
+}
+
+// SetCopy replaces the copy at index i
+func (child3D *Child3D) SetCopy(i int, config ChildCopy) {
+	child3D.copies[i] = config
+	child3D.copiesDirty = true
+}
+
+// ReindexCopies rebuilds the instance batches. It must be
+// called after copies are changed through GetCopies.
+func (child3D *Child3D) ReindexCopies() {
+	child3D.copiesDirty = true
 }
 
 func (child3D *Child3D) GetCopies() *[]ChildCopy {
@@ -199,7 +518,7 @@ func (child3D *Child3D) GetCopies() *[]ChildCopy {
 }
 
 func (child3D *Child3D) GetNumCopies() int {
-	return 0
+	return len(child3D.copies)
 }
 
 func (child3D *Child3D) GetCurrentCopies() []ChildCopy {
@@ -234,18 +553,204 @@ func (child3D *Child3D) GetSpecificRenderDistance() float32 {
 	return child3D.specificRenderDistance
 }
 
+// SetMouseFunc sets the function called when the mouse moves
+// onto or off the child, see CollisionControl.CreateMouseCollision
+func (child3D *Child3D) SetMouseFunc(r func(bool)) {
+	child3D.mouseCollision = r
+}
+
 func (child3D *Child3D) MouseCollisionFunc(collision bool) {
+	if child3D.mouseCollision != nil {
+		child3D.mouseCollision(collision)
+	}
+}
 
+//  --------------------------------------------------
+//  Ray Casting
+//  --------------------------------------------------
+
+// IntersectRay returns the distance along a ray to the child, and false
+// if it misses. The ray is tested against the child's bounds, and then
+// its triangles if triangles is set. dir must be normalized.
+func (child3D *Child3D) IntersectRay(origin, dir mgl32.Vec3, triangles bool) (float32, bool) {
+	box, _, ok := child3D.GetWorldBounds()
+	if !ok {
+		return 0, false
+	}
+	return child3D.intersectRay(child3D.modelMatrix, box, origin, dir, triangles)
+}
+
+// IntersectCopyRay is IntersectRay for a copy of the child
+func (child3D *Child3D) IntersectCopyRay(config ChildCopy, origin, dir mgl32.Vec3, triangles bool) (float32, bool) {
+	box, _, ok := child3D.GetCopyBounds(config)
+	if !ok {
+		return 0, false
+	}
+	return child3D.intersectRay(child3D.copyMatrix(config), box, origin, dir, triangles)
+}
+
+func (child3D *Child3D) intersectRay(model mgl32.Mat4, box geometry.AABB, origin, dir mgl32.Vec3, triangles bool) (float32, bool) {
+	t, ok := box.IntersectRay(origin, dir)
+	if !ok || !triangles {
+		return t, ok
+	}
+
+	// Distances along the ray in model space are the same multiples
+	// of the direction, so they're also distances in the world
+	inv := model.Inv()
+	return child3D.Model.IntersectRay(
+		inv.Mul4x1(origin.Vec4(1)).Vec3(),
+		inv.Mul4x1(dir.Vec4(0)).Vec3(),
+	)
 }
 
 //  --------------------------------------------------
 //  GL Instancing
 //  --------------------------------------------------
 
+// SetInstanceRenderDistance draws the child with its own far
+// plane, instead of the camera's. 0 uses the camera's again.
 func (child3D *Child3D) SetInstanceRenderDistance(dist float32) {
-	child3D.projectionMatrix = mgl32.Perspective(
-		mgl32.DegToRad(45),
-		float32(child3D.config.ScreenWidth)/float32(child3D.config.ScreenHeight),
-		0.1, dist,
-	)
+	child3D.farPlane = dist
+}
+
+//  --------------------------------------------------
+//  Copy Batches
+//  --------------------------------------------------
+
+// copyBatch holds the copies sharing a material override. Its meshes
+// share the model's VAOs, but each has its own instance buffer.
+type copyBatch struct {
+	// Material override of the copies, or nil for the model's materials
+	Material material.Material
+
+	meshes   []geometry.Mesh
+	matrices []mgl32.Mat4
+
+	// Bounds around every copy in the batch
+	bounds  geometry.AABB
+	bounded bool
+}
+
+func (child3D *Child3D) CheckInstancingEnabled() bool {
+	return child3D.instancingEnabled
+}
+
+// EnableGLInstancing draws the copies of the child with one instanced
+// draw call per mesh for each material override. It's enabled by default.
+// Instanced copies are culled per batch rather than one by one.
+func (child3D *Child3D) EnableGLInstancing() {
+	child3D.instancingEnabled = true
+	child3D.copiesDirty = true
+}
+
+// DisableGLInstancing draws and culls copies one by one
+func (child3D *Child3D) DisableGLInstancing() {
+	child3D.instancingEnabled = false
+}
+
+// RenderInstances draws every batch of copies
+func (child3D *Child3D) RenderInstances(mainCamera camera.Camera) {
+	ident := mgl32.Ident4()
+	for _, b := range child3D.visibleBatches(mainCamera) {
+		for i := range b.meshes {
+			mesh := &b.meshes[i]
+			mesh.Render(child3D.copyMaterial(b.Material, mesh), mainCamera.GetFirstViewIndex(), &ident[0], &child3D.projectionMatrix[0], 0, 0, 1)
+		}
+	}
+}
+
+// SubmitInstances adds every batch of copies to a render queue
+func (child3D *Child3D) SubmitInstances(q *render.Queue, mainCamera camera.Camera) {
+	view := mainCamera.GetView()
+	for _, b := range child3D.visibleBatches(mainCamera) {
+		depth := float32(0)
+		if b.bounded {
+			depth = render.ViewDepth(view, mgl32.Translate3D(b.bounds.Center().Elem()))
+		}
+
+		for i := range b.meshes {
+			mesh := &b.meshes[i]
+			mat := child3D.copyMaterial(b.Material, mesh)
+
+			q.Submit(render.Item{
+				Pass:       passOf(mat),
+				Mesh:       mesh,
+				Material:   mat,
+				View:       view,
+				Model:      mgl32.Ident4(),
+				Projection: child3D.projectionMatrix,
+				Depth:      depth,
+				Darkness:   1,
+			})
+		}
+	}
+}
+
+// visibleBatches rebuilds the batches if the copies have changed, and
+// returns those with copies inside the camera's frustum
+func (child3D *Child3D) visibleBatches(mainCamera camera.Camera) []*copyBatch {
+	if child3D.copiesDirty {
+		child3D.rebuildBatches()
+	}
+	child3D.GetProjection(mainCamera)
+
+	frustum := geometry.NewFrustum(child3D.projectionMatrix.Mul4(mainCamera.GetView()))
+
+	visible := make([]*copyBatch, 0, len(child3D.copyBatches))
+	for _, b := range child3D.copyBatches {
+		if len(b.matrices) == 0 {
+			continue
+		}
+		if b.bounded && !frustum.IntersectsAABB(b.bounds) {
+			continue
+		}
+		visible = append(visible, b)
+	}
+	return visible
+}
+
+// rebuildBatches groups the copies by material override and
+// uploads the model matrices of each group
+func (child3D *Child3D) rebuildBatches() {
+	for _, b := range child3D.copyBatches {
+		b.matrices = b.matrices[:0]
+		b.bounded = false
+	}
+
+	box, _, bounded := child3D.Model.GetBounds()
+
+	for _, cpy := range child3D.copies {
+		b, ok := child3D.batchLookup[cpy.Material]
+		if !ok {
+			b = &copyBatch{
+				Material: cpy.Material,
+				meshes:   append([]geometry.Mesh(nil), child3D.Model.Meshes...),
+			}
+			child3D.batchLookup[cpy.Material] = b
+			child3D.copyBatches = append(child3D.copyBatches, b)
+		}
+
+		m := child3D.copyMatrix(cpy)
+		b.matrices = append(b.matrices, m)
+
+		if bounded {
+			if cb := box.Transform(m); b.bounded {
+				b.bounds = b.bounds.Union(cb)
+			} else {
+				b.bounds, b.bounded = cb, true
+			}
+		}
+	}
+
+	for _, b := range child3D.copyBatches {
+		if len(b.matrices) == 0 {
+			continue
+		}
+		for i := range b.meshes {
+			b.meshes[i].SetInstanceMatrices(b.matrices)
+		}
+	}
+
+	child3D.copiesDirty = false
 }
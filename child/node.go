@@ -0,0 +1,141 @@
+package child
+
+import (
+	"errors"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+//  --------------------------------------------------
+//  Node.go contains the scene graph. Every child has a
+//  node, and any child can be parented to another, so
+//  its position, rotation and scale are relative to its
+//  parent. Moving a vehicle moves its wheels, and moving
+//  a menu moves its buttons.
+//
+//  World matrices are cached. When a node's local
+//  transform changes, it and everything below it are
+//  marked dirty, and their world matrices are rebuilt
+//  the next time they're needed. Children mark their
+//  nodes dirty from their setters, and when they're
+//  stepped or interpolated to a new position, so X and
+//  Y written directly are picked up by the next frame.
+//  Call MarkDirty after writing Transform's rotation or
+//  scale directly, or to see a move straight away.
+//
+//  Physics and collisions still use the positions of
+//  children as they are, which are only the same as
+//  their world positions for children without parents.
+//  --------------------------------------------------
+
+// ErrParentCycle is returned when a child would become its own ancestor
+var ErrParentCycle = errors.New("child: parent is a descendant of the child")
+
+// Parented is implemented by children that have a node in the scene graph
+type Parented interface {
+	GetNode() *Node
+}
+
+type Node struct {
+	owner    Parented
+	parent   *Node
+	children []*Node
+
+	// Builds the local transform from the owner's fields
+	local func() mgl32.Mat4
+
+	localMatrix mgl32.Mat4
+	worldMatrix mgl32.Mat4
+
+	// Set when the world matrix needs rebuilding. A dirty
+	// node's descendants are always dirty too.
+	dirty bool
+}
+
+func newNode(owner Parented, local func() mgl32.Mat4) *Node {
+	return &Node{
+		owner: owner,
+		local: local,
+		dirty: true,
+	}
+}
+
+// SetParent moves the node under a parent, or to the root if parent
+// is nil. The node keeps its local transform, so it moves with the
+// parent from wherever the parent is.
+func (n *Node) SetParent(parent *Node) error {
+	for p := parent; p != nil; p = p.parent {
+		if p == n {
+			return ErrParentCycle
+		}
+	}
+
+	if n.parent != nil {
+		siblings := n.parent.children
+		for i, c := range siblings {
+			if c == n {
+				n.parent.children = append(siblings[:i], siblings[i+1:]...)
+				break
+			}
+		}
+	}
+
+	n.parent = parent
+	if parent != nil {
+		parent.children = append(parent.children, n)
+	}
+	n.MarkDirty()
+	return nil
+}
+
+// GetParent returns the child the node is parented to, or nil
+func (n *Node) GetParent() Parented {
+	if n.parent == nil {
+		return nil
+	}
+	return n.parent.owner
+}
+
+// GetChildren returns the children parented to the node
+func (n *Node) GetChildren() []Parented {
+	children := make([]Parented, len(n.children))
+	for i, c := range n.children {
+		children[i] = c.owner
+	}
+	return children
+}
+
+// MarkDirty marks the node and its descendants to be rebuilt
+func (n *Node) MarkDirty() {
+	if n.dirty {
+		return
+	}
+	n.dirty = true
+	for _, c := range n.children {
+		c.MarkDirty()
+	}
+}
+
+// World returns the node's transform in the world
+func (n *Node) World() mgl32.Mat4 {
+	if !n.dirty {
+		return n.worldMatrix
+	}
+
+	n.localMatrix = n.local()
+	n.worldMatrix = n.localMatrix
+	if n.parent != nil {
+		n.worldMatrix = n.parent.World().Mul4(n.localMatrix)
+	}
+	n.dirty = false
+	return n.worldMatrix
+}
+
+// ParentWorld returns the world transform of the node's
+// parent, or the identity if it has none
+func (n *Node) ParentWorld() mgl32.Mat4 {
+	if n.parent == nil {
+		return mgl32.Ident4()
+	}
+	return n.parent.World()
+}
@@ -0,0 +1,62 @@
+package child
+
+import (
+	"testing"
+
+	"rapidengine/configuration"
+)
+
+func TestStillChildStaysClean(t *testing.T) {
+	config := configuration.EngineConfig{ScreenWidth: 800, ScreenHeight: 600}
+	c := NewChild3D(&config)
+	c.SetPosition(1, 2, 3)
+	c.Interpolate(1)
+	c.GetNode().World()
+
+	for _, alpha := range []float32{0, 0.5, 1} {
+		c.SaveState()
+		c.Step(1)
+		c.Interpolate(alpha)
+		if c.node.dirty {
+			t.Fatalf("still child marked dirty at alpha %v", alpha)
+		}
+	}
+
+	// A moving child is marked whenever its rendered position changes
+	c.VX = 1
+	c.SaveState()
+	c.Step(1)
+	if !c.node.dirty {
+		t.Error("stepped child not marked dirty")
+	}
+	c.GetNode().World()
+	c.Interpolate(0.5)
+	if !c.node.dirty {
+		t.Error("interpolated child not marked dirty")
+	}
+	if x := c.GetNode().World().Col(3).X(); x != 1.5 {
+		t.Errorf("rendered at X %v, want 1.5", x)
+	}
+}
+
+func TestFieldWritesAreSeen(t *testing.T) {
+	config := configuration.EngineConfig{ScreenWidth: 800, ScreenHeight: 600}
+	parent := NewChild2D(&config)
+	c := NewChild2D(&config)
+	c.SetParent(parent)
+	c.GetNode().World()
+
+	parent.X = 10
+	parent.SaveState()
+	parent.Interpolate(1)
+	c.Interpolate(1)
+	if x := c.GetNode().World().Col(3).X(); x != 10 {
+		t.Errorf("child at X %v after its parent moved to 10", x)
+	}
+
+	parent.Transform.Position[1] = 20
+	parent.Interpolate(1)
+	if y := c.GetNode().World().Col(3).Y(); y != 20 {
+		t.Errorf("child at Y %v after its parent's transform moved to 20", y)
+	}
+}
@@ -1,7 +1,7 @@
 package cmd
 
 import (
-	"os"
+	"rapidengine/assets"
 	"time"
 
 	"github.com/faiface/beep"
@@ -25,15 +25,18 @@ func (ac *AudioControl) Initialize(e *Engine) {
 	ac.engine = e
 }
 
-func (ac *AudioControl) Load(path string, name string) {
-	f, err := os.Open(path)
+// Load decodes a wav file and registers it under name. It returns an
+// assets.NotFoundError or assets.DecodeError, which is also logged.
+func (ac *AudioControl) Load(path string, name string) error {
+	f, err := ac.engine.Assets.OpenAsset(path)
 	if err != nil {
-		panic(err)
+		return ac.loadError(name, path, err)
 	}
 
 	s, format, err := wav.Decode(f)
 	if err != nil {
-		panic(err)
+		f.Close()
+		return ac.loadError(name, path, &assets.DecodeError{Path: path, Format: "wav", Err: err})
 	}
 
 	done := make(chan struct{})
@@ -43,10 +46,23 @@ func (ac *AudioControl) Load(path string, name string) {
 		Format: &format,
 		Done:   done,
 	}
+	ac.engine.Events.Publish(AssetLoaded{Kind: AssetSound, Name: name, Path: path})
+	return nil
 }
 
+func (ac *AudioControl) loadError(name, path string, err error) error {
+	ac.engine.Logger.WithField("sound", name).Error(err)
+	ac.engine.Events.Publish(AssetLoaded{Kind: AssetSound, Name: name, Path: path, Err: err})
+	return err
+}
+
+// Play plays a loaded sound. Sounds that aren't loaded are skipped.
 func (ac *AudioControl) Play(name string) {
-	audio := ac.Sounds[name]
+	audio, ok := ac.Sounds[name]
+	if !ok {
+		ac.engine.Logger.WithField("sound", name).Warn("sound not loaded")
+		return
+	}
 	speaker.Init(audio.Format.SampleRate, audio.Format.SampleRate.N(time.Second/10))
 	speaker.Play(beep.Seq(*audio.S, beep.Callback(func() {
 		close(audio.Done)
@@ -1,6 +1,10 @@
 package cmd
 
 import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+
 	"rapidengine/child"
 	"rapidengine/configuration"
 	"rapidengine/input"
@@ -20,6 +24,12 @@ type CollisionControl struct {
 	GroupMap map[string][]child.Child
 	LinkMap  map[child.Child]physics.CollisionLink
 
+	// Children with a collision link that collided in the last step
+	colliding map[child.Child]bool
+
+	// Reused for the indices of copies near a child
+	nearby []int
+
 	MouseChildren    map[int]child.Child
 	NumMouseChildren int
 	MouseCollider    physics.Collider
@@ -33,6 +43,7 @@ func NewCollisionControl(config *configuration.EngineConfig) CollisionControl {
 	return CollisionControl{
 		GroupMap:         make(map[string][]child.Child),
 		LinkMap:          make(map[child.Child]physics.CollisionLink),
+		colliding:        make(map[child.Child]bool),
 		MouseChildren:    make(map[int]child.Child),
 		NumMouseChildren: 0,
 		MouseCollider: physics.Collider{
@@ -60,7 +71,8 @@ func (collisionControl *CollisionControl) AddChildToGroup(c child.Child, group s
 }
 
 // CreateCollision adds a child/collisionlink pair to the LinkMap, so that
-// collision will be checked for in Update()
+// collision will be checked for in Update(). The callback may be nil if
+// only CollisionBegan and CollisionEnded events are needed.
 func (collisionControl *CollisionControl) CreateCollision(c child.Child, group string, callback func([]bool)) {
 	collisionControl.LinkMap[c] = physics.CollisionLink{group, callback}
 }
@@ -72,8 +84,9 @@ func (collisionControl *CollisionControl) CreateMouseCollision(c child.Child) {
 }
 
 // CheckCollisionWithGroup checks if a child is colliding with
-// any of the children in the passed group, including copies currently
-// on the screen.
+// any of the children in the passed group, including their copies.
+// Copies of children that index them are found with a neighbour
+// query, otherwise only copies currently on the screen are checked.
 func (collisionControl *CollisionControl) CheckCollisionWithGroup(c child.Child, group string, camX, camY float32) []bool {
 	out := []bool{false, false, false, false}
 	for _, other := range collisionControl.GroupMap[group] {
@@ -81,6 +94,15 @@ func (collisionControl *CollisionControl) CheckCollisionWithGroup(c child.Child,
 			if col := c.CheckCollision(other); col != 0 && c != other {
 				out[col-1] = true
 			}
+		} else if ci, ok := other.(child.CopyIndexer); ok {
+			copies := *other.GetCopies()
+			minX, minY, maxX, maxY := collisionArea(c, other.GetCollider())
+			collisionControl.nearby = ci.QueryCopies(minX, minY, maxX, maxY, collisionControl.nearby[:0])
+			for _, i := range collisionControl.nearby {
+				if col := c.CheckCollisionRaw(copies[i].X, copies[i].Y, other.GetCollider()); col != 0 {
+					out[col-1] = true
+				}
+			}
 		} else {
 			for _, cpy := range other.GetCurrentCopies() {
 				if col := c.CheckCollisionRaw(cpy.X, cpy.Y, other.GetCollider()); col != 0 {
@@ -92,29 +114,115 @@ func (collisionControl *CollisionControl) CheckCollisionWithGroup(c child.Child,
 	return out
 }
 
-// Update is called once per frame, and checks for
+// collisionArea returns the area where the position of another
+// collider would have to be to collide with a child
+func collisionArea(c child.Child, other *physics.Collider) (float32, float32, float32, float32) {
+	col := c.GetCollider()
+
+	vx, vy := float32(0), float32(0)
+	if c2, ok := c.(*child.Child2D); ok {
+		vx = float32(math.Abs(float64(c2.VX)))
+		vy = float32(math.Abs(float64(c2.VY)))
+	}
+
+	x, y := c.GetX()+col.OffsetX, c.GetY()+col.OffsetY
+	return x - other.Width - vx, y - other.Height - vy,
+		x + col.Width + vx, y + col.Height + vy
+}
+
+// Update is called once per step, and checks for
 // collisions of all children in the LinkMap. It also
 // checks for collisions with the mouse with all active
 // children in the MouseChildren map
-func (collisionControl *CollisionControl) Update(camX, camY float32, inputs *input.Input) {
+func (collisionControl *CollisionControl) Update(renderer *Renderer, inputs *input.Input) {
+	camX, camY, _ := renderer.MainCamera.GetPosition()
 	for c, link := range collisionControl.LinkMap {
 		if c.IsActive() {
 			if col := collisionControl.CheckCollisionWithGroup(c, link.Group, camX, camY); col != nil {
-				link.Callback(col)
+				if link.Callback != nil {
+					link.Callback(col)
+				}
+				collisionControl.publishCollision(c, link.Group, col)
 			}
+		} else {
+			collisionControl.publishCollision(c, link.Group, nil)
 		}
 	}
 
-	mx, my := float32(inputs.MouseX), float32(inputs.MouseY)-float32(collisionControl.config.ScreenHeight) //collisionControl.ScaleMouseCoords(inputs.MouseX, inputs.MouseY, camX, camY)
+	collisionControl.updateMouse(renderer, inputs)
+}
+
+// updateMouse checks the mouse children against the cursor. 2D children
+// are checked against MouseCollider, centered on the cursor, and 3D
+// children are picked with a ray through it.
+func (collisionControl *CollisionControl) updateMouse(renderer *Renderer, inputs *input.Input) {
+	x, y := float32(inputs.MouseX), float32(inputs.MouseY)
+
+	// Cursor on the screen, from the bottom left like static children
+	screen := mgl32.Vec2{x, float32(collisionControl.config.ScreenHeight) - y}
+
+	// Cursor in the world, and what it's over in 3D, found when needed
+	var world *mgl32.Vec2
+	var pick *Pick
+
+	col := &collisionControl.MouseCollider
 	for _, c := range collisionControl.MouseChildren {
-		if c.IsActive() {
-			c.MouseCollisionFunc(c.CheckCollisionRaw(mx, -my, &collisionControl.MouseCollider) != 0)
-		} else {
+		if !c.IsActive() {
 			c.MouseCollisionFunc(false)
+			continue
+		}
+
+		switch c := c.(type) {
+		case *child.Child3D:
+			if pick == nil {
+				p := collisionControl.engine.PickControl.PickScreen(x, y)
+				pick = &p
+			}
+			c.MouseCollisionFunc(pick.Child == c)
+
+		default:
+			p := screen
+			if c2, ok := c.(*child.Child2D); ok && !c2.Static && collisionControl.config.Dimensions == 2 {
+				if world == nil {
+					w := renderer.MainCamera.ScreenToWorld(x, y, 0)
+					world = &mgl32.Vec2{w.X(), w.Y()}
+				}
+				p = *world
+			}
+
+			// Colliders of parented children are relative to the parent
+			if pc, ok := c.(child.Parented); ok {
+				offset := pc.GetNode().ParentWorld().Col(3)
+				p = p.Sub(mgl32.Vec2{offset.X(), offset.Y()})
+			}
+			c.MouseCollisionFunc(c.CheckCollisionRaw(p.X()-col.Width/2, p.Y()-col.Height/2, col) != 0)
 		}
 	}
 }
 
+func (collisionControl *CollisionControl) Shutdown() {}
+
+// publishCollision publishes CollisionBegan or CollisionEnded
+// if the child started or stopped colliding with its group
+func (collisionControl *CollisionControl) publishCollision(c child.Child, group string, sides []bool) {
+	colliding := false
+	for _, side := range sides {
+		colliding = colliding || side
+	}
+
+	if colliding == collisionControl.colliding[c] {
+		return
+	}
+
+	if colliding {
+		collisionControl.colliding[c] = true
+		collisionControl.engine.Events.Publish(CollisionBegan{Child: c, Group: group, Sides: sides})
+	} else {
+		delete(collisionControl.colliding, c)
+		collisionControl.engine.Events.Publish(CollisionEnded{Child: c, Group: group})
+	}
+}
+
 func (collisionControl *CollisionControl) ScaleMouseCoords(x, y float64, camX, camY float32) (float32, float32) {
 	return float32(x) + camX - float32(collisionControl.config.ScreenWidth/2), (float32(y) - camY - float32(collisionControl.config.ScreenHeight/2))
 }
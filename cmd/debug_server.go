@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"time"
+)
+
+//  --------------------------------------------------
+//  DebugServer is an opt-in local HTTP server used by
+//  the engine's debugging tools. It is started when
+//  Config.DebugAddress is set, and serves:
+//
+//    /profile  frame profiler stats as JSON
+//    /glstate  GL state cache counters as JSON
+//    /scene    scene graph as JSON, and scene edits
+//  --------------------------------------------------
+
+type DebugServer struct {
+	Address string
+	Mux     *http.ServeMux
+
+	server *http.Server
+
+	engine *Engine
+}
+
+func NewDebugServer() DebugServer {
+	return DebugServer{
+		Mux: http.NewServeMux(),
+	}
+}
+
+func (ds *DebugServer) Initialize(engine *Engine) {
+	ds.engine = engine
+
+	ds.Mux.Handle("/profile", engine.Profiler)
+	ds.Mux.Handle("/glstate", engine.Renderer.State)
+}
+
+// Handle registers an additional handler on the server
+func (ds *DebugServer) Handle(pattern string, handler http.Handler) {
+	ds.Mux.Handle(pattern, handler)
+}
+
+// Start starts serving on address in the background. The server is meant
+// for local use only, so a warning is logged for non-loopback addresses.
+func (ds *DebugServer) Start(address string) error {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+
+	host, _, _ := net.SplitHostPort(address)
+	if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		ds.engine.Logger.Warn("debug server is listening on a non-local address: ", address)
+	}
+
+	ds.Address = listener.Addr().String()
+	ds.server = &http.Server{Handler: ds.Mux}
+
+	go func() {
+		if err := ds.server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			ds.engine.Logger.Error("debug server: ", err)
+		}
+	}()
+
+	ds.engine.Logger.Info("Debug server listening on http://", ds.Address)
+	return nil
+}
+
+// Shutdown stops the server, if it was started
+func (ds *DebugServer) Shutdown() {
+	if ds.server == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	ds.server.Shutdown(ctx)
+	ds.server = nil
+}
@@ -2,12 +2,15 @@ package cmd
 
 import (
 	"fmt"
-	"net/http"
+	"os"
+	"rapidengine/assets"
 	"rapidengine/camera"
 	"rapidengine/configuration"
+	"rapidengine/events"
 	"rapidengine/input"
 	"rapidengine/lighting"
 	"rapidengine/material"
+	"rapidengine/profiler"
 	"rapidengine/ui"
 
 	"github.com/sirupsen/logrus"
@@ -16,13 +19,16 @@ import (
 )
 
 type Engine struct {
-	Renderer   Renderer
+	Renderer Renderer
+
+	// User function, called once per fixed step
 	RenderFunc func(renderer *Renderer, inputs *input.Input)
 
 	ChildControl     ChildControl
 	GeometryControl  GeometryControl
 	SceneControl     SceneControl
 	CollisionControl CollisionControl
+	PickControl      PickControl
 	TextureControl   TextureControl
 	MaterialControl  MaterialControl
 	InputControl     InputControl
@@ -33,16 +39,44 @@ type Engine struct {
 	TextControl      TextControl
 	AudioControl     AudioControl
 	PostControl      PostControl
+	SystemControl    SystemControl
+	DebugServer      DebugServer
+	Inspector        Inspector
+
+	// Per-stage frame timings
+	Profiler *profiler.Profiler
+
+	// Engine and gameplay events, see events.go
+	Events *events.Bus
 
-	FPSBox     *ui.TextBox
-	FrameCount int
+	FPSBox          *ui.TextBox
+	ProfilerOverlay *ProfilerOverlay
+	FrameCount      int
 
 	Config *configuration.EngineConfig
 
+	// Filesystem the engine's loaders find assets through, cloned
+	// from assets.Default with the config's AssetPaths mounted
+	Assets *assets.FileSystem
+
 	Logger *logrus.Logger
+
+	// Inputs from the latest step
+	inputs *input.Input
 }
 
+// NewEngine creates an engine from a config. The config is validated
+// first, and an invalid one is fatal, see EngineConfig.Validate.
 func NewEngine(config *configuration.EngineConfig, renderFunc func(*Renderer, *input.Input)) *Engine {
+	if err := config.Validate(); err != nil {
+		logrus.Fatal(err)
+	}
+
+	fsys := assets.Default.Clone()
+	for i := len(config.AssetPaths) - 1; i >= 0; i-- {
+		fsys.Mount("", os.DirFS(config.AssetPaths[i]))
+	}
+
 	e := Engine{
 		// Main renderer
 		Renderer: NewRenderer(getEngineCamera(config.Dimensions, config), config),
@@ -52,6 +86,7 @@ func NewEngine(config *configuration.EngineConfig, renderFunc func(*Renderer, *i
 		GeometryControl:  NewGeometryControl(),
 		SceneControl:     NewSceneControl(),
 		CollisionControl: NewCollisionControl(config),
+		PickControl:      NewPickControl(),
 		TextureControl:   NewTextureControl(config),
 		InputControl:     NewInputControl(),
 		ShaderControl:    NewShaderControl(),
@@ -62,9 +97,18 @@ func NewEngine(config *configuration.EngineConfig, renderFunc func(*Renderer, *i
 		TextControl:      NewTextControl(config),
 		AudioControl:     NewAudioControl(),
 		PostControl:      NewPostControl(),
+		SystemControl:    NewSystemControl(),
+		DebugServer:      NewDebugServer(),
+		Inspector:        NewInspector(),
+
+		// Profiling
+		Profiler: profiler.NewProfiler(profiler.DefaultHistory),
+
+		Events: events.NewBus(),
 
 		// Configuration
 		Config:     config,
+		Assets:     fsys,
 		FrameCount: 0,
 
 		// User render function
@@ -73,18 +117,33 @@ func NewEngine(config *configuration.EngineConfig, renderFunc func(*Renderer, *i
 		Logger: config.Logger,
 	}
 
+	e.Profiler.Enabled = config.FrameProfiler || config.ShowProfiler
+	e.Profiler.GPU = config.GPUProfiler
+
 	e.ChildControl.Initialize(&e)
+	e.TextureControl.Initialize(&e)
+	e.InputControl.Initialize(&e)
 	e.GeometryControl.Initialize(&e)
 	e.SceneControl.Initialize(&e)
-	e.ShaderControl.Initialize()
+	e.PickControl.Initialize(&e)
+	e.ShaderControl.Initialize(&e)
 	e.MaterialControl.Initialize(&e)
-	e.UIControl.Initialize(&e)
-	e.TextControl.Initialize(&e)
-	e.TerrainControl.Initialize(&e)
-	e.CollisionControl.Initialize(&e)
 	e.AudioControl.Initialize(&e)
 	e.PostControl.Initialize(&e)
-	e.LightControl.Initialize(&e)
+	e.SystemControl.Initialize(&e)
+
+	// Built-in systems
+	e.SystemControl.AddSystem(SystemCollision, &e.CollisionControl, StageStep, 100)
+	e.SystemControl.AddSystem(SystemPicking, &e.PickControl, StageStep, 150)
+	e.SystemControl.AddSystem(SystemUI, &e.UIControl, StageStep, 200)
+	e.SystemControl.AddSystem(SystemTerrain, &e.TerrainControl, StageFrame, 100)
+	e.SystemControl.AddSystem(SystemLight, &e.LightControl, StageFrame, 200)
+	e.SystemControl.AddSystem(SystemText, &e.TextControl, StageFrame, 300)
+
+	if config.ShowProfiler {
+		e.ProfilerOverlay = NewProfilerOverlay()
+		e.SystemControl.AddSystem(SystemProfiler, e.ProfilerOverlay, StageFrame, 400, SystemText)
+	}
 
 	e.LightControl.Shaders = []*material.ShaderProgram{
 		e.ShaderControl.GetShader("standard"),
@@ -95,9 +154,11 @@ func NewEngine(config *configuration.EngineConfig, renderFunc func(*Renderer, *i
 	}
 
 	e.Renderer.Initialize(&e)
-	e.Renderer.AttachCallback(e.Update)
+	e.Renderer.AttachCallback(e.Render)
+	e.Renderer.AttachStepCallback(e.Update)
+	e.Renderer.Backend.SetResizeCallback(e.resize)
 
-	e.TextControl.LoadFont("../rapidengine/assets/fonts/avenir-next-regular.ttf", "avenir", 32, 0)
+	e.TextControl.LoadFont("engine/fonts/avenir-next-regular.ttf", "avenir", 32, 0)
 
 	if e.Config.ShowFPS {
 		e.FPSBox = e.TextControl.NewTextBox("Rapid Engine", "avenir", float32(e.Config.ScreenWidth-100), float32(e.Config.ScreenHeight-50), 1, [3]float32{50, 50, 50})
@@ -126,6 +187,24 @@ func NewEngine(config *configuration.EngineConfig, renderFunc func(*Renderer, *i
 		e.Renderer.SkyBox = e.TerrainControl.NewSkyBox("TropicalSunnyDay", "png", &e.ShaderControl, &e.TextureControl, e.Config)
 	}
 
+	e.DebugServer.Initialize(&e)
+	e.Inspector.Initialize(&e)
+	if config.DebugAddress != "" {
+		if err := e.DebugServer.Start(config.DebugAddress); err != nil {
+			e.Logger.Error("couldn't start debug server: ", err)
+		}
+	}
+
+	if config.ReplayInput != "" {
+		if err := e.InputControl.StartReplay(config.ReplayInput); err != nil {
+			e.Logger.Error("couldn't replay input: ", err)
+		}
+	} else if config.RecordInput != "" {
+		if err := e.InputControl.StartRecording(config.RecordInput); err != nil {
+			e.Logger.Error("couldn't record input: ", err)
+		}
+	}
+
 	return &e
 }
 
@@ -141,28 +220,50 @@ func (engine *Engine) Initialize() {
 	engine.SceneControl.PreRenderChildren()
 }
 
+// Update runs one fixed step of the simulation: input, the user
+// function, child physics and collision
 func (engine *Engine) Update(renderer *Renderer) {
-	// Get camera position
-	x, y, z := renderer.MainCamera.GetPosition()
+	p := engine.Profiler
 
 	// Get user inputs
-	inputs := engine.InputControl.Update(renderer.Window)
+	p.Begin(profiler.StageInput)
+	inputs := engine.InputControl.Update()
+	engine.inputs = inputs
+	p.End(profiler.StageInput)
+
+	// Store positions to interpolate from
+	children := engine.SceneControl.GetCurrentChildren()
+	for _, c := range children {
+		c.SaveState()
+	}
 
-	// Call user frame function
+	// Call user step function
+	p.Begin(profiler.StageUser)
 	engine.RenderFunc(renderer, inputs)
+	p.End(profiler.StageUser)
+
+	// Step physics
+	p.Begin(profiler.StagePhysics)
+	for _, c := range children {
+		c.Step(renderer.StepTime)
+	}
+	p.End(profiler.StagePhysics)
+
+	// Update systems
+	engine.SystemControl.Update(StageStep, renderer, inputs)
+}
 
+// Render is called once per frame, after the children
+// of the current scene have been rendered
+func (engine *Engine) Render(renderer *Renderer) {
 	// Update FPS
 	if engine.Config.ShowFPS && engine.FrameCount > 10 {
 		engine.FPSBox.Text = fmt.Sprintf("FPS: %v", int(1/renderer.DeltaFrameTime))
 		engine.FrameCount = 0
 	}
 
-	// Update controllers
-	engine.TerrainControl.Update()
-	engine.LightControl.Update(x, y, z)
-	engine.CollisionControl.Update(x, y, inputs)
-	engine.UIControl.Update(inputs)
-	engine.TextControl.Update()
+	// Update systems
+	engine.SystemControl.Update(StageFrame, renderer, engine.inputs)
 
 	engine.FrameCount++
 }
@@ -173,6 +274,33 @@ func (engine *Engine) StartRenderer() {
 	engine.Renderer.StartRenderer()
 }
 
+// Step renders a single frame. Used to drive the engine
+// manually, for example when running headlessly.
+func (engine *Engine) Step() {
+	engine.Renderer.renderFrame()
+}
+
+// resize is called by the backend when the window is resized
+func (engine *Engine) resize(width, height int) {
+	engine.Renderer.State.Viewport(0, 0, int32(width), int32(height))
+	engine.Events.Publish(WindowResized{Width: width, Height: height})
+}
+
+// Close signals the renderer to stop after the current frame
+func (engine *Engine) Close() {
+	engine.Renderer.Backend.SetShouldClose(true)
+}
+
+// AddSystem registers a user system with the engine
+func (engine *Engine) AddSystem(name string, s System, stage SystemStage, priority int, dependencies ...string) error {
+	return engine.SystemControl.AddSystem(name, s, stage, priority, dependencies...)
+}
+
+// RemoveSystem shuts down and removes a system from the engine
+func (engine *Engine) RemoveSystem(name string) error {
+	return engine.SystemControl.RemoveSystem(name)
+}
+
 func (engine *Engine) InstanceLight(l *lighting.PointLight) {
 	engine.LightControl.InstanceLight(l, 0)
 }
@@ -202,7 +330,3 @@ func getEngineCamera(dimension int, config *configuration.EngineConfig) camera.C
 	}
 	return nil
 }
-
-func profileEndpoint(w http.ResponseWriter, r *http.Request) {
-	w.Write([]byte("test"))
-}
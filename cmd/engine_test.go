@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"rapidengine/assets"
+	"rapidengine/backend"
+	"rapidengine/input"
+)
+
+// newHeadlessEngine builds an engine on the NullBackend, which
+// runs one fixed step for every frame
+func newHeadlessEngine(t *testing.T, dimensions int, step func()) *Engine {
+	t.Helper()
+
+	config := NewEngineConfig(800, 600, dimensions)
+	config.Headless = true
+	config.MaxFPS = 60
+	config.StepRate = 60
+
+	e := NewEngine(&config, func(r *Renderer, i *input.Input) { step() })
+	e.Initialize()
+	return e
+}
+
+func TestStepWithoutScene(t *testing.T) {
+	for _, dimensions := range []int{2, 3} {
+		steps := 0
+		e := newHeadlessEngine(t, dimensions, func() { steps++ })
+
+		for i := 0; i < 10; i++ {
+			e.Step()
+		}
+		if steps < 9 || steps > 10 {
+			t.Errorf("%vD: ran %v steps in 10 frames", dimensions, steps)
+		}
+	}
+}
+
+func TestStepMovesChildren(t *testing.T) {
+	steps := 0
+	e := newHeadlessEngine(t, 3, func() { steps++ })
+
+	scn := e.SceneControl.NewScene("main")
+	e.SceneControl.InstanceScene(scn)
+	c := e.ChildControl.NewChild3D()
+	c.VX = 1
+	scn.InstanceChild(c)
+	e.SceneControl.SetCurrentScene(scn)
+
+	for i := 0; i < 10; i++ {
+		e.Step()
+	}
+	if steps == 0 {
+		t.Fatal("no steps ran")
+	}
+	if c.GetX() != float32(steps) {
+		t.Errorf("child at X %v after %v steps", c.GetX(), steps)
+	}
+
+	// Clearing the current scene stops stepping its children
+	e.SceneControl.SetCurrentScene(nil)
+	x := c.GetX()
+	e.Step()
+	if c.GetX() != x {
+		t.Errorf("child moved from X %v to %v without a current scene", x, c.GetX())
+	}
+}
+
+func TestEngineAssetPaths(t *testing.T) {
+	dir := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(dir, "level.txt"), []byte("one"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	mounts := len(assets.Default.Mounts())
+
+	config := NewEngineConfig(800, 600, 2)
+	config.Headless = true
+	config.AssetPaths = []string{dir}
+	withPath := NewEngine(&config, func(r *Renderer, i *input.Input) {})
+
+	config = NewEngineConfig(800, 600, 2)
+	config.Headless = true
+	without := NewEngine(&config, func(r *Renderer, i *input.Input) {})
+
+	if data, err := withPath.Assets.ReadAsset("level.txt"); err != nil || string(data) != "one" {
+		t.Errorf("read %q, %v from the asset path", data, err)
+	}
+	if _, err := without.Assets.ReadAsset("level.txt"); err == nil {
+		t.Error("another engine's asset path was searched")
+	}
+	if len(assets.Default.Mounts()) != mounts {
+		t.Error("assets.Default was changed")
+	}
+	if _, err := without.Assets.ReadAsset("engine/shaders/basic/basic.vert"); err != nil {
+		t.Errorf("default mounts missing: %v", err)
+	}
+}
+
+func TestFrameCalls(t *testing.T) {
+	e := newHeadlessEngine(t, 2, func() {})
+	scn := e.SceneControl.NewScene("main")
+	e.SceneControl.InstanceScene(scn)
+	c := e.ChildControl.NewChild2D()
+	c.AttachMaterial(e.MaterialControl.NewBasicMaterial())
+	c.SetPosition(10, 20)
+	scn.InstanceChild(c)
+	e.SceneControl.SetCurrentScene(scn)
+	e.Step()
+
+	null := e.Renderer.Backend.(*backend.NullBackend)
+	null.Reset()
+	null.Recording = true
+	e.Step()
+
+	draws := null.CallsOfKind(backend.CallDraw)
+	if len(draws) != 1 || null.DrawCalls != 1 {
+		t.Fatalf("%v draw calls, want 1: %v", null.DrawCalls, draws)
+	}
+	basic := e.ShaderControl.GetShader("basic")
+	if draws[0].Args[0] != basic.GetID() || draws[0].Args[2] != int32(6) {
+		t.Errorf("drew %v, want a quad with the basic shader", draws[0].Args)
+	}
+
+	var model []interface{}
+	for _, call := range null.CallsOfKind(backend.CallUniform) {
+		if call.Args[0] == basic.GetID() && call.Args[1] == basic.GetUniform("modelMtx") {
+			model = append(model, call.Args[2])
+		}
+	}
+	if len(model) != 1 {
+		t.Fatalf("model matrix set %v times, want 1", len(model))
+	}
+
+	// The 2D model matrix includes the orthographic projection
+	m, ok := model[0].([]float32)
+	if !ok || len(m) != 16 || !near(m[12], -1+10*2/800.0) || !near(m[13], -1+20*2/600.0) {
+		t.Errorf("model matrix %v doesn't place the child at 10, 20", model[0])
+	}
+}
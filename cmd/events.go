@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"rapidengine/child"
+)
+
+//  --------------------------------------------------
+//  Events.go contains the events the engine publishes
+//  on Engine.Events. Other events are published by the
+//  packages they come from: ui.ButtonClicked and
+//  material.AnimationFinished.
+//
+//  To subscribe to an event:
+//
+//    events.Subscribe(engine.Events, func(e cmd.CollisionBegan) {
+//        ...
+//    })
+//  --------------------------------------------------
+
+// SceneChanged is published when the current scene is changed.
+// Previous is nil for the first scene.
+type SceneChanged struct {
+	Previous *Scene
+	Current  *Scene
+}
+
+// CollisionBegan is published when a child with a collision link
+// starts colliding with its group. Sides holds the sides of the
+// child that are colliding, as passed to the link's callback.
+type CollisionBegan struct {
+	Child child.Child
+	Group string
+	Sides []bool
+}
+
+// CollisionEnded is published when a child with a collision
+// link stops colliding with its group
+type CollisionEnded struct {
+	Child child.Child
+	Group string
+}
+
+// Mouse buttons in PickClicked events
+const (
+	MouseLeft = iota
+	MouseRight
+	MouseMiddle
+)
+
+// HoverBegan is published when the cursor moves onto a child, or
+// a copy of it, while PickControl is enabled
+type HoverBegan struct {
+	Pick Pick
+}
+
+// HoverEnded is published when the cursor moves off a child, or a
+// copy of it. Copy is -1 for the child itself.
+type HoverEnded struct {
+	Child *child.Child3D
+	Copy  int
+}
+
+// PickClicked is published when a mouse button is pressed while the
+// cursor is over a child or the terrain, and PickControl is enabled
+type PickClicked struct {
+	Pick   Pick
+	Button int
+}
+
+// WindowResized is published when the window's framebuffer is resized
+type WindowResized struct {
+	Width  int
+	Height int
+}
+
+// Kinds of assets in AssetLoaded events
+const (
+	AssetTexture = "texture"
+	AssetCubeMap = "cubemap"
+	AssetMesh    = "mesh"
+	AssetModel   = "model"
+	AssetFont    = "font"
+	AssetSound   = "sound"
+)
+
+// AssetLoaded is published when an asset has been loaded. If it
+// failed to load, Err is set and a fallback is used in its place.
+type AssetLoaded struct {
+	Kind string
+	Name string
+	Path string
+	Err  error
+}
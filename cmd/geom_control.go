@@ -1,6 +1,11 @@
 package cmd
 
 import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"rapidengine/assets"
 	"rapidengine/geometry"
 	"rapidengine/material"
 
@@ -8,6 +13,10 @@ import (
 )
 
 type GeometryControl struct {
+	// Substituted for meshes that are missing or fail to load.
+	// A cube is generated the first time it's needed.
+	FallbackMesh *geometry.Mesh
+
 	engine *Engine
 }
 
@@ -19,20 +28,87 @@ func (gm *GeometryControl) Initialize(e *Engine) {
 	gm.engine = e
 }
 
+// GetFallbackMesh returns the fallback mesh, generating
+// it if none has been set
+func (gm *GeometryControl) GetFallbackMesh() geometry.Mesh {
+	if gm.FallbackMesh == nil {
+		m := geometry.NewCube()
+		gm.FallbackMesh = &m
+	}
+	return *gm.FallbackMesh
+}
+
+// LoadObj loads an obj file. If it can't be loaded, the error is
+// logged and returned along with the fallback mesh.
+func (gm *GeometryControl) LoadObj(path string, scale float32) (geometry.Mesh, error) {
+	m, err := geometry.LoadObjFrom(gm.engine.Assets, path, scale)
+	gm.engine.Events.Publish(AssetLoaded{Kind: AssetMesh, Name: path, Path: path, Err: err})
+	if err != nil {
+		gm.engine.Logger.WithField("mesh", path).Error(err)
+		return gm.GetFallbackMesh(), err
+	}
+	return m, nil
+}
+
+// LoadModel imports a model through assimp. If it can't be imported,
+// the error is logged and the model contains only the fallback mesh.
+//
+// Assimp only reads from disk, so the model is imported from a
+// temporary copy, and files it refers to, such as .mtl files, aren't
+// found. Use a single file format like .fbx or .glb.
 func (gm *GeometryControl) LoadModel(path string, mat material.Material) geometry.Model {
-	scene := assimp.ImportFile(path, uint(assimp.Process_Triangulate|assimp.Process_FlipUVs))
+	scene, err := gm.importModel(path)
 	model := geometry.Model{
 		Materials: make(map[int]material.Material),
+		Path:      path,
 	}
 
 	model.Materials[0] = mat
 
+	if err != nil {
+		gm.engine.Logger.WithField("mesh", path).Error(err)
+		model.Meshes = append(model.Meshes, gm.GetFallbackMesh())
+		gm.engine.Events.Publish(AssetLoaded{Kind: AssetModel, Name: path, Path: path, Err: err})
+		return model
+	}
+
 	// Recursively process all nodes in the scene
 	gm.processNode(&model, scene.RootNode(), scene)
+	model.ComputeBounds()
+	gm.engine.Events.Publish(AssetLoaded{Kind: AssetModel, Name: path, Path: path})
 
 	return model
 }
 
+// importModel copies a model from the engine's Assets to a temporary
+// file with the same extension, which assimp uses to pick a format
+func (gm *GeometryControl) importModel(path string) (*assimp.Scene, error) {
+	data, err := gm.engine.Assets.ReadAsset(path)
+	if err != nil {
+		return nil, err
+	}
+
+	tmp, err := ioutil.TempFile("", "model-*"+filepath.Ext(path))
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	scene := assimp.ImportFile(tmp.Name(), uint(assimp.Process_Triangulate|assimp.Process_FlipUVs))
+	if scene == nil {
+		return nil, &assets.DecodeError{Path: path, Format: "model", Err: errors.New("assimp import failed")}
+	}
+	return scene, nil
+}
+
 func (gm *GeometryControl) processNode(m *geometry.Model, node *assimp.Node, scene *assimp.Scene) {
 	for _, mesh := range node.Meshes() {
 		m.Meshes = append(m.Meshes, gm.createMesh(m, scene.Meshes()[mesh], scene))
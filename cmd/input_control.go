@@ -1,25 +1,68 @@
 package cmd
 
 import (
+	"io"
+	"os"
+	"rapidengine/backend"
 	"rapidengine/input"
 
 	"github.com/go-gl/glfw/v3.2/glfw"
 )
 
+//  --------------------------------------------------
+//  InputControl polls the backend for input once per
+//  fixed step. The input can be recorded to a file, and
+//  a recording can be replayed in place of the backend,
+//  along with the frame times it was recorded at, so the
+//  simulation runs exactly as it did when recorded.
+//  --------------------------------------------------
+
 type InputControl struct {
 	keyMap map[string]glfw.Key
+
+	// Recording
+	recorder   *input.Recorder
+	recordFile *os.File
+
+	// Replay
+	replay      *input.Recording
+	replayFrame int
+	replayStep  int
+
+	engine *Engine
 }
 
 func NewInputControl() InputControl {
-	return InputControl{input.KeyMap}
+	return InputControl{
+		keyMap: input.KeyMap,
+	}
+}
+
+func (inputControl *InputControl) Initialize(engine *Engine) {
+	inputControl.engine = engine
 }
 
-func (inputControl *InputControl) Update(window *glfw.Window) *input.Input {
+// Update returns the input for the current fixed step, either
+// polled from the backend or taken from the replayed recording
+func (inputControl *InputControl) Update() *input.Input {
+	if inputControl.replay != nil {
+		backend.Current.PollEvents()
+		return inputControl.nextReplayStep()
+	}
+
+	inputs := inputControl.poll()
+	if inputControl.recorder != nil {
+		inputControl.recorder.RecordStep(inputs)
+	}
+	return inputs
+}
+
+func (inputControl *InputControl) poll() *input.Input {
 	defer input.SwapMousePositions()
-	glfw.PollEvents()
+	backend.Current.PollEvents()
 	current := map[string]bool{}
-	for name, key := range inputControl.keyMap {
-		current[name] = (window.GetKey(key) == glfw.Press)
+	for name := range inputControl.keyMap {
+		current[name] = backend.Current.KeyPressed(name)
 	}
 	return &input.Input{
 		current,
@@ -39,3 +82,132 @@ func (inputControl *InputControl) Update(window *glfw.Window) *input.Input {
 		input.Scroll,
 	}
 }
+
+// BeginFrame is called by the renderer before the fixed steps of a
+// frame are run, with the time since the last frame. It returns the
+// time to simulate, which is the recorded time during a replay.
+func (inputControl *InputControl) BeginFrame(delta float64) float64 {
+	if inputControl.replay != nil {
+		if inputControl.replayFrame >= len(inputControl.replay.Frames) {
+			inputControl.engine.Logger.Info("Input replay finished")
+			inputControl.StopReplay()
+			return delta
+		}
+
+		inputControl.replayStep = 0
+		delta = inputControl.replay.Frames[inputControl.replayFrame].Delta
+		inputControl.replayFrame++
+		return delta
+	}
+
+	if inputControl.recorder != nil {
+		if err := inputControl.recorder.BeginFrame(delta); err != nil {
+			inputControl.engine.Logger.Error("input recording failed: ", err)
+			inputControl.StopRecording()
+		}
+	}
+
+	return delta
+}
+
+func (inputControl *InputControl) nextReplayStep() *input.Input {
+	steps := inputControl.replay.Frames[inputControl.replayFrame-1].Steps
+	if inputControl.replayStep >= len(steps) {
+		inputControl.engine.Logger.Warn("input replay has diverged: frame ", inputControl.replayFrame-1, " ran more steps than were recorded")
+		return &input.Input{Keys: map[string]bool{}}
+	}
+
+	inputs := steps[inputControl.replayStep]
+	inputControl.replayStep++
+	return &inputs
+}
+
+//  --------------------------------------------------
+//  Recording & Replay
+//  --------------------------------------------------
+
+// StartRecording records every step's input, and every frame's
+// delta time, to a file until StopRecording is called
+func (inputControl *InputControl) StartRecording(path string) error {
+	inputControl.StopRecording()
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	recorder, err := input.NewRecorder(f, input.KeyNames(inputControl.keyMap))
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	inputControl.recorder = recorder
+	inputControl.recordFile = f
+	inputControl.engine.Renderer.ResetSimulationClock()
+
+	inputControl.engine.Logger.Info("Recording input to ", path)
+	return nil
+}
+
+// StopRecording finishes the recording, if one is running
+func (inputControl *InputControl) StopRecording() error {
+	if inputControl.recorder == nil {
+		return nil
+	}
+
+	err := inputControl.recorder.Close()
+	if closeErr := inputControl.recordFile.Close(); err == nil {
+		err = closeErr
+	}
+
+	inputControl.engine.Logger.Info("Recorded ", inputControl.recorder.Frames(), " frames of input")
+	inputControl.recorder = nil
+	inputControl.recordFile = nil
+	return err
+}
+
+func (inputControl *InputControl) IsRecording() bool {
+	return inputControl.recorder != nil
+}
+
+// StartReplay replaces the backend's input with a recording. The replay
+// stops when the recording runs out, and input is polled again. For the
+// replay to match the recording, the scene must be in the same state it
+// was in when recording started.
+func (inputControl *InputControl) StartReplay(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	rec, err := input.ReadRecording(f)
+	if err == io.ErrUnexpectedEOF {
+		inputControl.engine.Logger.Warn("input recording ", path, " is truncated, replaying ", len(rec.Frames), " frames")
+	} else if err != nil {
+		return err
+	}
+
+	return inputControl.Replay(rec)
+}
+
+// Replay replays an already decoded recording
+func (inputControl *InputControl) Replay(rec *input.Recording) error {
+	inputControl.StopRecording()
+
+	inputControl.replay = rec
+	inputControl.replayFrame = 0
+	inputControl.replayStep = 0
+	inputControl.engine.Renderer.ResetSimulationClock()
+
+	return nil
+}
+
+func (inputControl *InputControl) StopReplay() {
+	inputControl.replay = nil
+}
+
+func (inputControl *InputControl) IsReplaying() bool {
+	return inputControl.replay != nil
+}
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"rapidengine/backend"
+)
+
+// stepLog is what the step function saw on each fixed step
+type stepLog struct {
+	Delta float64
+	W     bool
+	Space bool
+}
+
+func TestInputReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "input.rec")
+	frameTimes := []float64{1.0 / 60, 1.0 / 30, 1.0 / 120, 1.0 / 45}
+	const frames = 40
+
+	var log *[]stepLog
+	newEngine := func() *Engine {
+		var e *Engine
+		e = newHeadlessEngine(t, 2, func() {
+			*log = append(*log, stepLog{e.Renderer.DeltaFrameTime, e.inputs.Keys["w"], e.inputs.Keys["space"]})
+		})
+		return e
+	}
+
+	recorded := []stepLog{}
+	log = &recorded
+	e := newEngine()
+	null := e.Renderer.Backend.(*backend.NullBackend)
+	if err := e.InputControl.StartRecording(path); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < frames; i++ {
+		null.FrameTime = frameTimes[i%len(frameTimes)]
+		null.Keys["w"] = i%3 == 0
+		null.Keys["space"] = i%5 == 0
+		e.Step()
+	}
+	if err := e.InputControl.StopRecording(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Replayed with a different frame time and no keys held
+	replayed := []stepLog{}
+	log = &replayed
+	e = newEngine()
+	e.Renderer.Backend.(*backend.NullBackend).FrameTime = 1
+	if err := e.InputControl.StartReplay(path); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < frames; i++ {
+		e.Step()
+	}
+
+	if len(replayed) < frames || !reflect.DeepEqual(replayed, recorded) {
+		t.Errorf("replayed %v steps\n%v\nrecorded\n%v", len(replayed), replayed, recorded)
+	}
+
+	// Input is polled again once the recording runs out
+	e.Step()
+	if e.InputControl.IsReplaying() {
+		t.Error("still replaying after the last recorded frame")
+	}
+}
@@ -0,0 +1,525 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"rapidengine/child"
+	"rapidengine/material"
+)
+
+//  --------------------------------------------------
+//  Inspector exposes the scene graph on the debug
+//  server. GET /scene returns it as JSON, and POST
+//  /scene applies an edit. Both are run on the render
+//  thread at the start of the next frame, so they never
+//  race with the engine.
+//  --------------------------------------------------
+
+// inspectorTimeout is how long a request waits for the render thread
+const inspectorTimeout = 2 * time.Second
+
+var errRenderThreadBusy = errors.New("render thread did not respond, is the engine running?")
+
+type Inspector struct {
+	// Requests waiting for the render thread
+	pending chan func()
+
+	engine *Engine
+}
+
+// SceneGraph is a snapshot of the engine's scenes and the
+// state of the controls they use
+type SceneGraph struct {
+	CurrentScene    string                      `json:"currentScene"`
+	Scenes          []SceneInfo                 `json:"scenes"`
+	CollisionGroups map[string][]string         `json:"collisionGroups"`
+	Textures        map[string]material.Texture `json:"textures"`
+	Effects         map[string]bool             `json:"effects"`
+	Frame           int                         `json:"frame"`
+}
+
+type SceneInfo struct {
+	ID                 string      `json:"id"`
+	Active             bool        `json:"active"`
+	AutomaticRendering bool        `json:"automaticRendering"`
+	Children           []ChildInfo `json:"children"`
+	Texts              []TextInfo  `json:"texts"`
+	Subscenes          []SceneInfo `json:"subscenes"`
+}
+
+// ChildInfo describes a child. Path identifies the
+// child in edits, as "<scene id>/<index>".
+type ChildInfo struct {
+	Path     string        `json:"path"`
+	Type     string        `json:"type"`
+	Active   bool          `json:"active"`
+	Position [3]float32    `json:"position"`
+	Velocity [3]float32    `json:"velocity"`
+	Rotation [3]float32    `json:"rotation"`
+	Scale    [3]float32    `json:"scale"`
+	Group    string        `json:"group"`
+	Copies   int           `json:"copies"`
+	Material *MaterialInfo `json:"material,omitempty"`
+}
+
+// MaterialInfo holds a material's type and its exported fields
+type MaterialInfo struct {
+	Type   string          `json:"type"`
+	Fields json.RawMessage `json:"fields,omitempty"`
+}
+
+type TextInfo struct {
+	Text string  `json:"text"`
+	Font string  `json:"font"`
+	X    float32 `json:"x"`
+	Y    float32 `json:"y"`
+}
+
+// InspectorEdit is a change posted to /scene. Every field is optional,
+// and the edit can target a child, a post processing effect, or the
+// current scene. For example:
+//
+//	{"child": "main/0", "set": {"X": 10, "Y": 20}}
+//	{"child": "main/2", "material": {"DiffuseLevel": 0.5}}
+//	{"effect": "bloom", "enabled": false}
+type InspectorEdit struct {
+	// Child to edit, as "<scene id>/<index>"
+	Child string `json:"child"`
+
+	// Exported fields of the child and its material to set, by field
+	// name. Only fields holding plain values, such as numbers, strings,
+	// vectors and transforms, can be set.
+	Set      json.RawMessage `json:"set"`
+	Material json.RawMessage `json:"material"`
+
+	// Activates or deactivates the child
+	Active *bool `json:"active"`
+
+	// Post processing effect to toggle, see SetEffectEnabled
+	Effect  string `json:"effect"`
+	Enabled *bool  `json:"enabled"`
+
+	// ID of a scene to make current
+	Scene string `json:"scene"`
+}
+
+func NewInspector() Inspector {
+	return Inspector{
+		pending: make(chan func(), 64),
+	}
+}
+
+func (in *Inspector) Initialize(engine *Engine) {
+	in.engine = engine
+
+	engine.DebugServer.Handle("/scene", in)
+}
+
+// Update runs the requests received since the last frame.
+// It is called by the renderer at the start of every frame.
+func (in *Inspector) Update() {
+	for {
+		select {
+		case f := <-in.pending:
+			f()
+		default:
+			return
+		}
+	}
+}
+
+// States of a request queued by run
+const (
+	requestQueued int32 = iota
+	requestStarted
+	requestCancelled
+)
+
+// run queues f for the render thread and waits for it to finish. If
+// it times out before f starts, f is cancelled and never runs.
+func (in *Inspector) run(f func()) error {
+	done := make(chan struct{})
+	state := requestQueued
+	timeout := time.After(inspectorTimeout)
+
+	request := func() {
+		if !atomic.CompareAndSwapInt32(&state, requestQueued, requestStarted) {
+			return
+		}
+		f()
+		close(done)
+	}
+
+	select {
+	case in.pending <- request:
+	case <-timeout:
+		return errRenderThreadBusy
+	}
+
+	select {
+	case <-done:
+		return nil
+	case <-timeout:
+		if atomic.CompareAndSwapInt32(&state, requestQueued, requestCancelled) {
+			return errRenderThreadBusy
+		}
+		// Too late to cancel, so wait for it to finish
+		<-done
+		return nil
+	}
+}
+
+// ServeHTTP serves the scene graph on GET, and applies an InspectorEdit
+// on POST, responding with the scene graph after the edit
+func (in *Inspector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var edit InspectorEdit
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		if err := json.NewDecoder(r.Body).Decode(&edit); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var graph SceneGraph
+	var editErr error
+	err := in.run(func() {
+		if r.Method == http.MethodPost {
+			editErr = in.Apply(edit)
+		}
+		graph = in.Snapshot()
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	if editErr != nil {
+		http.Error(w, editErr.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(graph)
+}
+
+//  --------------------------------------------------
+//  Snapshot
+//  --------------------------------------------------
+
+// Snapshot builds the scene graph. It must be called on the render thread.
+func (in *Inspector) Snapshot() SceneGraph {
+	e := in.engine
+	paths := make(map[child.Child]string)
+
+	graph := SceneGraph{
+		Scenes:          []SceneInfo{},
+		CollisionGroups: make(map[string][]string),
+		Textures:        make(map[string]material.Texture),
+		Effects:         e.PostControl.GetEffects(),
+		Frame:           e.Profiler.Frames,
+	}
+
+	for name, tx := range e.TextureControl.TexMap {
+		graph.Textures[name] = *tx
+	}
+
+	if current := e.SceneControl.GetCurrentScene(); current != nil {
+		graph.CurrentScene = current.ID
+	}
+	for _, scn := range e.SceneControl.scenes {
+		graph.Scenes = append(graph.Scenes, in.sceneInfo(scn, paths))
+	}
+
+	for group, children := range e.CollisionControl.GroupMap {
+		refs := []string{}
+		for _, c := range children {
+			if path, ok := paths[c]; ok {
+				refs = append(refs, path)
+			} else {
+				refs = append(refs, fmt.Sprintf("%T (not in a scene)", c))
+			}
+		}
+		graph.CollisionGroups[group] = refs
+	}
+
+	return graph
+}
+
+func (in *Inspector) sceneInfo(scn *Scene, paths map[child.Child]string) SceneInfo {
+	info := SceneInfo{
+		ID:                 scn.ID,
+		Active:             scn.active,
+		AutomaticRendering: scn.automaticRendering,
+		Children:           []ChildInfo{},
+		Texts:              []TextInfo{},
+		Subscenes:          []SceneInfo{},
+	}
+
+	for i, c := range scn.children {
+		ci := childInfo(c)
+		ci.Path = fmt.Sprintf("%v/%v", scn.ID, i)
+		paths[c] = ci.Path
+		info.Children = append(info.Children, ci)
+	}
+	for _, t := range scn.texts {
+		if t != nil {
+			info.Texts = append(info.Texts, TextInfo{Text: t.Text, Font: t.Font, X: t.X, Y: t.Y})
+		}
+	}
+	for _, sub := range scn.subscenes {
+		info.Subscenes = append(info.Subscenes, in.sceneInfo(sub, paths))
+	}
+
+	return info
+}
+
+func childInfo(c child.Child) ChildInfo {
+	info := ChildInfo{
+		Type:     fmt.Sprintf("%T", c),
+		Active:   c.IsActive(),
+		Position: [3]float32{c.GetX(), c.GetY(), 0},
+		Copies:   c.GetNumCopies(),
+	}
+
+	switch c := c.(type) {
+	case *child.Child2D:
+		info.Velocity = [3]float32{c.VX, c.VY, 0}
+		info.Rotation = [3]float32{0, 0, c.GetRotation()}
+		info.Scale = [3]float32{c.Transform.Scale.X(), c.Transform.Scale.Y(), 1}
+		info.Group = c.Group
+	case *child.Child3D:
+		info.Position[2] = c.GetZ()
+		info.Velocity = [3]float32{c.VX, c.VY, c.VZ}
+		x, y, z := c.Transform.Euler()
+		info.Rotation = [3]float32{x, y, z}
+		info.Scale = c.Transform.Scale
+		info.Group = c.Group
+	}
+
+	if m := childMaterial(c); m != nil {
+		info.Material = &MaterialInfo{Type: fmt.Sprintf("%T", m)}
+		if fields, err := json.Marshal(m); err == nil {
+			info.Material.Fields = fields
+		}
+	}
+
+	return info
+}
+
+func childMaterial(c child.Child) material.Material {
+	if mc, ok := c.(interface{ GetMaterial() material.Material }); ok {
+		return mc.GetMaterial()
+	}
+	return nil
+}
+
+//  --------------------------------------------------
+//  Edits
+//  --------------------------------------------------
+
+// Apply applies an edit. It must be called on the render thread.
+// Every part of the edit is checked before any of it is applied,
+// so an edit that's rejected changes nothing.
+func (in *Inspector) Apply(edit InspectorEdit) error {
+	var apply []func()
+
+	if edit.Child != "" {
+		c, err := in.FindChild(edit.Child)
+		if err != nil {
+			return err
+		}
+
+		if len(edit.Set) > 0 {
+			set, err := decodeFields(edit.Set, c)
+			if err != nil {
+				return fmt.Errorf("child %v: %v", edit.Child, err)
+			}
+			apply = append(apply, func() {
+				set()
+				// Don't interpolate from the old position
+				c.SaveState()
+				if pc, ok := c.(child.Parented); ok {
+					pc.GetNode().MarkDirty()
+				}
+			})
+		}
+
+		if len(edit.Material) > 0 {
+			m := childMaterial(c)
+			if m == nil {
+				return fmt.Errorf("child %v has no material", edit.Child)
+			}
+			set, err := decodeFields(edit.Material, m)
+			if err != nil {
+				return fmt.Errorf("child %v material: %v", edit.Child, err)
+			}
+			apply = append(apply, set)
+		}
+
+		if edit.Active != nil {
+			active := *edit.Active
+			apply = append(apply, func() {
+				if active {
+					c.Activate()
+				} else {
+					c.Deactivate()
+				}
+			})
+		}
+	}
+
+	if edit.Effect != "" {
+		if edit.Enabled == nil {
+			return fmt.Errorf("effect %v: enabled must be set", edit.Effect)
+		}
+		effect, enabled := edit.Effect, *edit.Enabled
+		if err := in.engine.PostControl.checkEffect(effect, enabled); err != nil {
+			return err
+		}
+		apply = append(apply, func() {
+			in.engine.PostControl.SetEffectEnabled(effect, enabled)
+		})
+	}
+
+	if edit.Scene != "" {
+		scn := in.FindScene(edit.Scene)
+		if scn == nil {
+			return fmt.Errorf("no scene %q", edit.Scene)
+		}
+		apply = append(apply, func() {
+			in.engine.SceneControl.SetCurrentScene(scn)
+		})
+	}
+
+	for _, f := range apply {
+		f()
+	}
+
+	in.engine.Logger.WithField("edit", edit).Debug("inspector edit applied")
+	return nil
+}
+
+// decodeFields decodes a JSON object of field names and values for
+// the struct v points to. Only exported fields holding plain values
+// can be set, so nothing the struct shares with anything else is
+// written to. It returns a function that sets the fields, so nothing
+// changes unless every field decodes.
+func decodeFields(data []byte, v interface{}) (func(), error) {
+	dst := reflect.ValueOf(v)
+	if dst.Kind() != reflect.Ptr || dst.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("can't edit %T", v)
+	}
+	dst = dst.Elem()
+
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(raw))
+	for name := range raw {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fields := make([]reflect.Value, len(names))
+	values := make([]reflect.Value, len(names))
+	for i, name := range names {
+		sf, ok := dst.Type().FieldByName(name)
+		if !ok || sf.PkgPath != "" || len(sf.Index) != 1 {
+			return nil, fmt.Errorf("no field %q", name)
+		}
+		if !isPlainValue(sf.Type) {
+			return nil, fmt.Errorf("field %q can't be edited", name)
+		}
+
+		// Start from the current value, so partial structs and
+		// arrays keep what they don't set
+		fields[i] = dst.Field(sf.Index[0])
+		values[i] = reflect.New(sf.Type)
+		values[i].Elem().Set(fields[i])
+		if err := json.Unmarshal(raw[name], values[i].Interface()); err != nil {
+			return nil, fmt.Errorf("field %q: %v", name, err)
+		}
+	}
+
+	return func() {
+		for i := range fields {
+			fields[i].Set(values[i].Elem())
+		}
+	}, nil
+}
+
+// isPlainValue reports whether a type holds only values, with no
+// pointers, slices, maps or interfaces that could be shared
+func isPlainValue(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	case reflect.Array:
+		return isPlainValue(t.Elem())
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if !isPlainValue(t.Field(i).Type) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// FindScene returns the scene or subscene with the given ID
+func (in *Inspector) FindScene(id string) *Scene {
+	var find func(scenes []*Scene) *Scene
+	find = func(scenes []*Scene) *Scene {
+		for _, scn := range scenes {
+			if scn.ID == id {
+				return scn
+			}
+			if sub := find(scn.subscenes); sub != nil {
+				return sub
+			}
+		}
+		return nil
+	}
+	return find(in.engine.SceneControl.scenes)
+}
+
+// FindChild returns the child at a "<scene id>/<index>" path
+func (in *Inspector) FindChild(path string) (child.Child, error) {
+	split := strings.LastIndex(path, "/")
+	if split < 0 {
+		return nil, fmt.Errorf("child path %q is not <scene>/<index>", path)
+	}
+
+	scn := in.FindScene(path[:split])
+	if scn == nil {
+		return nil, fmt.Errorf("no scene %q", path[:split])
+	}
+
+	index, err := strconv.Atoi(path[split+1:])
+	if err != nil || index < 0 || index >= len(scn.children) {
+		return nil, fmt.Errorf("no child %q", path)
+	}
+
+	return scn.children[index], nil
+}
@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"encoding/json"
+	"testing"
+
+	"rapidengine/child"
+	"rapidengine/material"
+)
+
+func newInspectedChild(t *testing.T) (*Engine, *child.Child2D, *material.StandardMaterial) {
+	t.Helper()
+
+	e := newHeadlessEngine(t, 2, func() {})
+	scn := e.SceneControl.NewScene("main")
+	e.SceneControl.InstanceScene(scn)
+	e.SceneControl.SetCurrentScene(scn)
+
+	c := e.ChildControl.NewChild2D()
+	m := e.MaterialControl.NewStandardMaterial()
+	m.DiffuseLevel = 1
+	c.AttachMaterial(m)
+	c.SetPosition(10, 20)
+	scn.InstanceChild(c)
+	return e, c, m
+}
+
+func applyEdit(t *testing.T, e *Engine, body string) error {
+	t.Helper()
+
+	var edit InspectorEdit
+	if err := json.Unmarshal([]byte(body), &edit); err != nil {
+		t.Fatal(err)
+	}
+	return e.Inspector.Apply(edit)
+}
+
+func TestInspectorApply(t *testing.T) {
+	e, c, m := newInspectedChild(t)
+
+	err := applyEdit(t, e, `{"child": "main/0", "set": {"X": 5, "Transform": {"Scale": [30, 40, 1]}}, "material": {"DiffuseLevel": 0.5}, "active": false}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.GetX() != 5 || c.GetY() != 20 {
+		t.Errorf("child at %v, %v, want 5, 20", c.GetX(), c.GetY())
+	}
+	if c.ScaleX() != 30 || c.ScaleY() != 40 {
+		t.Errorf("child scaled to %v, %v, want 30, 40", c.ScaleX(), c.ScaleY())
+	}
+	if m.DiffuseLevel != 0.5 {
+		t.Errorf("diffuse level %v, want 0.5", m.DiffuseLevel)
+	}
+	if c.IsActive() {
+		t.Error("child still active")
+	}
+}
+
+func TestInspectorRejectedEdits(t *testing.T) {
+	for _, body := range []string{
+		// Fields that aren't plain values, or don't exist
+		`{"child": "main/0", "set": {"Material": {"DiffuseLevel": 7}, "X": "bad"}}`,
+		`{"child": "main/0", "set": {"Material": {"DiffuseLevel": 7}}}`,
+		`{"child": "main/0", "set": {"X": 7, "Nope": 1}}`,
+		`{"child": "main/0", "set": {"X": 7, "node": {}}}`,
+
+		// A bad value alongside good ones
+		`{"child": "main/0", "set": {"X": 7, "Y": "bad"}}`,
+		`{"child": "main/0", "set": {"X": 7}, "material": {"DiffuseLevel": "bad"}}`,
+
+		// A bad effect or scene alongside a good child edit
+		`{"child": "main/0", "set": {"X": 7}, "material": {"DiffuseLevel": 7}, "active": false, "effect": "nope", "enabled": true}`,
+		`{"child": "main/0", "set": {"X": 7}, "active": false, "effect": "bloom", "enabled": true}`,
+		`{"child": "main/0", "set": {"X": 7}, "active": false, "scene": "nope"}`,
+	} {
+		e, c, m := newInspectedChild(t)
+		c.Activate()
+
+		if err := applyEdit(t, e, body); err == nil {
+			t.Errorf("%v: accepted", body)
+			continue
+		}
+		if c.GetX() != 10 || c.GetY() != 20 {
+			t.Errorf("%v: child moved to %v, %v", body, c.GetX(), c.GetY())
+		}
+		if m.DiffuseLevel != 1 {
+			t.Errorf("%v: diffuse level changed to %v", body, m.DiffuseLevel)
+		}
+		if !c.IsActive() {
+			t.Errorf("%v: child deactivated", body)
+		}
+	}
+}
@@ -1,10 +1,10 @@
 package cmd
 
 import (
+	"rapidengine/backend"
+	"rapidengine/input"
 	"rapidengine/lighting"
 	"rapidengine/material"
-
-	"github.com/go-gl/gl/v4.1-core/gl"
 )
 
 type LightControl struct {
@@ -34,7 +34,8 @@ func (lightControl *LightControl) Initialize(engine *Engine) {
 	lightControl.engine = engine
 }
 
-func (lightControl *LightControl) Update(cx, cy, cz float32) {
+func (lightControl *LightControl) Update(renderer *Renderer, inputs *input.Input) {
+	cx, cy, cz := renderer.MainCamera.GetPosition()
 	if lightControl.lightingEnabled[0] {
 		for _, shader := range lightControl.Shaders {
 			if lightControl.DirLight[0] != nil && lightControl.directionalEnabled[0] {
@@ -44,11 +45,13 @@ func (lightControl *LightControl) Update(cx, cy, cz float32) {
 				light.UpdateShader(cx, cy, cz, ind, shader)
 			}
 
-			gl.Uniform1i(gl.GetUniformLocation(shader.GetID(), gl.Str("numPointLights\x00")), int32(len(lightControl.pointLightMap)))
+			backend.Current.Uniform1i(backend.Current.GetUniformLocation(shader.GetID(), "numPointLights"), int32(len(lightControl.pointLightMap)))
 		}
 	}
 }
 
+func (lightControl *LightControl) Shutdown() {}
+
 func (lightControl *LightControl) PreRender() {
 	if lightControl.lightingEnabled[0] {
 		if lightControl.DirLight[0] != nil && lightControl.directionalEnabled[0] {
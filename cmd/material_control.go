@@ -2,7 +2,6 @@ package cmd
 
 import (
 	"rapidengine/material"
-	"rapidengine/state"
 )
 
 type MaterialControl struct {
@@ -19,19 +18,12 @@ func NewMaterialControl() MaterialControl {
 
 func (mc *MaterialControl) Initialize(engine *Engine) {
 	mc.engine = engine
-
-	e := uint32(1000)
-	state.BoundTexture0 = e
-	state.BoundTexture1 = e
-	state.BoundTexture2 = e
-	state.BoundTexture3 = e
-	state.BoundTexture4 = e
-	state.BoundTexture5 = e
-	state.BoundTexture6 = e
 }
 
 func (mc *MaterialControl) NewBasicMaterial() *material.BasicMaterial {
-	return material.NewBasicMaterial(mc.engine.ShaderControl.GetShader("basic"))
+	m := material.NewBasicMaterial(mc.engine.ShaderControl.GetShader("basic"))
+	m.Events = mc.engine.Events
+	return m
 }
 
 func (mc *MaterialControl) NewStandardMaterial() *material.StandardMaterial {
@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+
+	"rapidengine/child"
+	"rapidengine/input"
+)
+
+//  --------------------------------------------------
+//  PickControl finds what's under the cursor in 3D.
+//  Rays from the camera are tested against the bounds
+//  of every 3D child and copy in the current scene,
+//  and optionally their triangles, and against the
+//  terrain. While enabled, it also publishes hover and
+//  click events every step, see events.go.
+//  --------------------------------------------------
+
+// Pick is the result of a pick. If Hit is false, nothing was hit.
+type Pick struct {
+	Hit bool
+
+	// Child hit, and the index of the copy hit or -1 for the child
+	// itself. Child is nil if the terrain was hit.
+	Child *child.Child3D
+	Copy  int
+
+	// Whether the terrain was hit
+	Terrain bool
+
+	// Point hit, and its distance from the ray's origin
+	Point    mgl32.Vec3
+	Distance float32
+}
+
+type PickControl struct {
+	// Whether hover and click events are published every step
+	Enabled bool
+
+	// Whether children are tested against their triangles after
+	// their bounds. Slower, but exact for irregular models.
+	Triangles bool
+
+	// Whether the terrain can be picked
+	Terrain bool
+
+	// Distance beyond which nothing is picked
+	MaxDistance float32
+
+	// What the cursor was over at the last step
+	hovered Pick
+
+	// Mouse buttons held at the last step
+	buttons [3]bool
+
+	engine *Engine
+}
+
+func NewPickControl() PickControl {
+	return PickControl{
+		Terrain:     true,
+		MaxDistance: 10000,
+	}
+}
+
+func (pc *PickControl) Initialize(engine *Engine) {
+	pc.engine = engine
+}
+
+// Update picks under the cursor and publishes hover and click events
+func (pc *PickControl) Update(renderer *Renderer, inputs *input.Input) {
+	if !pc.Enabled {
+		return
+	}
+
+	p := pc.PickScreen(float32(inputs.MouseX), float32(inputs.MouseY))
+
+	old := pc.hovered
+	if old.Child != p.Child || old.Copy != p.Copy {
+		if old.Child != nil {
+			pc.engine.Events.Publish(HoverEnded{Child: old.Child, Copy: old.Copy})
+		}
+		if p.Child != nil {
+			pc.engine.Events.Publish(HoverBegan{Pick: p})
+		}
+	}
+	pc.hovered = p
+
+	for button, down := range [3]bool{inputs.LeftMouseButton, inputs.RightMouseButton, inputs.MiddleMouseButton} {
+		if down && !pc.buttons[button] && p.Hit {
+			pc.engine.Events.Publish(PickClicked{Pick: p, Button: button})
+		}
+		pc.buttons[button] = down
+	}
+}
+
+func (pc *PickControl) Shutdown() {}
+
+// GetHovered returns what the cursor was over at the last step
+func (pc *PickControl) GetHovered() Pick {
+	return pc.hovered
+}
+
+// PickScreen picks under a position on the screen, in pixels
+// from the top left like the mouse
+func (pc *PickControl) PickScreen(x, y float32) Pick {
+	origin, dir, _ := pc.engine.Renderer.ScreenRay(x, y)
+	return pc.PickRay(origin, dir)
+}
+
+// PickRay returns the nearest child, copy or terrain point hit by a
+// ray, up to MaxDistance. dir must be normalized.
+func (pc *PickControl) PickRay(origin, dir mgl32.Vec3) Pick {
+	nearest := Pick{Copy: -1, Distance: pc.MaxDistance}
+	hit := func(t float32, c *child.Child3D, cpy int) {
+		if t < nearest.Distance {
+			nearest = Pick{Hit: true, Child: c, Copy: cpy, Terrain: c == nil, Distance: t}
+		}
+	}
+
+	for _, c := range pc.engine.SceneControl.GetCurrentChildren() {
+		c3, ok := c.(*child.Child3D)
+		if !ok || !c3.IsActive() {
+			continue
+		}
+
+		if !c3.CheckCopyingEnabled() {
+			if t, ok := c3.IntersectRay(origin, dir, pc.Triangles); ok {
+				hit(t, c3, -1)
+			}
+			continue
+		}
+		for i, cpy := range *c3.GetCopies() {
+			if t, ok := c3.IntersectCopyRay(cpy, origin, dir, pc.Triangles); ok {
+				hit(t, c3, i)
+			}
+		}
+	}
+
+	if t := pc.engine.TerrainControl.GetTerrain(); pc.Terrain && t != nil && t.TChild != nil {
+		if d, ok := t.Raycast(origin, dir, nearest.Distance); ok {
+			hit(d, nil, -1)
+		}
+	}
+
+	if !nearest.Hit {
+		return Pick{Copy: -1, Distance: float32(math.Inf(1))}
+	}
+	nearest.Point = origin.Add(dir.Mul(nearest.Distance))
+	return nearest
+}
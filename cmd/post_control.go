@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"fmt"
+	"rapidengine/backend"
 	"rapidengine/child"
 	"rapidengine/geometry"
 	"rapidengine/material"
@@ -90,8 +92,7 @@ func (pc *PostControl) EnablePostProcessing() {
 	pc.ScreenChild = pc.engine.ChildControl.NewChild2D()
 	pc.ScreenChild.AttachMaterial(pc.ScreenMaterial)
 	pc.ScreenChild.AttachMesh(geometry.NewScreenQuad())
-	pc.ScreenChild.ScaleX = float32(pc.engine.Config.ScreenWidth)
-	pc.ScreenChild.ScaleY = float32(pc.engine.Config.ScreenHeight)
+	pc.ScreenChild.SetScale(float32(pc.engine.Config.ScreenWidth), float32(pc.engine.Config.ScreenHeight))
 	pc.ScreenChild.Static = true
 	pc.ScreenChild.SetPosition(0, 0)
 	pc.ScreenChild.PreRender(pc.engine.Renderer.MainCamera)
@@ -112,20 +113,29 @@ func (pc *PostControl) IsPostProcessingEnabled() bool {
 // PostControl in preparation for the post processing stage.
 func (pc *PostControl) UpdateFrameBuffers() {
 	if pc.engine.Config.Dimensions == 3 {
-		gl.Enable(gl.DEPTH_TEST)
+		backend.Current.Enable(gl.DEPTH_TEST)
 	}
 
 	if pc.PostProcessingEnabled {
-		gl.BindFramebuffer(gl.FRAMEBUFFER, pc.PInputBuffer.FrameBuffer)
+		backend.Current.BindFramebuffer(gl.FRAMEBUFFER, pc.PInputBuffer.FrameBuffer)
 	} else {
-		gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+		backend.Current.BindFramebuffer(gl.FRAMEBUFFER, 0)
 	}
 
-	gl.Clear(gl.COLOR_BUFFER_BIT)
-	gl.Clear(gl.DEPTH_BUFFER_BIT)
+	backend.Current.Clear(gl.COLOR_BUFFER_BIT)
+	backend.Current.Clear(gl.DEPTH_BUFFER_BIT)
 
-	drawBuffers := []uint32{gl.COLOR_ATTACHMENT0, gl.COLOR_ATTACHMENT1}
-	gl.DrawBuffers(2, &drawBuffers[0])
+	backend.Current.DrawBuffers([]uint32{gl.COLOR_ATTACHMENT0, gl.COLOR_ATTACHMENT1})
+}
+
+// ScreenFramebuffer returns the framebuffer the scene is drawn into
+// for the screen: the input buffer of the effect chain if post
+// processing is enabled, otherwise the default framebuffer
+func (pc *PostControl) ScreenFramebuffer() uint32 {
+	if pc.PostProcessingEnabled {
+		return pc.PInputBuffer.FrameBuffer
+	}
+	return 0
 }
 
 // Update applies the post processing effect chain every frame.
@@ -138,16 +148,23 @@ func (pc *PostControl) Update() {
 		return
 	}
 
+	p := pc.engine.Profiler
+
 	// Apply input buffer
+	p.Begin("post/input")
 	pc.ApplyInput()
+	p.End("post/input")
 
 	// Apply HDR
 	if pc.hdrEnabled {
+		p.Begin("post/hdr")
 		pc.ApplyHDR(&pc.PBuffer1, &pc.PBuffer2)
 		pc.SwapPingPongBuffers()
+		p.End("post/hdr")
 	}
 
 	if pc.bloomEnabled {
+		p.Begin("post/bloom")
 		pc.ApplyPreBloom(&pc.PBuffer1, &pc.BloomBuffer1)
 		pc.ApplyGaussianBlur(&pc.BloomBuffer1, &pc.PBuffer2)
 		pc.ApplyPostBloom(&pc.PBuffer1, &pc.PBuffer2, &pc.BloomBuffer1)
@@ -157,6 +174,7 @@ func (pc *PostControl) Update() {
 		pc.BloomBuffer1 = pc.PIntermediateBuffer
 
 		pc.SwapPingPongBuffers()
+		p.End("post/bloom")
 	}
 
 	if pc.gaussianEnabled {
@@ -165,18 +183,23 @@ func (pc *PostControl) Update() {
 	}
 
 	if pc.scatteringEnabled {
+		p.Begin("post/scattering")
 		pc.ApplyPreScattering(&EffectBuffers{RenderedTexture: pc.ScatteringTexture}, &pc.ScatteringBuffer)
 		pc.ApplyPostScattering(&pc.PBuffer1, &pc.ScatteringBuffer, &pc.PBuffer2)
 		pc.SwapPingPongBuffers()
+		p.End("post/scattering")
 	}
 
 	// Render final buffer to screen
+	p.Begin("post/final")
+	defer p.End("post/final")
+
 	pc.ScreenMaterial.ScreenMap = &pc.PBuffer1.RenderedTexture
 	pc.ScreenMaterial.AttachShader(pc.engine.ShaderControl.GetShader("post_final"))
 
-	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
-	gl.Clear(gl.COLOR_BUFFER_BIT)
-	gl.Clear(gl.DEPTH_BUFFER_BIT)
+	backend.Current.BindFramebuffer(gl.FRAMEBUFFER, 0)
+	backend.Current.Clear(gl.COLOR_BUFFER_BIT)
+	backend.Current.Clear(gl.DEPTH_BUFFER_BIT)
 
 	pc.engine.Renderer.RenderChild(pc.ScreenChild)
 }
@@ -220,7 +243,7 @@ func (pc *PostControl) ApplyVerticalGaussian(input, output *EffectBuffers) {
 }
 
 func (pc *PostControl) ApplyGaussianBlur(input, output *EffectBuffers) {
-	gl.Viewport(0, 0, int32(pc.engine.Config.ScreenWidth/pc.gaussianScale), int32(pc.engine.Config.ScreenHeight/pc.gaussianScale))
+	backend.Current.Viewport(0, 0, int32(pc.engine.Config.ScreenWidth/pc.gaussianScale), int32(pc.engine.Config.ScreenHeight/pc.gaussianScale))
 	pc.ScreenMaterial.FboWidth = float32(pc.engine.Config.ScreenWidth / pc.gaussianScale)
 	pc.ScreenMaterial.FboHeight = float32(pc.engine.Config.ScreenWidth / pc.gaussianScale)
 	pc.ApplyHorizontalGaussian(input, &pc.GaussianBuffer1)
@@ -232,12 +255,12 @@ func (pc *PostControl) ApplyGaussianBlur(input, output *EffectBuffers) {
 		pc.ApplyVerticalGaussian(&pc.GaussianBuffer2, &pc.GaussianBuffer1)
 	}
 
-	gl.Viewport(pc.BloomOffsetX, pc.BloomOffsetY, int32(pc.engine.Config.ScreenWidth), int32(pc.engine.Config.ScreenHeight))
+	backend.Current.Viewport(pc.BloomOffsetX, pc.BloomOffsetY, int32(pc.engine.Config.ScreenWidth), int32(pc.engine.Config.ScreenHeight))
 	pc.ScreenMaterial.FboWidth = float32(pc.engine.Config.ScreenWidth)
 	pc.ScreenMaterial.FboHeight = float32(pc.engine.Config.ScreenWidth)
 	pc.ApplyHorizontalGaussian(&pc.GaussianBuffer1, &pc.GaussianBuffer3)
 	pc.ApplyVerticalGaussian(&pc.GaussianBuffer3, output)
-	gl.Viewport(0, 0, int32(pc.engine.Config.ScreenWidth), int32(pc.engine.Config.ScreenHeight))
+	backend.Current.Viewport(0, 0, int32(pc.engine.Config.ScreenWidth), int32(pc.engine.Config.ScreenHeight))
 }
 
 var SunX float32
@@ -251,15 +274,15 @@ func (pc *PostControl) ApplyPreScattering(input, output *EffectBuffers) {
 	pos := []float32{
 		SunX, SunY,
 	}
-	gl.Uniform2fv(
+	backend.Current.Uniform2fv(
 		pc.ScreenMaterial.GetShader().GetUniform("lightPos"),
 		1, &pos[0],
 	)
 
-	gl.Uniform1f(pc.ScreenMaterial.GetShader().GetUniform("decay"), pc.ScatteringDecay)
-	gl.Uniform1f(pc.ScreenMaterial.GetShader().GetUniform("density"), pc.ScatteringDensity)
-	gl.Uniform1f(pc.ScreenMaterial.GetShader().GetUniform("weight"), pc.ScatteringWeight)
-	gl.Uniform1f(pc.ScreenMaterial.GetShader().GetUniform("exposure"), pc.ScatteringExposure)
+	backend.Current.Uniform1f(pc.ScreenMaterial.GetShader().GetUniform("decay"), pc.ScatteringDecay)
+	backend.Current.Uniform1f(pc.ScreenMaterial.GetShader().GetUniform("density"), pc.ScatteringDensity)
+	backend.Current.Uniform1f(pc.ScreenMaterial.GetShader().GetUniform("weight"), pc.ScatteringWeight)
+	backend.Current.Uniform1f(pc.ScreenMaterial.GetShader().GetUniform("exposure"), pc.ScatteringExposure)
 
 	output.BindAndClear()
 
@@ -271,9 +294,9 @@ func (pc *PostControl) ApplyPostScattering(input, scatterInput, output *EffectBu
 	pc.ScreenMaterial.AttachShader(pc.engine.ShaderControl.GetShader("post_postscattering"))
 
 	pc.ScreenMaterial.GetShader().Bind()
-	gl.ActiveTexture(gl.TEXTURE1)
-	gl.BindTexture(gl.TEXTURE_2D, scatterInput.RenderedTexture)
-	gl.Uniform1i(pc.ScreenMaterial.GetShader().GetUniform("scatterInput"), 1)
+	backend.Current.ActiveTexture(gl.TEXTURE1)
+	backend.Current.BindTexture(gl.TEXTURE_2D, scatterInput.RenderedTexture)
+	backend.Current.Uniform1i(pc.ScreenMaterial.GetShader().GetUniform("scatterInput"), 1)
 
 	output.BindAndClear()
 
@@ -284,7 +307,7 @@ func (pc *PostControl) ApplyPreBloom(input, output *EffectBuffers) {
 	pc.ScreenMaterial.ScreenMap = &input.RenderedTexture
 	pc.ScreenMaterial.AttachShader(pc.engine.ShaderControl.GetShader("post_prebloom"))
 	pc.ScreenMaterial.GetShader().Bind()
-	gl.Uniform1f(pc.ScreenMaterial.GetShader().GetUniform("bloomThreshold"), pc.BloomThreshold)
+	backend.Current.Uniform1f(pc.ScreenMaterial.GetShader().GetUniform("bloomThreshold"), pc.BloomThreshold)
 
 	output.BindAndClear()
 
@@ -296,11 +319,11 @@ func (pc *PostControl) ApplyPostBloom(mainInput, bloomInput, output *EffectBuffe
 	pc.ScreenMaterial.AttachShader(pc.engine.ShaderControl.GetShader("post_postbloom"))
 
 	pc.ScreenMaterial.GetShader().Bind()
-	gl.ActiveTexture(gl.TEXTURE1)
-	gl.BindTexture(gl.TEXTURE_2D, bloomInput.RenderedTexture)
-	gl.Uniform1i(pc.ScreenMaterial.GetShader().GetUniform("bloomInput"), 1)
+	backend.Current.ActiveTexture(gl.TEXTURE1)
+	backend.Current.BindTexture(gl.TEXTURE_2D, bloomInput.RenderedTexture)
+	backend.Current.Uniform1i(pc.ScreenMaterial.GetShader().GetUniform("bloomInput"), 1)
 
-	gl.Uniform1f(pc.ScreenMaterial.GetShader().GetUniform("bloomIntensity"), pc.BloomIntensity)
+	backend.Current.Uniform1f(pc.ScreenMaterial.GetShader().GetUniform("bloomIntensity"), pc.BloomIntensity)
 
 	output.BindAndClear()
 
@@ -326,6 +349,67 @@ func (pc *PostControl) EnableHDR() {
 	pc.hdrEnabled = true
 }
 
+// Names of the effects that can be toggled with SetEffectEnabled
+const (
+	EffectPostProcessing = "postprocessing"
+	EffectHDR            = "hdr"
+	EffectBloom          = "bloom"
+	EffectScattering     = "scattering"
+)
+
+// GetEffects returns whether each effect is currently enabled
+func (pc *PostControl) GetEffects() map[string]bool {
+	return map[string]bool{
+		EffectPostProcessing: pc.PostProcessingEnabled,
+		EffectHDR:            pc.hdrEnabled,
+		EffectBloom:          pc.bloomEnabled,
+		EffectScattering:     pc.scatteringEnabled,
+	}
+}
+
+// SetEffectEnabled turns an effect on or off. Effects that need buffers
+// can only be turned back on after they've been enabled once with their
+// Enable function, since that's where their settings come from.
+func (pc *PostControl) SetEffectEnabled(effect string, enabled bool) error {
+	if err := pc.checkEffect(effect, enabled); err != nil {
+		return err
+	}
+
+	switch effect {
+	case EffectPostProcessing:
+		if enabled && pc.ScreenChild == nil {
+			pc.EnablePostProcessing()
+		}
+		pc.PostProcessingEnabled = enabled
+	case EffectHDR:
+		pc.hdrEnabled = enabled
+	case EffectBloom:
+		pc.bloomEnabled = enabled
+	case EffectScattering:
+		pc.scatteringEnabled = enabled
+	}
+	return nil
+}
+
+// checkEffect returns the error SetEffectEnabled would, without
+// changing anything
+func (pc *PostControl) checkEffect(effect string, enabled bool) error {
+	switch effect {
+	case EffectPostProcessing, EffectHDR:
+	case EffectBloom:
+		if enabled && pc.BloomBuffer1.FrameBuffer == 0 {
+			return fmt.Errorf("bloom has not been set up with EnableBloom")
+		}
+	case EffectScattering:
+		if enabled && pc.SunChild == nil {
+			return fmt.Errorf("light scattering has not been set up with EnableLightScattering")
+		}
+	default:
+		return fmt.Errorf("unknown effect %q", effect)
+	}
+	return nil
+}
+
 func (pc *PostControl) EnableGaussianBlur(iterations int, scale int) {
 	pc.gaussianEnabled = true
 	pc.gaussianIterations = iterations
@@ -364,9 +448,9 @@ type EffectBuffers struct {
 }
 
 func (eb *EffectBuffers) BindAndClear() {
-	gl.BindFramebuffer(gl.FRAMEBUFFER, eb.FrameBuffer)
-	gl.Clear(gl.COLOR_BUFFER_BIT)
-	gl.Clear(gl.DEPTH_BUFFER_BIT)
+	backend.Current.BindFramebuffer(gl.FRAMEBUFFER, eb.FrameBuffer)
+	backend.Current.Clear(gl.COLOR_BUFFER_BIT)
+	backend.Current.Clear(gl.DEPTH_BUFFER_BIT)
 }
 
 func (pc *PostControl) NewEffectBuffers(width, height int32, highPrecision bool) EffectBuffers {
@@ -375,47 +459,46 @@ func (pc *PostControl) NewEffectBuffers(width, height int32, highPrecision bool)
 	renderedTexture := uint32(0)
 
 	// Generate frame buffer
-	gl.GenFramebuffers(1, &frameBuffer)
-	gl.BindFramebuffer(gl.FRAMEBUFFER, frameBuffer)
+	frameBuffer = backend.Current.GenFramebuffer()
+	backend.Current.BindFramebuffer(gl.FRAMEBUFFER, frameBuffer)
 
 	// Generate rendered texture
-	gl.GenTextures(1, &renderedTexture)
-	gl.BindTexture(gl.TEXTURE_2D, renderedTexture)
+	renderedTexture = backend.Current.GenTexture()
+	backend.Current.BindTexture(gl.TEXTURE_2D, renderedTexture)
 
 	if highPrecision {
-		gl.TexImage2D(
+		backend.Current.TexImage2D(
 			gl.TEXTURE_2D, 0, gl.RGBA16F,
 			width, height,
-			0, gl.RGBA, gl.FLOAT, gl.PtrOffset(0),
+			gl.RGBA, gl.FLOAT, nil,
 		)
 	} else {
-		gl.TexImage2D(
+		backend.Current.TexImage2D(
 			gl.TEXTURE_2D, 0, gl.RGB,
 			width, height,
-			0, gl.RGB, gl.UNSIGNED_BYTE, gl.PtrOffset(0),
+			gl.RGB, gl.UNSIGNED_BYTE, nil,
 		)
 	}
 
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.NEAREST)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.NEAREST)
+	backend.Current.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.NEAREST)
+	backend.Current.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.NEAREST)
 
 	// Generate depth buffer
-	gl.GenRenderbuffers(1, &depthRenderBuffer)
-	gl.BindRenderbuffer(gl.RENDERBUFFER, depthRenderBuffer)
-	gl.RenderbufferStorage(gl.RENDERBUFFER, gl.DEPTH_COMPONENT, width, height)
-	gl.FramebufferRenderbuffer(gl.FRAMEBUFFER, gl.DEPTH_ATTACHMENT, gl.RENDERBUFFER, depthRenderBuffer)
+	depthRenderBuffer = backend.Current.GenRenderbuffer()
+	backend.Current.BindRenderbuffer(gl.RENDERBUFFER, depthRenderBuffer)
+	backend.Current.RenderbufferStorage(gl.RENDERBUFFER, gl.DEPTH_COMPONENT, width, height)
+	backend.Current.FramebufferRenderbuffer(gl.FRAMEBUFFER, gl.DEPTH_ATTACHMENT, gl.RENDERBUFFER, depthRenderBuffer)
 
 	// Configure framebuffer
-	gl.FramebufferTexture(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0, renderedTexture, 0)
-	drawBuffers := []uint32{gl.COLOR_ATTACHMENT0}
-	gl.DrawBuffers(1, &drawBuffers[0])
+	backend.Current.FramebufferTexture(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0, renderedTexture, 0)
+	backend.Current.DrawBuffers([]uint32{gl.COLOR_ATTACHMENT0})
 
 	// Check for errors
-	if gl.CheckFramebufferStatus(gl.FRAMEBUFFER) != gl.FRAMEBUFFER_COMPLETE {
+	if backend.Current.CheckFramebufferStatus(gl.FRAMEBUFFER) != gl.FRAMEBUFFER_COMPLETE {
 		panic("Framebuffer Invalid")
 	}
 
-	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+	backend.Current.BindFramebuffer(gl.FRAMEBUFFER, 0)
 
 	return EffectBuffers{
 		FrameBuffer:       frameBuffer,
@@ -432,71 +515,70 @@ func (pc *PostControl) NewDoubleEffectBuffers(width, height int32, highPrecision
 	renderedTexture2 := uint32(0)
 
 	// Generate frame buffer
-	gl.GenFramebuffers(1, &frameBuffer)
-	gl.BindFramebuffer(gl.FRAMEBUFFER, frameBuffer)
+	frameBuffer = backend.Current.GenFramebuffer()
+	backend.Current.BindFramebuffer(gl.FRAMEBUFFER, frameBuffer)
 
 	// Generate rendered texture 1
-	//gl.ActiveTexture(gl.TEXTURE0)
-	gl.GenTextures(1, &renderedTexture1)
-	gl.BindTexture(gl.TEXTURE_2D, renderedTexture1)
+	//backend.Current.ActiveTexture(gl.TEXTURE0)
+	renderedTexture1 = backend.Current.GenTexture()
+	backend.Current.BindTexture(gl.TEXTURE_2D, renderedTexture1)
 
 	if highPrecision {
-		gl.TexImage2D(
+		backend.Current.TexImage2D(
 			gl.TEXTURE_2D, 0, gl.RGBA16F,
 			width, height,
-			0, gl.RGBA, gl.FLOAT, gl.PtrOffset(0),
+			gl.RGBA, gl.FLOAT, nil,
 		)
 	} else {
-		gl.TexImage2D(
+		backend.Current.TexImage2D(
 			gl.TEXTURE_2D, 0, gl.RGB,
 			width, height,
-			0, gl.RGB, gl.UNSIGNED_BYTE, gl.PtrOffset(0),
+			gl.RGB, gl.UNSIGNED_BYTE, nil,
 		)
 	}
 
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.NEAREST)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.NEAREST)
+	backend.Current.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.NEAREST)
+	backend.Current.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.NEAREST)
 
 	// Generate rendered texture 2
-	//gl.ActiveTexture(gl.TEXTURE1)
-	gl.GenTextures(1, &renderedTexture2)
-	gl.BindTexture(gl.TEXTURE_2D, renderedTexture2)
+	//backend.Current.ActiveTexture(gl.TEXTURE1)
+	renderedTexture2 = backend.Current.GenTexture()
+	backend.Current.BindTexture(gl.TEXTURE_2D, renderedTexture2)
 
 	if highPrecision {
-		gl.TexImage2D(
+		backend.Current.TexImage2D(
 			gl.TEXTURE_2D, 0, gl.RGBA16F,
 			width, height,
-			0, gl.RGBA, gl.FLOAT, gl.PtrOffset(0),
+			gl.RGBA, gl.FLOAT, nil,
 		)
 	} else {
-		gl.TexImage2D(
+		backend.Current.TexImage2D(
 			gl.TEXTURE_2D, 0, gl.RGB,
 			width, height,
-			0, gl.RGB, gl.UNSIGNED_BYTE, gl.PtrOffset(0),
+			gl.RGB, gl.UNSIGNED_BYTE, nil,
 		)
 	}
 
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.NEAREST)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.NEAREST)
+	backend.Current.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.NEAREST)
+	backend.Current.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.NEAREST)
 
 	// Generate depth buffer
-	gl.GenRenderbuffers(1, &depthRenderBuffer)
-	gl.BindRenderbuffer(gl.RENDERBUFFER, depthRenderBuffer)
-	gl.RenderbufferStorage(gl.RENDERBUFFER, gl.DEPTH_COMPONENT, width, height)
-	gl.FramebufferRenderbuffer(gl.FRAMEBUFFER, gl.DEPTH_ATTACHMENT, gl.RENDERBUFFER, depthRenderBuffer)
+	depthRenderBuffer = backend.Current.GenRenderbuffer()
+	backend.Current.BindRenderbuffer(gl.RENDERBUFFER, depthRenderBuffer)
+	backend.Current.RenderbufferStorage(gl.RENDERBUFFER, gl.DEPTH_COMPONENT, width, height)
+	backend.Current.FramebufferRenderbuffer(gl.FRAMEBUFFER, gl.DEPTH_ATTACHMENT, gl.RENDERBUFFER, depthRenderBuffer)
 
 	// Configure framebuffer
-	gl.FramebufferTexture(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0, renderedTexture1, 0)
-	gl.FramebufferTexture(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT1, renderedTexture2, 0)
-	drawBuffers := []uint32{gl.COLOR_ATTACHMENT0, gl.COLOR_ATTACHMENT1}
-	gl.DrawBuffers(2, &drawBuffers[0])
+	backend.Current.FramebufferTexture(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0, renderedTexture1, 0)
+	backend.Current.FramebufferTexture(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT1, renderedTexture2, 0)
+	backend.Current.DrawBuffers([]uint32{gl.COLOR_ATTACHMENT0, gl.COLOR_ATTACHMENT1})
 
 	// Check for errors
-	if gl.CheckFramebufferStatus(gl.FRAMEBUFFER) != gl.FRAMEBUFFER_COMPLETE {
+	if backend.Current.CheckFramebufferStatus(gl.FRAMEBUFFER) != gl.FRAMEBUFFER_COMPLETE {
 		panic("Framebuffer Invalid")
 	}
 
-	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+	backend.Current.BindFramebuffer(gl.FRAMEBUFFER, 0)
 
 	return EffectBuffers{
 		FrameBuffer:       frameBuffer,
@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"fmt"
+	"rapidengine/input"
+	"rapidengine/profiler"
+	"rapidengine/ui"
+)
+
+// ProfilerOverlay draws the frame rate and the average time of every
+// profiled stage in the top left of the screen. It is a more detailed
+// alternative to the FPSBox, registered as a system when
+// Config.ShowProfiler is set.
+type ProfilerOverlay struct {
+	X          float32
+	Y          float32
+	LineHeight float32
+	Scale      float32
+	Color      [3]float32
+
+	// Frames between refreshes of the text. Values below
+	// 1 refresh it every frame.
+	RefreshRate int
+
+	lines  []*ui.TextBox
+	frames int
+
+	engine *Engine
+}
+
+func NewProfilerOverlay() *ProfilerOverlay {
+	return &ProfilerOverlay{
+		X:           10,
+		LineHeight:  20,
+		Scale:       0.6,
+		Color:       [3]float32{50, 50, 50},
+		RefreshRate: 10,
+	}
+}
+
+func (po *ProfilerOverlay) Initialize(engine *Engine) {
+	po.engine = engine
+	po.Y = float32(engine.Config.ScreenHeight - 30)
+}
+
+func (po *ProfilerOverlay) Update(renderer *Renderer, inputs *input.Input) {
+	rate := po.RefreshRate
+	if rate < 1 {
+		rate = 1
+	}
+	if po.frames%rate == 0 {
+		po.refresh()
+	}
+	po.frames++
+
+	for _, line := range po.lines {
+		line.Update(po.engine.Config)
+	}
+
+	// Text is drawn without the backend, so the GL state is unknown
+	po.engine.Renderer.State.Invalidate()
+}
+
+func (po *ProfilerOverlay) Shutdown() {}
+
+// refresh rewrites the text from the latest profiler stats,
+// adding a text box for each stage seen for the first time
+func (po *ProfilerOverlay) refresh() {
+	stats := po.engine.Profiler.Stats()
+
+	text := []string{"FPS: -"}
+	if frame, ok := po.engine.Profiler.GetStage(profiler.StageFrame); ok && frame.Avg > 0 {
+		text[0] = fmt.Sprintf("FPS: %v  (%.2f ms)", int(1/po.engine.Renderer.DeltaFrameTime), frame.Avg)
+	}
+	for _, s := range stats.Stages {
+		if s.Name == profiler.StageFrame {
+			continue
+		}
+		text = append(text, fmt.Sprintf("%v: %.2f ms  (p95 %.2f, max %.2f)", s.Name, s.Avg, s.P95, s.Max))
+	}
+
+	for len(po.lines) < len(text) {
+		y := po.Y - float32(len(po.lines))*po.LineHeight
+		po.lines = append(po.lines, po.engine.TextControl.NewTextBox("", "avenir", po.X, y, po.Scale, po.Color))
+	}
+	for i, line := range po.lines {
+		line.Text = ""
+		if i < len(text) {
+			line.Text = text[i]
+		}
+	}
+}
@@ -2,31 +2,40 @@ package cmd
 
 //   --------------------------------------------------
 //   Render.go contains the main render loop, as well as
-//   functions to initialize OpenGL and GLFW. A renderer
+//   functions to initialize the render backend. A renderer
 //   has a list of "children" which it renders every frame.
 //   --------------------------------------------------
 
 import (
+	"math"
+	"sort"
 	"time"
 
 	"github.com/go-gl/gl/v4.1-core/gl"
-	"github.com/go-gl/glfw/v3.2/glfw"
+	"github.com/go-gl/mathgl/mgl32"
 	"github.com/pkg/profile"
 	log "github.com/sirupsen/logrus"
 
+	"rapidengine/backend"
 	"rapidengine/camera"
 	"rapidengine/child"
 	"rapidengine/configuration"
-	"rapidengine/input"
+	"rapidengine/geometry"
 	"rapidengine/material"
+	"rapidengine/profiler"
+	"rapidengine/render"
+	"rapidengine/state"
 	"rapidengine/terrain"
 )
 
 // Renderer contains the information required for
 // the main render loop
 type Renderer struct {
-	// GLFW Window object
-	Window *glfw.Window
+	// Window & graphics backend
+	Backend backend.Backend
+
+	// Cache of the GL state, installed as backend.Current
+	State *state.Cache
 
 	// Current shader program
 	ShaderProgram uint32
@@ -35,12 +44,30 @@ type Renderer struct {
 	CurrentBoundVAO    uint32
 	CurrentBoundShader uint32
 
-	// Per-frame callback from the user
+	// Draws submitted by children, sorted and executed every frame
+	Queue *render.Queue
+
+	// Batches sprites drawn by the queue, or immediately between
+	// BeginSprites and Sprites.End
+	Sprites *render.SpriteBatch
+
+	// Per-frame callback, called after children are rendered
 	RenderFunc func(renderer *Renderer)
 
+	// Fixed-rate callback, called once per simulation step
+	StepFunc func(renderer *Renderer)
+
 	// Scene Camera
 	MainCamera camera.Camera
 
+	// Views the scene is drawn through, in order of priority. MainView
+	// draws through MainCamera, and is in Views unless it's removed.
+	Views    []*render.View
+	MainView *render.View
+
+	// View being drawn
+	view *render.View
+
 	// Current camera position
 	camX float32
 	camY float32
@@ -49,6 +76,16 @@ type Renderer struct {
 	// Render Distance
 	RenderDistance float32
 
+	// Children and copies outside the camera's frustum in the last frame
+	Culled int
+
+	// Reused for the indices of copies near the camera
+	copyBuffer []int
+
+	// Frustum of the camera, and the projection * view it was built from
+	frustum     geometry.Frustum
+	frustumClip mgl32.Mat4
+
 	// Skybox
 	SkyBoxEnabled bool
 	SkyBox        *terrain.SkyBox
@@ -66,6 +103,11 @@ type Renderer struct {
 	MinFrameTime   float64
 	TotalFrameTime float64
 
+	// Fixed timestep
+	StepTime    float64
+	Alpha       float64
+	accumulator float64
+
 	// Termination Channel
 	Done chan bool
 
@@ -79,118 +121,477 @@ func (renderer *Renderer) StartRenderer() {
 		defer profile.Start().Stop()
 	}
 
-	gl.ClearColor(float32(0)/255, float32(0)/255, float32(0)/255, 1)
+	backend.Current.ClearColor(float32(0)/255, float32(0)/255, float32(0)/255, 1)
 
 	// Render loop
-	for !renderer.Window.ShouldClose() {
+	for !renderer.Backend.ShouldClose() {
 		renderer.renderFrame()
 	}
 
 	renderer.Config.Logger.Info("Terminating...")
-	glfw.Terminate()
+	renderer.engine.SystemControl.Shutdown()
+	renderer.engine.InputControl.StopRecording()
+	renderer.engine.DebugServer.Shutdown()
+	renderer.Backend.Terminate()
 	renderer.Done <- true
 }
 
-// RenderFrame renders a single frame to the screen
+// maxStepsPerFrame limits how many fixed steps are run to catch up
+// after a slow frame, so the simulation can't fall further behind
+const maxStepsPerFrame = 5
+
+// RenderFrame runs any pending fixed steps, then renders a
+// single frame to the screen. Each stage of the frame is
+// timed by the engine's profiler.
 func (renderer *Renderer) renderFrame() {
-	renderer.engine.PostControl.UpdateFrameBuffers()
+	p := renderer.engine.Profiler
+	p.Begin(profiler.StageFrame)
 
-	// Render skybox
-	if renderer.SkyBoxEnabled {
-		renderer.SkyBox.Render(renderer.MainCamera)
-	}
+	// Apply edits from the inspector
+	renderer.engine.Inspector.Update()
 
-	if renderer.Config.Blending {
-		renderer.EnableBlending()
-	}
+	renderer.stepSimulation()
 
-	// Render children
-	renderer.RenderChildren()
+	renderer.engine.PostControl.UpdateFrameBuffers()
+
+	// Render each view
+	renderer.RenderViews()
 
-	// Call user render loop
+	// Call per-frame callback
 	renderer.RenderFunc(renderer)
 
-	// Update camera
-	renderer.MainCamera.Look(renderer.DeltaFrameTime)
-	renderer.camX, renderer.camY, renderer.camZ = renderer.MainCamera.GetPosition()
+	// Update cameras
+	renderer.LookCameras()
 
 	// Post processing update
+	p.Begin(profiler.StagePost)
+	p.BeginGPU(profiler.StagePost)
 	renderer.engine.PostControl.Update()
+	p.EndGPU()
+	p.End(profiler.StagePost)
 
 	// Update window buffers
-	renderer.Window.SwapBuffers()
+	p.Begin(profiler.StageSwap)
+	renderer.Backend.SwapBuffers()
+	p.End(profiler.StageSwap)
 
-	// Frame logic
-	renderer.TotalFrameTime = glfw.GetTime()
+	// Deliver deferred events
+	renderer.engine.Events.Flush()
+
+	p.End(profiler.StageFrame)
+	p.EndFrame()
+	renderer.State.EndFrame()
+
+	// Frame logic. The time is read again after sleeping, so the
+	// sleep is counted once, in this frame's delta.
+	if elapsed := renderer.Backend.GetTime() - renderer.LastFrameTime; elapsed < renderer.MinFrameTime {
+		time.Sleep(time.Duration(1000000000 * (renderer.MinFrameTime - elapsed)))
+	}
+	renderer.TotalFrameTime = renderer.Backend.GetTime()
 	renderer.DeltaFrameTime = renderer.TotalFrameTime - renderer.LastFrameTime
 	renderer.LastFrameTime = renderer.TotalFrameTime
+}
+
+// stepSimulation adds the last frame time to the accumulator and
+// calls StepFunc once for every whole step it contains. The remainder
+// is kept as Alpha, which children use to interpolate their positions.
+func (renderer *Renderer) stepSimulation() {
+	// During an input replay, the recorded frame time is used instead
+	renderer.DeltaFrameTime = renderer.engine.InputControl.BeginFrame(renderer.DeltaFrameTime)
 
-	if renderer.DeltaFrameTime < renderer.MinFrameTime {
-		time.Sleep(time.Duration(1000000000 * (renderer.MinFrameTime - renderer.DeltaFrameTime)))
-		renderer.DeltaFrameTime = renderer.MinFrameTime
+	renderer.accumulator += renderer.DeltaFrameTime
+
+	steps := 0
+	for renderer.accumulator >= renderer.StepTime {
+		if steps == maxStepsPerFrame {
+			renderer.accumulator = math.Mod(renderer.accumulator, renderer.StepTime)
+			break
+		}
+
+		renderer.StepFunc(renderer)
+		renderer.accumulator -= renderer.StepTime
+		steps++
 	}
+
+	renderer.Alpha = renderer.accumulator / renderer.StepTime
+}
+
+// ResetSimulationClock discards any partial step in the accumulator,
+// so the next frame starts exactly on a step boundary
+func (renderer *Renderer) ResetSimulationClock() {
+	renderer.accumulator = 0
+	renderer.Alpha = 0
 }
 
 // ForceUpdate forces a frame render
 func (renderer *Renderer) ForceUpdate() {
 	renderer.engine.PostControl.UpdateFrameBuffers()
-	renderer.RenderChildren()
+	renderer.RenderViews()
 	renderer.engine.PostControl.Update()
-	renderer.engine.TextControl.Update()
+	renderer.engine.TextControl.Update(renderer, nil)
 
-	renderer.Window.SwapBuffers()
+	renderer.Backend.SwapBuffers()
+}
+
+//  --------------------------------------------------
+//  Views
+//  --------------------------------------------------
+
+// RenderViews draws the scene through every enabled view, from lowest
+// to highest priority. Afterwards, the screen's framebuffer is bound
+// again with a viewport covering the whole screen.
+func (renderer *Renderer) RenderViews() {
+	p := renderer.engine.Profiler
+	renderer.Culled = 0
+
+	// Current copies are those drawn by any view this frame
+	for _, child := range renderer.engine.SceneControl.GetCurrentChildren() {
+		child.RemoveCurrentCopies()
+	}
+
+	if renderer.MainView != nil {
+		renderer.MainView.Camera = renderer.MainCamera
+	}
+	sort.SliceStable(renderer.Views, func(i, j int) bool {
+		return renderer.Views[i].Priority < renderer.Views[j].Priority
+	})
+
+	screen := renderer.engine.PostControl.ScreenFramebuffer()
+	for _, v := range renderer.Views {
+		if !v.Enabled || v.Camera == nil {
+			continue
+		}
+
+		renderer.view = v
+		renderer.camX, renderer.camY, renderer.camZ = v.Camera.GetPosition()
+		v.Camera.SetAspect(v.Aspect(renderer.Config.ScreenWidth, renderer.Config.ScreenHeight))
+		v.Bind(screen, renderer.Config.ScreenWidth, renderer.Config.ScreenHeight)
+
+		if renderer.SkyBoxEnabled && v.SkyBox {
+			p.Begin(profiler.StageSkyBox)
+			renderer.SkyBox.Render(v.Camera)
+			p.End(profiler.StageSkyBox)
+		}
+
+		if renderer.Config.Blending {
+			renderer.EnableBlending()
+		}
+
+		p.Begin(profiler.StageChildren)
+		p.BeginGPU(profiler.StageChildren)
+		renderer.RenderChildren()
+		p.EndGPU()
+		p.End(profiler.StageChildren)
+	}
+	renderer.view = nil
+
+	backend.Current.BindFramebuffer(gl.FRAMEBUFFER, screen)
+	backend.Current.Viewport(0, 0, int32(renderer.Config.ScreenWidth), int32(renderer.Config.ScreenHeight))
+}
+
+// LookCameras updates the view matrix of every view's camera once
+func (renderer *Renderer) LookCameras() {
+	renderer.MainCamera.Look(renderer.DeltaFrameTime)
+
+	looked := map[camera.Camera]bool{renderer.MainCamera: true}
+	for _, v := range renderer.Views {
+		if v.Camera != nil && !looked[v.Camera] {
+			v.Camera.Look(renderer.DeltaFrameTime)
+			looked[v.Camera] = true
+		}
+	}
+}
+
+// AddView adds a view to draw the scene through
+func (renderer *Renderer) AddView(v *render.View) {
+	renderer.Views = append(renderer.Views, v)
+}
+
+// RemoveView removes the named view
+func (renderer *Renderer) RemoveView(name string) {
+	for i, v := range renderer.Views {
+		if v.Name == name {
+			renderer.Views = append(renderer.Views[:i], renderer.Views[i+1:]...)
+			return
+		}
+	}
+}
+
+// GetView returns the named view, or nil if there isn't one
+func (renderer *Renderer) GetView(name string) *render.View {
+	for _, v := range renderer.Views {
+		if v.Name == name {
+			return v
+		}
+	}
+	return nil
+}
+
+// CurrentView returns the view being drawn, or nil between views
+func (renderer *Renderer) CurrentView() *render.View {
+	return renderer.view
+}
+
+// ViewAt returns the view drawn on top at a position on the screen,
+// in pixels from the top left like the mouse. Views drawn into
+// render targets aren't on the screen, so they're skipped.
+func (renderer *Renderer) ViewAt(x, y float32) *render.View {
+	w, h := renderer.Config.ScreenWidth, renderer.Config.ScreenHeight
+
+	var top *render.View
+	for _, v := range renderer.Views {
+		if !v.Enabled || v.Camera == nil || v.Target != nil {
+			continue
+		}
+		vx, vy, vw, vh := v.Pixels(w, h)
+		sy := float32(h) - y
+		if x < float32(vx) || x >= float32(vx+vw) || sy < float32(vy) || sy >= float32(vy+vh) {
+			continue
+		}
+		if top == nil || v.Priority >= top.Priority {
+			top = v
+		}
+	}
+	return top
+}
+
+// ScreenRay returns the ray through a position on the screen, in pixels
+// from the top left like the mouse, from the camera of the view there.
+// The view is nil, and the ray is from the main camera, if no view is.
+func (renderer *Renderer) ScreenRay(x, y float32) (mgl32.Vec3, mgl32.Vec3, *render.View) {
+	v := renderer.ViewAt(x, y)
+	if v == nil {
+		origin, dir := renderer.MainCamera.ScreenRay(x, y)
+		return origin, dir, nil
+	}
+
+	// Position in the view's own viewport
+	w, h := renderer.Config.ScreenWidth, renderer.Config.ScreenHeight
+	vx, vy, vw, vh := v.Pixels(w, h)
+	vtop := float32(h) - float32(vy+vh)
+	nx, ny := camera.ScreenToNDC(x-float32(vx), y-vtop, float32(vw), float32(vh))
+
+	if renderer.Config.Dimensions == 2 {
+		// 2D cameras convert to pixels themselves, so scale the
+		// position as if the view covered the whole screen
+		origin, dir := v.Camera.ScreenRay((nx+1)/2*float32(w), (1-ny)/2*float32(h))
+		return origin, dir, v
+	}
+	origin, dir := camera.Ray(v.Camera.GetView(), v.Camera.GetProjection(), nx, ny)
+	return origin, dir, v
+}
+
+// camera returns the camera of the view being drawn, or
+// the main camera when no view is being drawn
+func (renderer *Renderer) camera() camera.Camera {
+	if renderer.view != nil {
+		return renderer.view.Camera
+	}
+	return renderer.MainCamera
+}
+
+// inView checks if a child is on one of the layers of the view being drawn
+func (renderer *Renderer) inView(c child.Child) bool {
+	if renderer.view == nil {
+		return true
+	}
+	layer := 0
+	if l, ok := c.(child.Layered); ok {
+		layer = l.GetLayer()
+	}
+	return renderer.view.Layers&render.LayerMask(layer) != 0
 }
 
-// RenderChildren binds the appropriate shaders and Vertex Array for each child,
-// or child copy, and draws them to the screen using an element buffer
+//  --------------------------------------------------
+//  Children
+//  --------------------------------------------------
+
+// RenderChildren submits each child, or child copy, on the current view's
+// layers to the render queue, then sorts the queue and draws it
 func (renderer *Renderer) RenderChildren() {
-	if renderer.engine.SceneControl.GetCurrentScene().IsAutomaticRendering() {
+	if scn := renderer.engine.SceneControl.GetCurrentScene(); scn != nil && scn.IsAutomaticRendering() {
 		for _, child := range renderer.engine.SceneControl.GetCurrentChildren() {
-			go child.RemoveCurrentCopies()
+			if !renderer.inView(child) {
+				continue
+			}
 			if !child.CheckCopyingEnabled() {
-				renderer.RenderChild(child)
+				child.Interpolate(float32(renderer.Alpha))
+				renderer.SubmitChild(child)
 			} else {
-				renderer.RenderChildCopies(child)
+				renderer.SubmitChildCopies(child)
 			}
 		}
+
+		renderer.Queue.Flush()
 	}
 }
 
 // RenderChild renders a single child to the screen
 func (renderer *Renderer) RenderChild(c child.Child) {
-	c.Update(renderer.MainCamera, renderer.DeltaFrameTime, renderer.TotalFrameTime)
+	c.Update(renderer.camera(), renderer.DeltaFrameTime, renderer.TotalFrameTime)
 }
 
-// RenderChildCopies renders all copies of a child
+// SubmitChild adds a child to the render queue. Children that
+// can't be queued are rendered immediately, and children with
+// bounds outside the camera's frustum are skipped.
+func (renderer *Renderer) SubmitChild(c child.Child) {
+	if b, ok := c.(child.Bounded); ok {
+		if box, sphere, bounded := b.GetWorldBounds(); bounded && !renderer.InFrustum(b.GetProjection(renderer.camera()), box, sphere) {
+			renderer.Culled++
+			return
+		}
+	}
+
+	if s, ok := c.(child.Submitter); ok {
+		s.Submit(renderer.Queue, renderer.camera(), renderer.DeltaFrameTime, renderer.TotalFrameTime)
+		return
+	}
+	renderer.RenderChild(c)
+}
+
+// RenderChildCopies renders all copies of a child. Children with
+// instancing enabled draw all of their copies in one call.
 func (renderer *Renderer) RenderChildCopies(c child.Child) {
+	if ic, ok := c.(child.Instancer); ok && ic.CheckInstancingEnabled() {
+		ic.RenderInstances(renderer.camera())
+		return
+	}
+
 	renderer.BindChild(c)
 
 	copies := *(c.GetCopies())
-	for x := 0; x < c.GetNumCopies(); x++ {
+	for _, x := range renderer.nearbyCopies(c) {
 		renderer.RenderCopy(c, copies[x])
 	}
 }
 
+// SubmitChildCopies adds the visible copies of a child to the render
+// queue, or a single draw of every copy if the child is instanced.
+// Copies of children that can't be queued are rendered immediately.
+func (renderer *Renderer) SubmitChildCopies(c child.Child) {
+	if ic, ok := c.(child.Instancer); ok && ic.CheckInstancingEnabled() {
+		ic.SubmitInstances(renderer.Queue, renderer.camera())
+		return
+	}
+
+	s, ok := c.(child.CopySubmitter)
+	if !ok {
+		renderer.RenderChildCopies(c)
+		return
+	}
+
+	copies := *(c.GetCopies())
+	for _, x := range renderer.nearbyCopies(c) {
+		if renderer.copyVisible(c, copies[x]) {
+			s.SubmitCopy(renderer.Queue, copies[x], renderer.camera())
+			c.AddCurrentCopy(copies[x])
+		}
+	}
+}
+
 // RenderCopy renders a single copy of a child
 func (renderer *Renderer) RenderCopy(c child.Child, cpy child.ChildCopy) {
 	renderer.BindChild(c)
 
-	if renderer.Config.Dimensions == 2 {
-		if (c.GetSpecificRenderDistance() != 0 && InBounds2D(cpy.X, cpy.Y, float32(renderer.camX), float32(renderer.camY), c.GetSpecificRenderDistance())) ||
-			InBounds2D(cpy.X, cpy.Y, float32(renderer.camX), float32(renderer.camY), renderer.RenderDistance) {
+	if renderer.copyVisible(c, cpy) {
+		c.RenderCopy(cpy, renderer.camera())
+		c.AddCurrentCopy(cpy)
+	}
+}
+
+// nearbyCopies returns the indices of the copies of a child that may be
+// within render distance. In 2D, children that index their copies only
+// return those around the camera, otherwise every copy is returned.
+func (renderer *Renderer) nearbyCopies(c child.Child) []int {
+	renderer.copyBuffer = renderer.copyBuffer[:0]
+
+	if ci, ok := c.(child.CopyIndexer); ok && renderer.Config.Dimensions == 2 {
+		d := renderer.RenderDistance
+		if sd := c.GetSpecificRenderDistance(); sd > d {
+			d = sd
+		}
+		minX, minY, maxX, maxY := renderer.camX-d, renderer.camY-d, renderer.camX+d, renderer.camY+d
 
-			c.RenderCopy(cpy, renderer.MainCamera)
-			c.AddCurrentCopy(cpy)
+		// Copies are indexed relative to the parent, so the query
+		// covers the camera's area moved into the parent's space
+		if pc, ok := c.(child.Parented); ok {
+			minX, minY, maxX, maxY = transformRect(pc.GetNode().ParentWorld().Inv(), minX, minY, maxX, maxY)
 		}
+
+		renderer.copyBuffer = ci.QueryCopies(minX, minY, maxX, maxY, renderer.copyBuffer)
+		return renderer.copyBuffer
 	}
 
-	if renderer.Config.Dimensions == 3 {
-		if InBounds3D(cpy.X, cpy.Y, cpy.Z, float32(renderer.camX), float32(renderer.camY), float32(renderer.camZ), renderer.RenderDistance) {
-			c.RenderCopy(cpy, renderer.MainCamera)
+	for x := 0; x < c.GetNumCopies(); x++ {
+		renderer.copyBuffer = append(renderer.copyBuffer, x)
+	}
+	return renderer.copyBuffer
+}
 
-			c.AddCurrentCopy(cpy)
+// transformRect returns the bounds of a rectangle's corners
+// after they're transformed by m
+func transformRect(m mgl32.Mat4, minX, minY, maxX, maxY float32) (float32, float32, float32, float32) {
+	corners := [4]mgl32.Vec4{
+		m.Mul4x1(mgl32.Vec4{minX, minY, 0, 1}),
+		m.Mul4x1(mgl32.Vec4{maxX, minY, 0, 1}),
+		m.Mul4x1(mgl32.Vec4{minX, maxY, 0, 1}),
+		m.Mul4x1(mgl32.Vec4{maxX, maxY, 0, 1}),
+	}
+
+	minX, minY, maxX, maxY = corners[0].X(), corners[0].Y(), corners[0].X(), corners[0].Y()
+	for _, p := range corners[1:] {
+		minX = float32(math.Min(float64(minX), float64(p.X())))
+		minY = float32(math.Min(float64(minY), float64(p.Y())))
+		maxX = float32(math.Max(float64(maxX), float64(p.X())))
+		maxY = float32(math.Max(float64(maxY), float64(p.Y())))
+	}
+	return minX, minY, maxX, maxY
+}
+
+// copyVisible checks if a copy is within render distance of the camera
+func (renderer *Renderer) copyVisible(c child.Child, cpy child.ChildCopy) bool {
+	if renderer.Config.Dimensions == 2 {
+		x, y := cpy.X, cpy.Y
+		if pc, ok := c.(child.Parented); ok {
+			p := pc.GetNode().ParentWorld().Mul4x1(mgl32.Vec4{x, y, 0, 1})
+			x, y = p.X(), p.Y()
 		}
+		return (c.GetSpecificRenderDistance() != 0 && InBounds2D(x, y, float32(renderer.camX), float32(renderer.camY), c.GetSpecificRenderDistance())) ||
+			InBounds2D(x, y, float32(renderer.camX), float32(renderer.camY), renderer.RenderDistance)
 	}
+
+	if renderer.Config.Dimensions == 3 {
+		if b, ok := c.(child.Bounded); ok {
+			if box, sphere, bounded := b.GetCopyBounds(cpy); bounded {
+				if !renderer.InFrustum(b.GetProjection(renderer.camera()), box, sphere) {
+					renderer.Culled++
+					return false
+				}
+				return true
+			}
+		}
+		return InBounds3D(cpy.X, cpy.Y, cpy.Z, float32(renderer.camX), float32(renderer.camY), float32(renderer.camZ), renderer.RenderDistance)
+	}
+
+	return false
+}
+
+// Frustum returns the main camera's frustum for a projection. It's
+// only rebuilt when the camera or projection has changed.
+func (renderer *Renderer) Frustum(projection mgl32.Mat4) geometry.Frustum {
+	clip := projection.Mul4(renderer.camera().GetView())
+	if clip != renderer.frustumClip {
+		renderer.frustum = geometry.NewFrustum(clip)
+		renderer.frustumClip = clip
+	}
+	return renderer.frustum
+}
+
+// InFrustum checks if bounds are at least partly inside the
+// main camera's frustum
+func (renderer *Renderer) InFrustum(projection mgl32.Mat4, box geometry.AABB, sphere geometry.Sphere) bool {
+	f := renderer.Frustum(projection)
+	return f.IntersectsSphere(sphere) && f.IntersectsAABB(box)
 }
 
 // BindChild intelligently binds the VAO & Shader of a child
@@ -223,34 +624,43 @@ func InBounds3D(x, y, z, camX, camY, camZ, renderDistance float32) bool {
 }
 
 // NewRenderer creates a new renderer, and takes in a renderFunc which
-// is called every frame, allowing the User to have frame-by-frame control
+// is called every frame, allowing the User to have frame-by-frame control.
+// The render backend is created from the config, and installed behind the
+// renderer's state cache as the current backend for the rest of the engine.
 func NewRenderer(camera camera.Camera, config *configuration.EngineConfig) Renderer {
-	win := InitGLFW(config)
+	b := backend.New(config)
+	if err := b.Init(config); err != nil {
+		log.Fatal(err)
+	}
+	cache := state.NewCache(b)
+	backend.Current = cache
+
 	s := uint32(0)
+	mainView := render.NewView("main", camera)
 	r := Renderer{
-		Window:         win,
+		Backend:        b,
+		State:          cache,
 		ShaderProgram:  s,
 		RenderFunc:     func(r *Renderer) {},
+		StepFunc:       func(r *Renderer) {},
 		RenderDistance: 1000,
 		MinFrameTime:   1 / float64(config.MaxFPS),
+		StepTime:       1 / float64(config.StepRate),
 		Done:           make(chan bool),
 		MainCamera:     camera,
+		MainView:       mainView,
+		Views:          []*render.View{mainView},
 		Config:         config,
+		Queue:          render.NewQueue(),
 	}
 
-	r.Window.SetCursorPosCallback(input.MouseCallback)
-	r.Window.SetMouseButtonCallback(input.MouseButtonCallback)
-	r.Window.SetScrollCallback(input.ScrollCallback)
-
-	gl.Init()
-
 	return r
 }
 
 func (renderer *Renderer) Initialize(engine *Engine) {
 	renderer.engine = engine
 
-	engine.TextureControl.NewTexture("../rapidengine/assets/abstract.jpg", "default", "linear")
+	engine.TextureControl.NewTexture("engine/abstract.jpg", "default", "linear")
 
 	dm1 := renderer.engine.MaterialControl.NewBasicMaterial()
 	dm1.Hue = [4]float32{46, 49, 49, 255}
@@ -260,6 +670,26 @@ func (renderer *Renderer) Initialize(engine *Engine) {
 
 	renderer.DefaultMaterial1 = dm1
 	renderer.DefaultMaterial2 = dm2
+
+	renderer.Sprites = render.NewSpriteBatch(engine.ShaderControl.GetShader("sprite"), render.DefaultMaxSprites, renderer.Config)
+	renderer.Queue.Sprites = renderer.Sprites
+}
+
+// BeginSprites starts a batch of sprites in world pixels, seen
+// through the main camera. Draw sprites with Sprites.Draw, then
+// call Sprites.End.
+func (renderer *Renderer) BeginSprites() {
+	projection := mgl32.Ortho2D(-1, 1, -1, 1)
+	if renderer.Config.Dimensions == 2 {
+		projection = renderer.MainCamera.GetProjection()
+	}
+	renderer.Sprites.Begin(renderer.MainCamera.GetView(), projection)
+}
+
+// BeginStaticSprites starts a batch of sprites in screen
+// pixels, which don't move with the camera
+func (renderer *Renderer) BeginStaticSprites() {
+	renderer.Sprites.Begin(renderer.MainCamera.GetStaticView(), mgl32.Ortho2D(-1, 1, -1, 1))
 }
 
 // AttachCallback attaches a callback function to the renderer,
@@ -268,76 +698,35 @@ func (renderer *Renderer) AttachCallback(f func(*Renderer)) {
 	renderer.RenderFunc = f
 }
 
-func InitGLFW(config *configuration.EngineConfig) *glfw.Window {
-	if err := glfw.Init(); err != nil {
-		log.Fatal(err)
-	}
-
-	glfw.WindowHint(glfw.Samples, 4)
-	glfw.WindowHint(glfw.ContextVersionMajor, 4)
-	glfw.WindowHint(glfw.ContextVersionMinor, 1)
-	glfw.WindowHint(glfw.Resizable, glfw.False)
-	glfw.WindowHint(glfw.OpenGLProfile, glfw.OpenGLCoreProfile)
-	glfw.WindowHint(glfw.OpenGLForwardCompatible, glfw.True)
-	glfw.WindowHint(glfw.OpenGLDebugContext, glfw.True)
-
-	var m *glfw.Monitor
-	if config.FullScreen {
-		m = glfw.GetPrimaryMonitor()
-	} else {
-		m = nil
-	}
-
-	window, err := glfw.CreateWindow(config.ScreenWidth, config.ScreenHeight, config.WindowTitle, m, nil)
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	if config.Dimensions == 3 {
-		//window.SetInputMode(glfw.CursorMode, glfw.CursorDisabled)
-	}
-
-	window.MakeContextCurrent()
-
-	if config.AntiAliasing {
-		glfw.WindowHint(glfw.Samples, 8)
-	}
-
-	if !config.VSync {
-		glfw.SwapInterval(0)
-	}
-
-	return window
+// AttachStepCallback attaches a callback function to the renderer,
+// to be called once per fixed step
+func (renderer *Renderer) AttachStepCallback(f func(*Renderer)) {
+	renderer.StepFunc = f
 }
 
 func InitOpenGL(config *configuration.EngineConfig) uint32 {
-	if err := gl.Init(); err != nil {
-		log.Fatal(err)
-	}
-
-	version := gl.GoStr(gl.GetString(gl.VERSION))
-	log.Info("Using OpenGL Version ", version)
+	log.Info("Using OpenGL Version ", backend.Current.Version())
 
 	if config.PolygonLines {
-		gl.PolygonMode(gl.FRONT_AND_BACK, gl.LINE)
+		backend.Current.PolygonMode(gl.FRONT_AND_BACK, gl.LINE)
 	}
 
-	gl.Enable(gl.BLEND)
-	gl.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
+	backend.Current.Enable(gl.BLEND)
+	backend.Current.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
 
 	if config.Dimensions == 3 {
-		gl.Enable(gl.DEPTH_TEST)
-		gl.Disable(gl.CULL_FACE)
+		backend.Current.Enable(gl.DEPTH_TEST)
+		backend.Current.Disable(gl.CULL_FACE)
 	} else {
-		gl.Disable(gl.DEPTH_TEST)
+		backend.Current.Disable(gl.DEPTH_TEST)
 	}
 
 	if config.GammaCorrection {
-		gl.Enable(gl.FRAMEBUFFER_SRGB)
+		backend.Current.Enable(gl.FRAMEBUFFER_SRGB)
 	}
 
 	if config.AntiAliasing {
-		gl.Enable(gl.MULTISAMPLE)
+		backend.Current.Enable(gl.MULTISAMPLE)
 	}
 
 	return 0
@@ -345,45 +734,45 @@ func InitOpenGL(config *configuration.EngineConfig) uint32 {
 
 func (renderer *Renderer) ResetOpenGL(config *configuration.EngineConfig) {
 	if config.PolygonLines {
-		gl.PolygonMode(gl.FRONT_AND_BACK, gl.LINE)
+		backend.Current.PolygonMode(gl.FRONT_AND_BACK, gl.LINE)
 	}
 
-	gl.Enable(gl.BLEND)
-	gl.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
+	backend.Current.Enable(gl.BLEND)
+	backend.Current.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
 
 	if config.Dimensions == 3 {
-		gl.Enable(gl.DEPTH_TEST)
-		gl.Disable(gl.CULL_FACE)
+		backend.Current.Enable(gl.DEPTH_TEST)
+		backend.Current.Disable(gl.CULL_FACE)
 	} else {
-		gl.Disable(gl.DEPTH_TEST)
+		backend.Current.Disable(gl.DEPTH_TEST)
 	}
 
 	if config.GammaCorrection {
-		gl.Enable(gl.FRAMEBUFFER_SRGB)
+		backend.Current.Enable(gl.FRAMEBUFFER_SRGB)
 	}
 
 	if config.AntiAliasing {
-		gl.Enable(gl.MULTISAMPLE)
+		backend.Current.Enable(gl.MULTISAMPLE)
 	}
 }
 
 func (renderer *Renderer) EnablePolygonLines() {
 	renderer.engine.Config.PolygonLines = true
-	gl.PolygonMode(gl.FRONT_AND_BACK, gl.LINE)
+	backend.Current.PolygonMode(gl.FRONT_AND_BACK, gl.LINE)
 }
 
 func (renderer *Renderer) DisablePolygonLines() {
 	renderer.engine.Config.PolygonLines = false
-	gl.PolygonMode(gl.FRONT_AND_BACK, gl.FILL)
+	backend.Current.PolygonMode(gl.FRONT_AND_BACK, gl.FILL)
 }
 
 func (renderer *Renderer) EnableBlending() {
-	gl.Enable(gl.BLEND)
-	gl.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
+	backend.Current.Enable(gl.BLEND)
+	backend.Current.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
 }
 
 func (renderer *Renderer) DisableBlending() {
-	gl.Disable(gl.BLEND)
+	backend.Current.Disable(gl.BLEND)
 }
 
 // SetRenderDistance sets the render distance
@@ -392,17 +781,17 @@ func (renderer *Renderer) SetRenderDistance(distance float32) {
 }
 
 func (renderer *Renderer) DisableCursor() {
-	renderer.Window.SetInputMode(glfw.CursorMode, glfw.CursorDisabled)
+	renderer.Backend.SetCursorEnabled(false)
 }
 
 func (renderer *Renderer) EnableCursor() {
-	renderer.Window.SetInputMode(glfw.CursorMode, glfw.CursorNormal)
+	renderer.Backend.SetCursorEnabled(true)
 }
 
 // CheckError decodes the various unhelpful error codes
 // which OpenGL sometimes creates
 func CheckError(tag string) {
-	if err := gl.GetError(); err != 0 {
+	if err := backend.Current.GetError(); err != 0 {
 		var errString = ""
 		switch err {
 		case 0:
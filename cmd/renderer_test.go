@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+
+	"rapidengine/camera"
+	"rapidengine/child"
+	"rapidengine/render"
+)
+
+func TestNearbyCopiesOfParentedChild(t *testing.T) {
+	e := newHeadlessEngine(t, 2, func() {})
+
+	parent := e.ChildControl.NewChild2D()
+	parent.SetPosition(5000, 0)
+
+	c := e.ChildControl.NewChild2D()
+	c.EnableCopying()
+	c.AddCopy(child.ChildCopy{X: 0, Y: 0})     // at 5000, 0 in the world
+	c.AddCopy(child.ChildCopy{X: -5000, Y: 0}) // at the camera
+	c.SetParent(parent)
+
+	r := &e.Renderer
+	r.camX, r.camY = 0, 0
+	r.RenderDistance = 1000
+
+	visible := []int{}
+	for _, i := range r.nearbyCopies(c) {
+		if r.copyVisible(c, (*c.GetCopies())[i]) {
+			visible = append(visible, i)
+		}
+	}
+	if !reflect.DeepEqual(visible, []int{1}) {
+		t.Errorf("visible copies %v, want [1]", visible)
+	}
+}
+
+func TestCurrentCopiesFromEveryView(t *testing.T) {
+	e := newHeadlessEngine(t, 2, func() {})
+	scn := e.SceneControl.NewScene("main")
+	e.SceneControl.InstanceScene(scn)
+	e.SceneControl.SetCurrentScene(scn)
+
+	far := camera.NewCamera2D(mgl32.Vec3{10, 0, 0}, 1, e.Config)
+	far.TargetPosition = far.Position
+	e.Renderer.AddView(render.NewView("far", far))
+	e.Renderer.RenderDistance = 1000
+
+	// One copy in front of each camera
+	c := e.ChildControl.NewChild2D()
+	m := e.MaterialControl.NewBasicMaterial()
+	c.AttachMaterial(m)
+	c.EnableCopying()
+	for _, cam := range []camera.Camera{e.Renderer.MainCamera, far} {
+		x, y, _ := cam.GetPosition()
+		c.AddCopy(child.ChildCopy{X: x, Y: y, Material: m})
+	}
+	scn.InstanceChild(c)
+
+	for i := 0; i < 3; i++ {
+		e.Step()
+		if copies := c.GetCurrentCopies(); len(copies) != 2 {
+			t.Fatalf("frame %v: current copies %v, want one from each view", i, copies)
+		}
+	}
+}
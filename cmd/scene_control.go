@@ -1,7 +1,10 @@
 package cmd
 
 import (
+	"github.com/go-gl/mathgl/mgl32"
+
 	"rapidengine/child"
+	"rapidengine/geometry"
 	"rapidengine/ui"
 )
 
@@ -33,24 +36,80 @@ func (sc *SceneControl) PreRenderChildren() {
 	}
 }
 
+// SetCurrentScene activates a scene and makes it the one that's
+// stepped and rendered. It can be nil, to step and render nothing.
 func (sc *SceneControl) SetCurrentScene(scn *Scene) {
+	previous := sc.currentScene
+
 	sc.ClearActivation()
 	sc.currentScene = scn
-	sc.currentScene.Activate()
+	if scn != nil {
+		scn.Activate()
+	}
+
+	sc.engine.Events.Publish(SceneChanged{Previous: previous, Current: scn})
 }
 
 func (sc *SceneControl) GetCurrentScene() *Scene {
 	return sc.currentScene
 }
 
+// GetCurrentChildren returns the children of the current
+// scene, or nil if there isn't one
 func (sc *SceneControl) GetCurrentChildren() []child.Child {
+	if sc.currentScene == nil {
+		return nil
+	}
 	return sc.currentScene.GetChildren()
 }
 
+// GetCurrentTexts returns the texts of the current
+// scene, or nil if there isn't one
 func (sc *SceneControl) GetCurrentTexts() []*ui.TextBox {
+	if sc.currentScene == nil {
+		return nil
+	}
 	return sc.currentScene.GetTexts()
 }
 
+// Raycast returns the distance along a ray to the nearest bounds of a
+// child, or child copy, in the current scene, and false if nothing is
+// hit within maxDist. Bounds containing the origin are ignored, so a
+// ray cast from a child doesn't hit the child itself. dir must be
+// normalized. It can be used as a camera.Raycaster.
+func (sc *SceneControl) Raycast(origin, dir mgl32.Vec3, maxDist float32) (float32, bool) {
+	nearest, hit := maxDist, false
+	test := func(box geometry.AABB) {
+		if box.Contains(origin) {
+			return
+		}
+		if t, ok := box.IntersectRay(origin, dir); ok && t < nearest {
+			nearest, hit = t, true
+		}
+	}
+
+	for _, c := range sc.GetCurrentChildren() {
+		b, ok := c.(child.Bounded)
+		if !ok {
+			continue
+		}
+
+		if !c.CheckCopyingEnabled() {
+			if box, _, bounded := b.GetWorldBounds(); bounded {
+				test(box)
+			}
+			continue
+		}
+		for _, cpy := range *c.GetCopies() {
+			if box, _, bounded := b.GetCopyBounds(cpy); bounded {
+				test(box)
+			}
+		}
+	}
+
+	return nearest, hit
+}
+
 func (sc *SceneControl) ClearActivation() {
 	for _, scn := range sc.scenes {
 		scn.Deactivate()
@@ -0,0 +1,1029 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"reflect"
+
+	"github.com/go-gl/mathgl/mgl32"
+
+	"rapidengine/assets"
+	"rapidengine/child"
+	"rapidengine/geometry"
+	"rapidengine/material"
+	"rapidengine/physics"
+	"rapidengine/ui"
+)
+
+//  --------------------------------------------------
+//  Scene_file.go contains the scene file format, which
+//  saves a scene with its subscenes, children, texts,
+//  materials and references to the assets they use.
+//
+//  Files are JSON, or a compact binary form: the bytes
+//  "RSCN", the version as a little endian uint32, then
+//  the same document encoded with encoding/gob.
+//
+//  Textures, models and obj meshes are saved by their
+//  name or path and loaded again from disk. Primitive
+//  meshes are regenerated, and other meshes are saved
+//  with their vertices. Materials are saved with their
+//  exported parameters, and shared between children by
+//  their index in the file.
+//
+//  Callbacks, animations, lights, terrain and UI
+//  elements aren't saved, and neither are textures
+//  that materials hold as raw GL handles.
+//  --------------------------------------------------
+
+// SceneFileVersion is the version of the scene files written.
+// Files of newer versions can't be loaded.
+const SceneFileVersion = 1
+
+// ErrSceneVersion is returned when loading a scene file
+// of a version this engine can't read
+var ErrSceneVersion = errors.New("scene file: unsupported version")
+
+// Magic bytes starting binary scene files
+var sceneMagic = []byte("RSCN")
+
+// SceneFormat is the encoding of a scene file
+type SceneFormat int
+
+const (
+	SceneJSON SceneFormat = iota
+	SceneBinary
+)
+
+type SceneFile struct {
+	Version int `json:"version"`
+
+	Textures  []TextureRef   `json:"textures,omitempty"`
+	Materials []MaterialData `json:"materials,omitempty"`
+
+	Scene SceneData `json:"scene"`
+}
+
+// TextureRef refers to a texture by name, and the
+// file it's loaded from if it isn't loaded yet
+type TextureRef struct {
+	Name   string `json:"name"`
+	Path   string `json:"path"`
+	Filter string `json:"filter"`
+}
+
+// MaterialData holds a material's type and exported parameters. The
+// renderer's default material is saved with the type "default". Name
+// is the material's name in MaterialControl.Materials, if it has one,
+// and a loaded material of that name is used instead of a new one.
+type MaterialData struct {
+	Type string `json:"type"`
+	Name string `json:"name,omitempty"`
+
+	// Texture fields, by the index of the texture in the file
+	Textures map[string]int `json:"textures,omitempty"`
+
+	Params map[string]json.RawMessage `json:"params,omitempty"`
+}
+
+type SceneData struct {
+	ID                 string `json:"id"`
+	Active             bool   `json:"active"`
+	AutomaticRendering bool   `json:"automaticRendering"`
+
+	Children  []ChildData `json:"children,omitempty"`
+	Texts     []TextData  `json:"texts,omitempty"`
+	Subscenes []SceneData `json:"subscenes,omitempty"`
+}
+
+// ChildData holds a 2D or 3D child. Parent is the index of the
+// child's parent among every child in the file, counted through
+// each scene's children before its subscenes, or -1. Material
+// is an index into the file's materials, or -1 if there's none.
+type ChildData struct {
+	Type   string `json:"type"`
+	Active bool   `json:"active"`
+	Parent int    `json:"parent"`
+
+	Group string `json:"group,omitempty"`
+	Layer int    `json:"layer,omitempty"`
+
+	Position [3]float32 `json:"position"`
+	Velocity [3]float32 `json:"velocity"`
+	Gravity  float32    `json:"gravity,omitempty"`
+
+	// Rotation quaternion, as x, y, z, w
+	Rotation [4]float32 `json:"rotation"`
+	Scale    [3]float32 `json:"scale"`
+
+	Material int `json:"material"`
+
+	// The mesh of a 2D child, or the model of a 3D child
+	Mesh  *MeshData  `json:"mesh,omitempty"`
+	Model *ModelData `json:"model,omitempty"`
+
+	Collider       *physics.Collider `json:"collider,omitempty"`
+	RenderDistance float32           `json:"renderDistance,omitempty"`
+
+	// 2D only
+	Static   bool    `json:"static,omitempty"`
+	Batching bool    `json:"batching,omitempty"`
+	Darkness float32 `json:"darkness,omitempty"`
+
+	Copying    bool       `json:"copying,omitempty"`
+	Instancing bool       `json:"instancing,omitempty"`
+	Copies     []CopyData `json:"copies,omitempty"`
+}
+
+// MeshData refers to a primitive or obj mesh by its ID and the
+// scale it was loaded at, or holds the vertices of any other mesh
+type MeshData struct {
+	ID    string  `json:"id"`
+	Scale float32 `json:"scale,omitempty"`
+
+	Vertices  []float32 `json:"vertices,omitempty"`
+	Indices   []uint32  `json:"indices,omitempty"`
+	TexCoords []float32 `json:"texCoords,omitempty"`
+	Normals   []float32 `json:"normals,omitempty"`
+
+	ModelMaterial int `json:"modelMaterial,omitempty"`
+}
+
+// ModelData refers to a model by its path, or holds its meshes.
+// Materials maps the model's material indices to the file's.
+type ModelData struct {
+	Path      string      `json:"path,omitempty"`
+	Meshes    []MeshData  `json:"meshes,omitempty"`
+	Materials map[int]int `json:"materials,omitempty"`
+}
+
+type CopyData struct {
+	Position [3]float32 `json:"position"`
+
+	// Rotation quaternion, as x, y, z, w. Zero is no rotation.
+	Rotation   [4]float32 `json:"rotation"`
+	Scale      [3]float32 `json:"scale"`
+	Material   int        `json:"material"`
+	Darkness   float32    `json:"darkness"`
+	AtlasIndex int        `json:"atlasIndex,omitempty"`
+	ID         string     `json:"id,omitempty"`
+}
+
+// TextData holds a text box. Color is from 0 to 1, and Parent
+// is the index of a child as in ChildData, or -1.
+type TextData struct {
+	Text   string     `json:"text"`
+	Font   string     `json:"font"`
+	X      float32    `json:"x"`
+	Y      float32    `json:"y"`
+	Scale  float32    `json:"scale"`
+	Color  [3]float32 `json:"color"`
+	Parent int        `json:"parent"`
+}
+
+//  --------------------------------------------------
+//  Disk
+//  --------------------------------------------------
+
+// SaveScene writes a scene, and its subscenes, to a file
+func (sc *SceneControl) SaveScene(scn *Scene, path string, format SceneFormat) error {
+	var buf bytes.Buffer
+	f := sc.EncodeScene(scn)
+	if err := WriteSceneFile(&buf, &f, format); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, buf.Bytes(), 0644)
+}
+
+// LoadScene loads a scene from a JSON or binary file, found through
+// the engine's Assets, and instances it. It doesn't become the current
+// scene until SetCurrentScene.
+func (sc *SceneControl) LoadScene(path string) (*Scene, error) {
+	f, err := sc.readSceneFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	scn := sc.NewScene(f.Scene.ID)
+	if err := sc.DecodeScene(f, scn); err != nil {
+		return nil, err
+	}
+	applySceneFlags(f.Scene, scn)
+	sc.InstanceScene(scn)
+	return scn, nil
+}
+
+// LoadSceneInto loads a scene file into a live scene, adding the
+// file's children, texts and subscenes to those it already has
+func (sc *SceneControl) LoadSceneInto(scn *Scene, path string) error {
+	f, err := sc.readSceneFile(path)
+	if err != nil {
+		return err
+	}
+	return sc.DecodeScene(f, scn)
+}
+
+func (sc *SceneControl) readSceneFile(path string) (*SceneFile, error) {
+	r, err := sc.engine.Assets.OpenAsset(path)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	f, err := ReadSceneFile(r)
+	if err != nil {
+		return nil, &assets.DecodeError{Path: path, Format: "scene", Err: err}
+	}
+	return f, nil
+}
+
+// WriteSceneFile encodes a scene file as JSON or binary
+func WriteSceneFile(w io.Writer, f *SceneFile, format SceneFormat) error {
+	if format == SceneJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(f)
+	}
+
+	if _, err := w.Write(sceneMagic); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.LittleEndian, uint32(f.Version)); err != nil {
+		return err
+	}
+	return gob.NewEncoder(w).Encode(f)
+}
+
+// ReadSceneFile decodes a JSON or binary scene file. It returns
+// ErrSceneVersion if the file is newer than SceneFileVersion.
+func ReadSceneFile(r io.Reader) (*SceneFile, error) {
+	blob, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &SceneFile{}
+	if bytes.HasPrefix(blob, sceneMagic) {
+		blob = blob[len(sceneMagic):]
+		if len(blob) < 4 {
+			return nil, errors.New("scene file: truncated header")
+		}
+		if err := checkSceneVersion(int(binary.LittleEndian.Uint32(blob))); err != nil {
+			return nil, err
+		}
+		if err := gob.NewDecoder(bytes.NewReader(blob[4:])).Decode(f); err != nil {
+			return nil, fmt.Errorf("scene file: %v", err)
+		}
+	} else if err := json.Unmarshal(blob, f); err != nil {
+		return nil, fmt.Errorf("scene file: %v", err)
+	}
+
+	return f, checkSceneVersion(f.Version)
+}
+
+func checkSceneVersion(v int) error {
+	if v < 1 || v > SceneFileVersion {
+		return fmt.Errorf("version %v: %w", v, ErrSceneVersion)
+	}
+	return nil
+}
+
+//  --------------------------------------------------
+//  Encoding
+//  --------------------------------------------------
+
+// sceneEncoder collects the assets shared between
+// children while a scene is encoded
+type sceneEncoder struct {
+	file *SceneFile
+
+	textures  map[*material.Texture]int
+	materials map[material.Material]int
+
+	// Index of every child in the file, which parents refer to
+	children map[child.Parented]int
+
+	names map[material.Material]string
+	fps   *ui.TextBox
+
+	// Saved as a reference, rather than with its parameters
+	defaultMaterial material.Material
+}
+
+// EncodeScene returns a scene, and its subscenes, as a scene file
+func (sc *SceneControl) EncodeScene(scn *Scene) SceneFile {
+	e := &sceneEncoder{
+		file:      &SceneFile{Version: SceneFileVersion},
+		textures:  make(map[*material.Texture]int),
+		materials: make(map[material.Material]int),
+		children:  make(map[child.Parented]int),
+		names:     make(map[material.Material]string),
+		fps:       sc.engine.FPSBox,
+
+		defaultMaterial: sc.engine.Renderer.DefaultMaterial1,
+	}
+	for name, m := range sc.engine.MaterialControl.Materials {
+		e.names[m] = name
+	}
+
+	e.index(scn)
+	e.file.Scene = e.scene(scn)
+	return *e.file
+}
+
+// index numbers the children saved, in the order they're saved
+func (e *sceneEncoder) index(scn *Scene) {
+	for _, c := range scn.children {
+		if p, ok := savedChild(c); ok {
+			e.children[p] = len(e.children)
+		}
+	}
+	for _, sub := range scn.subscenes {
+		e.index(sub)
+	}
+}
+
+// parent returns the index of a parent in the file, or -1 if
+// there's no parent or it isn't saved
+func (e *sceneEncoder) parent(p child.Parented) int {
+	if i, ok := e.children[p]; ok && p != nil {
+		return i
+	}
+	return -1
+}
+
+// savedChild returns whether a child is saved: only 2D and 3D children are
+func savedChild(c child.Child) (child.Parented, bool) {
+	switch c := c.(type) {
+	case *child.Child2D:
+		return c, true
+	case *child.Child3D:
+		return c, true
+	}
+	return nil, false
+}
+
+func (e *sceneEncoder) scene(scn *Scene) SceneData {
+	data := SceneData{
+		ID:                 scn.ID,
+		Active:             scn.active,
+		AutomaticRendering: scn.automaticRendering,
+	}
+
+	for _, c := range scn.children {
+		if p, ok := savedChild(c); ok {
+			cd := e.child(c)
+			cd.Parent = e.parent(p.GetNode().GetParent())
+			data.Children = append(data.Children, cd)
+		}
+	}
+
+	for _, t := range scn.texts {
+		if t == nil || t == e.fps {
+			continue
+		}
+		data.Texts = append(data.Texts, TextData{
+			Text:   t.Text,
+			Font:   t.Font,
+			X:      t.X,
+			Y:      t.Y,
+			Scale:  t.Scale,
+			Color:  t.Color,
+			Parent: e.parent(t.Parent),
+		})
+	}
+
+	for _, sub := range scn.subscenes {
+		data.Subscenes = append(data.Subscenes, e.scene(sub))
+	}
+
+	return data
+}
+
+// child returns the data of a 2D or 3D child
+func (e *sceneEncoder) child(c child.Child) ChildData {
+	cd := ChildData{
+		Active:   c.IsActive(),
+		Parent:   -1,
+		Material: -1,
+		Copying:  c.CheckCopyingEnabled(),
+	}
+
+	var t geometry.Transform
+	switch c := c.(type) {
+	case *child.Child2D:
+		cd.Type = "2d"
+		cd.Group, cd.Layer = c.Group, c.Layer
+		cd.Position = [3]float32{c.GetX(), c.GetY(), 0}
+		cd.Velocity = [3]float32{c.VX, c.VY, 0}
+		cd.Gravity = c.Gravity
+		cd.Material = e.material(c.GetMaterial())
+		cd.Mesh = e.mesh(c.Mesh)
+		cd.Static, cd.Batching, cd.Darkness = c.Static, c.CheckBatchingEnabled(), c.Darkness
+		cd.Instancing = c.CheckInstancingEnabled()
+		t = c.Transform
+	case *child.Child3D:
+		cd.Type = "3d"
+		cd.Group, cd.Layer = c.Group, c.Layer
+		cd.Position = [3]float32{c.GetX(), c.GetY(), c.GetZ()}
+		cd.Velocity = [3]float32{c.VX, c.VY, c.VZ}
+		cd.Gravity = c.Gravity
+		cd.Material = e.material(c.Material)
+		cd.Model = e.model(c.Model)
+		cd.Instancing = c.CheckInstancingEnabled()
+		t = c.Transform
+	}
+
+	q := t.Rotation.Normalize()
+	cd.Rotation = [4]float32{q.X(), q.Y(), q.Z(), q.W}
+	cd.Scale = t.Scale
+	cd.RenderDistance = c.GetSpecificRenderDistance()
+	if col := c.GetCollider(); col != nil && *col != (physics.Collider{}) {
+		saved := *col
+		cd.Collider = &saved
+	}
+
+	for _, cpy := range *c.GetCopies() {
+		r := cpy.Rotation
+		cd.Copies = append(cd.Copies, CopyData{
+			Position:   [3]float32{cpy.X, cpy.Y, cpy.Z},
+			Rotation:   [4]float32{r.X(), r.Y(), r.Z(), r.W},
+			Scale:      [3]float32{cpy.ScaleX, cpy.ScaleY, cpy.ScaleZ},
+			Material:   e.material(cpy.Material),
+			Darkness:   cpy.Darkness,
+			AtlasIndex: cpy.AtlasIndex,
+			ID:         cpy.ID,
+		})
+	}
+
+	return cd
+}
+
+// Meshes regenerated from their ID when loaded
+var primitiveMeshes = map[string]func() geometry.Mesh{
+	"rectangle": geometry.NewRectangle,
+	"screen":    geometry.NewScreenQuad,
+	"cube":      geometry.NewCube,
+	"billboard": geometry.NewBillBoard,
+}
+
+func (e *sceneEncoder) mesh(m geometry.Mesh) *MeshData {
+	md := &MeshData{ID: m.ID, ModelMaterial: m.ModelMaterial}
+	if _, ok := primitiveMeshes[m.ID]; ok || m.VAO == nil {
+		return md
+	}
+	if m.FileScale != 0 {
+		md.Scale = m.FileScale
+		return md
+	}
+
+	md.Vertices = m.VAO.GetVertices()
+	md.Indices = m.VAO.GetIndices()
+	md.TexCoords = m.TexCoords
+	md.Normals = m.Normals
+	return md
+}
+
+func (e *sceneEncoder) model(m geometry.Model) *ModelData {
+	md := &ModelData{Path: m.Path, Materials: make(map[int]int)}
+	for i, mat := range m.Materials {
+		md.Materials[i] = e.material(mat)
+	}
+	if m.Path != "" {
+		return md
+	}
+	for _, ms := range m.Meshes {
+		md.Meshes = append(md.Meshes, *e.mesh(ms))
+	}
+	return md
+}
+
+// material returns the index of a material in the file, adding
+// it if it isn't there yet, or -1 if the material is nil
+func (e *sceneEncoder) material(m material.Material) int {
+	if m == nil {
+		return -1
+	}
+	v := reflect.ValueOf(m)
+	if v.Kind() == reflect.Ptr && v.IsNil() {
+		return -1
+	}
+	if i, ok := e.materials[m]; ok {
+		return i
+	}
+
+	if m == e.defaultMaterial {
+		e.materials[m] = len(e.file.Materials)
+		e.file.Materials = append(e.file.Materials, MaterialData{Type: "default"})
+		return e.materials[m]
+	}
+
+	md := MaterialData{
+		Type:     materialType(m),
+		Name:     e.names[m],
+		Textures: make(map[string]int),
+		Params:   make(map[string]json.RawMessage),
+	}
+
+	if v = reflect.Indirect(v); v.Kind() == reflect.Struct {
+		for i := 0; i < v.NumField(); i++ {
+			f := v.Type().Field(i)
+			if f.PkgPath != "" || f.Tag.Get("json") == "-" {
+				continue
+			}
+
+			fv := v.Field(i)
+			if f.Type == textureType {
+				if tx := fv.Interface().(*material.Texture); tx != nil {
+					md.Textures[f.Name] = e.texture(tx)
+				}
+				continue
+			}
+			if !plainType(f.Type) {
+				continue
+			}
+			if raw, err := json.Marshal(fv.Interface()); err == nil {
+				md.Params[f.Name] = raw
+			}
+		}
+	}
+
+	e.materials[m] = len(e.file.Materials)
+	e.file.Materials = append(e.file.Materials, md)
+	return e.materials[m]
+}
+
+func (e *sceneEncoder) texture(t *material.Texture) int {
+	if i, ok := e.textures[t]; ok {
+		return i
+	}
+	e.textures[t] = len(e.file.Textures)
+	e.file.Textures = append(e.file.Textures, TextureRef{Name: t.Name, Path: t.Path, Filter: t.Filter})
+	return e.textures[t]
+}
+
+var textureType = reflect.TypeOf(&material.Texture{})
+
+// plainType returns whether values of a type are saved as material
+// parameters: numbers, bools, strings, and arrays and slices of them
+func plainType(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	case reflect.Array, reflect.Slice:
+		return plainType(t.Elem())
+	}
+	return false
+}
+
+// materialType returns the name a material's type is saved as
+func materialType(m material.Material) string {
+	switch m.(type) {
+	case *material.BasicMaterial:
+		return "basic"
+	case *material.StandardMaterial:
+		return "standard"
+	case *material.PBRMaterial:
+		return "pbr"
+	case *material.CubemapMaterial:
+		return "cubemap"
+	case *material.TerrainMaterial:
+		return "terrain"
+	case *material.FoliageMaterial:
+		return "foliage"
+	case *material.WaterMaterial:
+		return "water"
+	}
+	return fmt.Sprintf("%T", m)
+}
+
+//  --------------------------------------------------
+//  Decoding
+//  --------------------------------------------------
+
+// sceneDecoder holds the assets shared between
+// children while a scene file is decoded
+type sceneDecoder struct {
+	sc   *SceneControl
+	file *SceneFile
+
+	textures  []*material.Texture
+	materials []material.Material
+
+	// Named materials created from the file, which are added
+	// to MaterialControl.Materials once the file has loaded
+	named map[string]material.Material
+
+	// Every child in the file, and the parents to set once
+	// they've all been created
+	children     []child.Child
+	childParents map[child.Child]int
+	textParents  map[*ui.TextBox]int
+}
+
+// DecodeScene adds the children, texts and subscenes of a scene
+// file to a scene, loading the assets they refer to. The scene
+// keeps its own ID and flags. The file is checked before anything
+// is loaded from it, and the scene isn't changed if it's rejected.
+func (sc *SceneControl) DecodeScene(f *SceneFile, scn *Scene) error {
+	if err := checkSceneFile(f); err != nil {
+		return err
+	}
+
+	d := &sceneDecoder{
+		sc:           sc,
+		file:         f,
+		named:        make(map[string]material.Material),
+		childParents: make(map[child.Child]int),
+		textParents:  make(map[*ui.TextBox]int),
+	}
+	for _, t := range f.Textures {
+		d.textures = append(d.textures, d.texture(t))
+	}
+	for _, m := range f.Materials {
+		d.materials = append(d.materials, d.material(m))
+	}
+
+	// Built on its own, then moved into scn once it's complete
+	built := &Scene{}
+	d.scene(f.Scene, built)
+
+	for c, i := range d.childParents {
+		if err := d.setParent(c.(child.Parented), i); err != nil {
+			return err
+		}
+	}
+	for t, i := range d.textParents {
+		t.Parent, _ = d.children[i].(child.Parented)
+	}
+
+	for name, m := range d.named {
+		sc.engine.MaterialControl.Materials[name] = m
+	}
+	for _, c := range built.children {
+		scn.InstanceChild(c)
+	}
+	for _, t := range built.texts {
+		scn.InstanceText(t)
+	}
+	for _, sub := range built.subscenes {
+		scn.InstanceSubscene(sub)
+	}
+	return nil
+}
+
+// checkSceneFile checks the version of a scene file, and every index
+// in it, so a bad file is rejected before anything is loaded from it
+func checkSceneFile(f *SceneFile) error {
+	if err := checkSceneVersion(f.Version); err != nil {
+		return err
+	}
+
+	for i, md := range f.Materials {
+		for name, t := range md.Textures {
+			if t < 0 || t >= len(f.Textures) {
+				return fmt.Errorf("scene file: material %v: no texture %v for %v", i, t, name)
+			}
+		}
+	}
+
+	// Children and texts in the order they're numbered
+	var children []ChildData
+	var texts []TextData
+	var collect func(sd SceneData)
+	collect = func(sd SceneData) {
+		children = append(children, sd.Children...)
+		texts = append(texts, sd.Texts...)
+		for _, sub := range sd.Subscenes {
+			collect(sub)
+		}
+	}
+	collect(f.Scene)
+
+	material := func(i int) bool {
+		return i >= -1 && i < len(f.Materials)
+	}
+	for i, cd := range children {
+		if cd.Type != "2d" && cd.Type != "3d" {
+			return fmt.Errorf("scene file: child %v has unknown type %q", i, cd.Type)
+		}
+		if cd.Parent < -1 || cd.Parent >= len(children) {
+			return fmt.Errorf("scene file: child %v: no child %v to parent to", i, cd.Parent)
+		}
+		if !material(cd.Material) {
+			return fmt.Errorf("scene file: child %v: no material %v", i, cd.Material)
+		}
+		for _, cpy := range cd.Copies {
+			if !material(cpy.Material) {
+				return fmt.Errorf("scene file: child %v copy %v: no material %v", i, cpy.ID, cpy.Material)
+			}
+		}
+		if cd.Model != nil {
+			for _, m := range cd.Model.Materials {
+				if !material(m) {
+					return fmt.Errorf("scene file: child %v model: no material %v", i, m)
+				}
+			}
+		}
+	}
+
+	// A chain of parents longer than the number of children loops
+	for i := range children {
+		steps := 0
+		for p := children[i].Parent; p >= 0; p = children[p].Parent {
+			if steps++; steps > len(children) {
+				return fmt.Errorf("scene file: child %v is its own ancestor", i)
+			}
+		}
+	}
+
+	for i, td := range texts {
+		if td.Parent < -1 || td.Parent >= len(children) {
+			return fmt.Errorf("scene file: text %v: no child %v to parent to", i, td.Parent)
+		}
+	}
+	return nil
+}
+
+func (d *sceneDecoder) setParent(c child.Parented, i int) error {
+	if i < 0 || i >= len(d.children) {
+		return fmt.Errorf("scene file: no child %v to parent to", i)
+	}
+	p, ok := d.children[i].(child.Parented)
+	if !ok {
+		return fmt.Errorf("scene file: child %v can't be a parent", i)
+	}
+	return c.GetNode().SetParent(p.GetNode())
+}
+
+func (d *sceneDecoder) scene(data SceneData, scn *Scene) {
+	for _, cd := range data.Children {
+		c := d.child(cd)
+		if cd.Parent >= 0 {
+			d.childParents[c] = cd.Parent
+		}
+		d.children = append(d.children, c)
+		scn.InstanceChild(c)
+	}
+
+	for _, td := range data.Texts {
+		color := [3]float32{td.Color[0] * 255, td.Color[1] * 255, td.Color[2] * 255}
+		t := d.sc.engine.TextControl.NewTextBox(td.Text, td.Font, td.X, td.Y, td.Scale, color)
+		if td.Parent >= 0 {
+			d.textParents[t] = td.Parent
+		}
+		scn.InstanceText(t)
+	}
+
+	for _, sd := range data.Subscenes {
+		sub := d.sc.NewScene(sd.ID)
+		d.scene(sd, sub)
+		applySceneFlags(sd, sub)
+		scn.InstanceSubscene(sub)
+	}
+}
+
+// applySceneFlags sets whether a new scene is active and rendered
+// automatically. Scenes that files are loaded into keep their own.
+func applySceneFlags(data SceneData, scn *Scene) {
+	if !data.AutomaticRendering {
+		scn.DisableAutomaticRendering()
+	}
+	// Scenes start active
+	if !data.Active {
+		scn.Deactivate()
+	}
+}
+
+// copier is implemented by both 2D and 3D children
+type copier interface {
+	EnableCopying()
+	AddCopy(child.ChildCopy)
+}
+
+// child creates a child from its data, which must be of a known type
+func (d *sceneDecoder) child(cd ChildData) child.Child {
+	rotation := mgl32.Quat{W: cd.Rotation[3], V: mgl32.Vec3{cd.Rotation[0], cd.Rotation[1], cd.Rotation[2]}}
+
+	var c child.Child
+	switch cd.Type {
+	case "2d":
+		c2 := d.sc.engine.ChildControl.NewChild2D()
+		c2.Group, c2.Layer, c2.Gravity = cd.Group, cd.Layer, cd.Gravity
+		c2.SetPosition(cd.Position[0], cd.Position[1])
+		c2.SetVelocity(cd.Velocity[0], cd.Velocity[1])
+		c2.Transform.SetRotation(rotation)
+		c2.SetScale(cd.Scale[0], cd.Scale[1])
+		c2.Static, c2.Darkness = cd.Static, cd.Darkness
+		if m := d.getMaterial(cd.Material); m != nil {
+			c2.AttachMaterial(m)
+		}
+		if cd.Mesh != nil {
+			c2.AttachMesh(d.mesh(*cd.Mesh))
+		}
+		if cd.Collider != nil {
+			col := *cd.Collider
+			c2.AttachCollider(col.OffsetX, col.OffsetY, col.Width, col.Height)
+		}
+		if cd.Batching {
+			c2.EnableBatching()
+		}
+		if cd.Instancing {
+			c2.EnableGLInstancing(len(cd.Copies))
+		}
+		c = c2
+
+	case "3d":
+		c3 := d.sc.engine.ChildControl.NewChild3D()
+		c3.Group, c3.Layer, c3.Gravity = cd.Group, cd.Layer, cd.Gravity
+		c3.SetPosition(cd.Position[0], cd.Position[1], cd.Position[2])
+		c3.VX, c3.VY, c3.VZ = cd.Velocity[0], cd.Velocity[1], cd.Velocity[2]
+		c3.Transform.SetRotation(rotation)
+		c3.SetScale(cd.Scale[0], cd.Scale[1], cd.Scale[2])
+		if m := d.getMaterial(cd.Material); m != nil {
+			c3.AttachMaterial(m)
+		}
+		if cd.Model != nil {
+			c3.AttachModel(d.model(*cd.Model, c3.Material))
+		}
+		if !cd.Instancing {
+			c3.DisableGLInstancing()
+		}
+		c = c3
+
+	default:
+		return nil
+	}
+
+	c.SetSpecificRenderDistance(cd.RenderDistance)
+	cp := c.(copier)
+	if cd.Copying {
+		cp.EnableCopying()
+	}
+	for _, cpy := range cd.Copies {
+		r := cpy.Rotation
+		cp.AddCopy(child.ChildCopy{
+			X: cpy.Position[0], Y: cpy.Position[1], Z: cpy.Position[2],
+			ScaleX: cpy.Scale[0], ScaleY: cpy.Scale[1], ScaleZ: cpy.Scale[2],
+			Rotation:   mgl32.Quat{W: r[3], V: mgl32.Vec3{r[0], r[1], r[2]}},
+			Material:   d.getMaterial(cpy.Material),
+			Darkness:   cpy.Darkness,
+			AtlasIndex: cpy.AtlasIndex,
+			ID:         cpy.ID,
+		})
+	}
+
+	if cd.Active {
+		c.Activate()
+	} else {
+		c.Deactivate()
+	}
+	return c
+}
+
+func (d *sceneDecoder) mesh(md MeshData) geometry.Mesh {
+	var m geometry.Mesh
+	if primitive, ok := primitiveMeshes[md.ID]; ok {
+		m = primitive()
+	} else if len(md.Vertices) > 0 {
+		m = geometry.Mesh{
+			ID:          md.ID,
+			VAO:         geometry.NewVertexArray(md.Vertices, md.Indices),
+			TexCoords:   md.TexCoords,
+			Normals:     md.Normals,
+			NumVertices: int32(len(md.Indices)),
+		}
+		if len(m.TexCoords) > 0 {
+			m.VAO.AddVertexAttribute(m.TexCoords, 1, 3)
+			m.TexCoordsEnabled = true
+		}
+		if len(m.Normals) > 0 {
+			m.VAO.AddVertexAttribute(m.Normals, 2, 3)
+			m.NormalsEnabled = true
+		}
+	} else {
+		var err error
+		if m, err = d.sc.engine.GeometryControl.LoadObj(md.ID, md.Scale); err != nil {
+			d.assetError(md.ID, err)
+		}
+	}
+
+	m.ModelMaterial = md.ModelMaterial
+	return m
+}
+
+func (d *sceneDecoder) model(md ModelData, mat material.Material) geometry.Model {
+	var m geometry.Model
+	if md.Path != "" {
+		m = d.sc.engine.GeometryControl.LoadModel(md.Path, mat)
+	} else {
+		m = geometry.Model{Materials: make(map[int]material.Material)}
+		for _, ms := range md.Meshes {
+			m.Meshes = append(m.Meshes, d.mesh(ms))
+		}
+	}
+
+	for i, j := range md.Materials {
+		if mat := d.getMaterial(j); mat != nil {
+			m.Materials[i] = mat
+		}
+	}
+	return m
+}
+
+func (d *sceneDecoder) getMaterial(i int) material.Material {
+	if i < 0 || i >= len(d.materials) {
+		return nil
+	}
+	return d.materials[i]
+}
+
+// texture returns a loaded texture by name, or loads it
+func (d *sceneDecoder) texture(t TextureRef) *material.Texture {
+	tc := &d.sc.engine.TextureControl
+	if tx, err := tc.LookupTexture(t.Name); err == nil {
+		return tx
+	}
+	if t.Path == "" {
+		d.assetError(t.Name, fmt.Errorf("no texture %q is loaded, and the file has no path for it", t.Name))
+		return tc.GetFallback()
+	}
+	if err := tc.NewTexture(t.Path, t.Name, t.Filter); err != nil {
+		d.assetError(t.Path, err)
+	}
+	return tc.GetTexture(t.Name)
+}
+
+// assetError logs an asset that couldn't be loaded, and is replaced
+// by a placeholder in the scene
+func (d *sceneDecoder) assetError(path string, err error) {
+	d.sc.engine.Logger.WithField("asset", path).Warn("scene file: using a placeholder: ", err)
+}
+
+// material creates a material from its data, or returns the
+// loaded material of the same name
+func (d *sceneDecoder) material(md MaterialData) material.Material {
+	mc := &d.sc.engine.MaterialControl
+	if m, ok := mc.Materials[md.Name]; ok && md.Name != "" {
+		return m
+	}
+	if m, ok := d.named[md.Name]; ok && md.Name != "" {
+		return m
+	}
+
+	var m material.Material
+	switch md.Type {
+	case "default":
+		return d.sc.engine.Renderer.DefaultMaterial1
+	case "basic":
+		m = mc.NewBasicMaterial()
+	case "standard":
+		m = mc.NewStandardMaterial()
+	case "pbr":
+		// Not through NewPBRMaterial, which registers it straight away
+		m = material.NewPBRMaterial(d.sc.engine.ShaderControl.GetShader("pbr"))
+	case "cubemap":
+		m = mc.NewCubemapMaterial()
+	case "terrain":
+		m = mc.NewTerrainMaterial()
+	case "foliage":
+		m = mc.NewFoliageMaterial()
+	case "water":
+		m = mc.NewWaterMaterial()
+	default:
+		d.sc.engine.Logger.WithField("type", md.Type).Warn("scene file: unknown material type, using the default material")
+		return d.sc.engine.Renderer.DefaultMaterial1
+	}
+	if md.Name != "" {
+		d.named[md.Name] = m
+	}
+
+	v := reflect.ValueOf(m).Elem()
+	for name, raw := range md.Params {
+		f := v.FieldByName(name)
+		if !f.IsValid() || !f.CanSet() || !plainType(f.Type()) {
+			continue
+		}
+		if err := json.Unmarshal(raw, f.Addr().Interface()); err != nil {
+			d.sc.engine.Logger.WithField("param", name).Warn("scene file: ", err)
+		}
+	}
+	for name, i := range md.Textures {
+		f := v.FieldByName(name)
+		if !f.IsValid() || !f.CanSet() || f.Type() != textureType || i < 0 || i >= len(d.textures) {
+			continue
+		}
+		f.Set(reflect.ValueOf(d.textures[i]))
+	}
+
+	return m
+}
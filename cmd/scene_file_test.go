@@ -0,0 +1,173 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+
+	"rapidengine/child"
+	"rapidengine/material"
+)
+
+// newSavedScene builds a scene with a subscene, a parented
+// child with copies, a parented text and a named material
+func newSavedScene(t *testing.T) (*Engine, *Scene, *material.PBRMaterial) {
+	t.Helper()
+
+	e := newHeadlessEngine(t, 3, func() {})
+	scn := e.SceneControl.NewScene("level")
+	e.SceneControl.InstanceScene(scn)
+
+	mat := e.MaterialControl.NewPBRMaterial("metal")
+	mat.RoughnessScalar = 0.3
+
+	car := e.ChildControl.NewChild3D()
+	car.SetPosition(1, 2, 3)
+	car.SetRotation(0.1, 0.5, 0)
+	car.SetScale(2, 2, 2)
+	car.AttachMaterial(mat)
+	car.AddCopy(child.ChildCopy{X: 4, Rotation: mgl32.QuatRotate(0.5, mgl32.Vec3{0, 1, 0}), Material: mat})
+	scn.InstanceChild(car)
+
+	sub := e.SceneControl.NewScene("hud")
+	scn.InstanceSubscene(sub)
+	wheel := e.ChildControl.NewChild2D()
+	wheel.SetPosition(10, 20)
+	wheel.SetScale(30, 40)
+	wheel.SetRotation(math.Pi / 4)
+	wheel.AttachCollider(0, 0, 30, 40)
+	wheel.EnableCopying()
+	wheel.AddCopy(child.ChildCopy{X: 5, Y: 6, Darkness: 1})
+	wheel.SetParent(car)
+	sub.InstanceChild(wheel)
+
+	text := e.TextControl.NewTextBox("hi", "arial", 1, 2, 1, [3]float32{255, 0, 0})
+	text.Parent = wheel
+	sub.InstanceText(text)
+
+	e.SceneControl.SetCurrentScene(scn)
+	sub.Activate()
+	return e, scn, mat
+}
+
+func near(a, b float32) bool {
+	return math.Abs(float64(a-b)) < 1e-4
+}
+
+func TestSceneFileRoundTrip(t *testing.T) {
+	for _, format := range []SceneFormat{SceneJSON, SceneBinary} {
+		e, scn, mat := newSavedScene(t)
+
+		path := filepath.Join(t.TempDir(), "scene")
+		if err := e.SceneControl.SaveScene(scn, path, format); err != nil {
+			t.Fatal(err)
+		}
+		loaded, err := e.SceneControl.LoadScene(path)
+		if err != nil {
+			t.Fatalf("format %v: %v", format, err)
+		}
+
+		children := loaded.GetChildren()
+		if len(children) != 2 {
+			t.Fatalf("format %v: loaded %v children, want 2", format, len(children))
+		}
+		car, ok := children[0].(*child.Child3D)
+		if !ok {
+			t.Fatalf("format %v: first child is %T", format, children[0])
+		}
+		wheel, ok := children[1].(*child.Child2D)
+		if !ok {
+			t.Fatalf("format %v: second child is %T", format, children[1])
+		}
+
+		if car.GetX() != 1 || car.GetY() != 2 || car.GetZ() != 3 {
+			t.Errorf("format %v: car at %v, %v, %v", format, car.GetX(), car.GetY(), car.GetZ())
+		}
+		if x, y, z := car.Transform.Euler(); !near(x, 0.1) || !near(y, 0.5) || !near(z, 0) {
+			t.Errorf("format %v: car rotated %v, %v, %v", format, x, y, z)
+		}
+		if car.ScaleX() != 2 || car.ScaleY() != 2 || car.ScaleZ() != 2 {
+			t.Errorf("format %v: car scaled %v", format, car.Transform.Scale)
+		}
+		if car.Material != material.Material(mat) {
+			t.Errorf("format %v: car has a new material, not the named one", format)
+		}
+		want := mgl32.QuatRotate(0.5, mgl32.Vec3{0, 1, 0})
+		if copies := *car.GetCopies(); len(copies) != 1 || copies[0].X != 4 || copies[0].Rotation != want {
+			t.Errorf("format %v: car copies %v", format, copies)
+		}
+
+		if wheel.GetX() != 10 || wheel.GetY() != 20 || wheel.ScaleX() != 30 || wheel.ScaleY() != 40 {
+			t.Errorf("format %v: wheel at %v, %v sized %v, %v", format, wheel.GetX(), wheel.GetY(), wheel.ScaleX(), wheel.ScaleY())
+		}
+		if !near(wheel.GetRotation(), math.Pi/4) {
+			t.Errorf("format %v: wheel rotated %v", format, wheel.GetRotation())
+		}
+		if c := wheel.GetCollider(); c == nil || c.Width != 30 || c.Height != 40 {
+			t.Errorf("format %v: wheel collider %v", format, c)
+		}
+		if copies := *wheel.GetCopies(); len(copies) != 1 || copies[0].X != 5 || copies[0].Y != 6 {
+			t.Errorf("format %v: wheel copies %v", format, copies)
+		}
+		if wheel.GetParent() != child.Parented(car) {
+			t.Errorf("format %v: wheel isn't parented to the car", format)
+		}
+
+		texts := loaded.GetTexts()
+		if text := texts[len(texts)-1]; text.Text != "hi" || text.Parent != child.Parented(wheel) {
+			t.Errorf("format %v: text %q parented to %v", format, text.Text, text.Parent)
+		}
+	}
+}
+
+func TestSceneFileVersion(t *testing.T) {
+	e, scn, _ := newSavedScene(t)
+
+	f := e.SceneControl.EncodeScene(scn)
+	f.Version = SceneFileVersion + 1
+	for _, format := range []SceneFormat{SceneJSON, SceneBinary} {
+		var buf bytes.Buffer
+		if err := WriteSceneFile(&buf, &f, format); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ReadSceneFile(&buf); !errors.Is(err, ErrSceneVersion) {
+			t.Errorf("format %v: got %v, want ErrSceneVersion", format, err)
+		}
+	}
+}
+
+func TestSceneFileRejected(t *testing.T) {
+	for name, spoil := range map[string]func(f *SceneFile){
+		"parent out of range": func(f *SceneFile) { f.Scene.Children[0].Parent = 5 },
+		"parent cycle":        func(f *SceneFile) { f.Scene.Children[0].Parent = 1 },
+		"text parent":         func(f *SceneFile) { f.Scene.Subscenes[0].Texts[0].Parent = 9 },
+		"material":            func(f *SceneFile) { f.Scene.Children[0].Material = 9 },
+		"copy material":       func(f *SceneFile) { f.Scene.Subscenes[0].Children[0].Copies[0].Material = 9 },
+		"texture":             func(f *SceneFile) { f.Materials[len(f.Materials)-1].Textures = map[string]int{"DiffuseMap": 9} },
+		"child type":          func(f *SceneFile) { f.Scene.Children[0].Type = "4d" },
+	} {
+		e, scn, _ := newSavedScene(t)
+
+		f := e.SceneControl.EncodeScene(scn)
+		f.Materials = append(f.Materials, MaterialData{Type: "basic", Name: "added"})
+		f.Scene.Subscenes[0].Children[0].Material = len(f.Materials) - 1
+		spoil(&f)
+
+		target := e.SceneControl.NewScene("target")
+		before := len(target.children)
+		if err := e.SceneControl.DecodeScene(&f, target); err == nil {
+			t.Errorf("%v: accepted", name)
+			continue
+		}
+		if len(target.children) != before || len(target.subscenes) != 0 {
+			t.Errorf("%v: the scene was changed", name)
+		}
+		if _, ok := e.MaterialControl.Materials["added"]; ok {
+			t.Errorf("%v: the file's material was registered", name)
+		}
+	}
+}
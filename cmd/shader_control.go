@@ -4,22 +4,37 @@ import "rapidengine/material"
 
 type ShaderControl struct {
 	programs map[string]*material.ShaderProgram
+
+	// Substituted for shaders that are missing or fail to compile
+	Fallback *material.ShaderProgram
+
+	engine *Engine
 }
 
 func NewShaderControl() ShaderControl {
-	return ShaderControl{make(map[string]*material.ShaderProgram)}
+	return ShaderControl{
+		programs: make(map[string]*material.ShaderProgram),
+		Fallback: &material.FallbackProgram,
+	}
 }
 
 func (shaderControl *ShaderControl) BindShader(name string) {
-	shaderControl.programs[name].Bind()
+	shaderControl.GetShader(name).Bind()
 }
 
-func (shaderControl *ShaderControl) Initialize() {
+func (shaderControl *ShaderControl) Initialize(engine *Engine) {
+	shaderControl.engine = engine
+
+	if err := shaderControl.Fallback.CompileFrom(engine.Assets); err != nil {
+		panic(err)
+	}
+
 	shaderControl.programs = map[string]*material.ShaderProgram{
 		"basic":    &material.BasicProgram,
 		"standard": &material.StandardProgram,
 		"pbr":      &material.PBRProgram,
 		"skybox":   &material.SkyBoxProgram,
+		"sprite":   &material.SpriteProgram,
 		"terrain":  &material.TerrainProgram,
 		"foliage":  &material.FoliageProgram,
 		"water":    &material.WaterProgram,
@@ -34,11 +49,20 @@ func (shaderControl *ShaderControl) Initialize() {
 		"post_prebloom":       &material.PostPreBloomProgram,
 		"post_postbloom":      &material.PostPostBloomProgram,
 	}
-	for _, prog := range shaderControl.programs {
-		prog.Compile()
+	for name, prog := range shaderControl.programs {
+		if err := prog.CompileFrom(engine.Assets); err != nil {
+			shaderControl.engine.Logger.WithField("shader", name).Error(err)
+			shaderControl.programs[name] = shaderControl.Fallback
+		}
 	}
 }
 
+// GetShader returns the named shader, or the fallback
+// shader if it doesn't exist
 func (shaderControl *ShaderControl) GetShader(name string) *material.ShaderProgram {
-	return shaderControl.programs[name]
+	if prog, ok := shaderControl.programs[name]; ok {
+		return prog
+	}
+	shaderControl.engine.Logger.WithField("shader", name).Error("shader not found, using fallback")
+	return shaderControl.Fallback
 }
@@ -0,0 +1,245 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"rapidengine/input"
+)
+
+//  --------------------------------------------------
+//  SystemControl schedules the engine's subsystems.
+//  Each system runs in a stage, either once per fixed
+//  step or once per rendered frame, and is ordered by
+//  its dependencies first and its priority second.
+//  --------------------------------------------------
+
+// System is a subsystem updated by the engine. The built-in
+// collision, UI, terrain, lighting and text controls are systems,
+// and users can register their own (AI, networking, scripting...)
+type System interface {
+	Initialize(engine *Engine)
+	Update(renderer *Renderer, inputs *input.Input)
+	Shutdown()
+}
+
+// SystemStage selects when a system is updated
+type SystemStage int
+
+const (
+	// StageStep systems run once per fixed step, after the user function
+	StageStep SystemStage = iota
+
+	// StageFrame systems run once per frame, after children are rendered
+	StageFrame
+)
+
+// Names of the built-in systems
+const (
+	SystemCollision = "collision"
+	SystemPicking   = "picking"
+	SystemUI        = "ui"
+	SystemTerrain   = "terrain"
+	SystemLight     = "light"
+	SystemText      = "text"
+	SystemProfiler  = "profiler"
+)
+
+// SystemEntry holds a registered system and its scheduling options
+type SystemEntry struct {
+	Name   string
+	System System
+	Stage  SystemStage
+
+	// Lower priorities run first
+	Priority int
+
+	// Names of systems in the same stage that must run before this one
+	Dependencies []string
+
+	index int
+}
+
+type SystemControl struct {
+	entries map[string]*SystemEntry
+	order   map[SystemStage][]*SystemEntry
+
+	nextIndex int
+
+	engine *Engine
+}
+
+func NewSystemControl() SystemControl {
+	return SystemControl{
+		entries: make(map[string]*SystemEntry),
+		order:   make(map[SystemStage][]*SystemEntry),
+	}
+}
+
+func (sc *SystemControl) Initialize(engine *Engine) {
+	sc.engine = engine
+}
+
+// AddSystem registers and initializes a system. It fails if the name is
+// already taken or the dependencies can't be satisfied.
+func (sc *SystemControl) AddSystem(name string, s System, stage SystemStage, priority int, dependencies ...string) error {
+	if _, ok := sc.entries[name]; ok {
+		return fmt.Errorf("system %v already exists", name)
+	}
+
+	entry := &SystemEntry{
+		Name:         name,
+		System:       s,
+		Stage:        stage,
+		Priority:     priority,
+		Dependencies: dependencies,
+		index:        sc.nextIndex,
+	}
+
+	sc.entries[name] = entry
+	if err := sc.sortStage(stage); err != nil {
+		delete(sc.entries, name)
+		sc.sortStage(stage)
+		return err
+	}
+	sc.nextIndex++
+
+	s.Initialize(sc.engine)
+	return nil
+}
+
+// RemoveSystem shuts down and unregisters a system
+func (sc *SystemControl) RemoveSystem(name string) error {
+	entry, ok := sc.entries[name]
+	if !ok {
+		return fmt.Errorf("system %v does not exist", name)
+	}
+
+	delete(sc.entries, name)
+	if err := sc.sortStage(entry.Stage); err != nil {
+		sc.entries[name] = entry
+		sc.sortStage(entry.Stage)
+		return err
+	}
+
+	entry.System.Shutdown()
+	return nil
+}
+
+// SetPriority changes the priority of a system, reordering its stage
+func (sc *SystemControl) SetPriority(name string, priority int) error {
+	entry, ok := sc.entries[name]
+	if !ok {
+		return fmt.Errorf("system %v does not exist", name)
+	}
+
+	entry.Priority = priority
+	return sc.sortStage(entry.Stage)
+}
+
+// GetSystem returns the system registered under name, or nil
+func (sc *SystemControl) GetSystem(name string) System {
+	if entry, ok := sc.entries[name]; ok {
+		return entry.System
+	}
+	return nil
+}
+
+// GetOrder returns the names of the systems in a stage, in update order
+func (sc *SystemControl) GetOrder(stage SystemStage) []string {
+	names := []string{}
+	for _, entry := range sc.order[stage] {
+		names = append(names, entry.Name)
+	}
+	return names
+}
+
+// Update updates every system in the given stage. Systems added or
+// removed during the update take effect from the next one. Each
+// system is timed by the profiler under its name.
+func (sc *SystemControl) Update(stage SystemStage, renderer *Renderer, inputs *input.Input) {
+	for _, entry := range sc.order[stage] {
+		if _, ok := sc.entries[entry.Name]; ok {
+			sc.engine.Profiler.Begin(entry.Name)
+			entry.System.Update(renderer, inputs)
+			sc.engine.Profiler.End(entry.Name)
+		}
+	}
+}
+
+// Shutdown shuts down every system, in reverse update order
+func (sc *SystemControl) Shutdown() {
+	for _, stage := range []SystemStage{StageFrame, StageStep} {
+		order := sc.order[stage]
+		for i := len(order) - 1; i >= 0; i-- {
+			order[i].System.Shutdown()
+		}
+	}
+}
+
+// sortStage orders the systems of a stage so that every system runs after
+// its dependencies. Among systems that are ready to run, lower priorities
+// go first, then systems that were added earlier.
+func (sc *SystemControl) sortStage(stage SystemStage) error {
+	remaining := []*SystemEntry{}
+	for _, entry := range sc.entries {
+		if entry.Stage == stage {
+			remaining = append(remaining, entry)
+		}
+	}
+
+	sort.Slice(remaining, func(i, j int) bool {
+		if remaining[i].Priority != remaining[j].Priority {
+			return remaining[i].Priority < remaining[j].Priority
+		}
+		return remaining[i].index < remaining[j].index
+	})
+
+	for _, entry := range remaining {
+		for _, dep := range entry.Dependencies {
+			other, ok := sc.entries[dep]
+			if !ok {
+				return fmt.Errorf("system %v depends on missing system %v", entry.Name, dep)
+			}
+			if other.Stage != stage {
+				return fmt.Errorf("system %v depends on %v, which runs in another stage", entry.Name, dep)
+			}
+		}
+	}
+
+	done := make(map[string]bool)
+	order := []*SystemEntry{}
+
+	for len(remaining) > 0 {
+		next := -1
+		for i, entry := range remaining {
+			ready := true
+			for _, dep := range entry.Dependencies {
+				if !done[dep] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				next = i
+				break
+			}
+		}
+
+		if next == -1 {
+			names := []string{}
+			for _, entry := range remaining {
+				names = append(names, entry.Name)
+			}
+			return fmt.Errorf("dependency cycle between systems %v", strings.Join(names, ", "))
+		}
+
+		done[remaining[next].Name] = true
+		order = append(order, remaining[next])
+		remaining = append(remaining[:next], remaining[next+1:]...)
+	}
+
+	sc.order[stage] = order
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"rapidengine/configuration"
 	"rapidengine/geometry"
+	"rapidengine/input"
 	"rapidengine/material"
 	"rapidengine/terrain"
 
@@ -29,7 +30,7 @@ func (tc *TerrainControl) Initialize(engine *Engine) {
 	tc.engine = engine
 }
 
-func (tc *TerrainControl) Update() {
+func (tc *TerrainControl) Update(renderer *Renderer, inputs *input.Input) {
 	if tc.terrainEnabled {
 		tc.engine.Renderer.RenderChild(tc.root.TChild)
 
@@ -43,6 +44,16 @@ func (tc *TerrainControl) Update() {
 	}
 }
 
+func (tc *TerrainControl) Shutdown() {}
+
+// GetTerrain returns the current terrain, or nil if there isn't one
+func (tc *TerrainControl) GetTerrain() *terrain.Terrain {
+	if !tc.terrainEnabled {
+		return nil
+	}
+	return tc.root
+}
+
 func (tc *TerrainControl) InstanceFoliage(f *terrain.Foliage) {
 	tc.foliages = append(tc.foliages, f)
 }
@@ -81,7 +92,8 @@ func (tc *TerrainControl) NewPlanetaryTerrain(width int, height int, vertices in
 
 	t.TChild.AttachMaterial(tc.engine.MaterialControl.NewTerrainMaterial())
 	//t.TChild.AttachMesh(geometry.NewPlane(width, height, vertices, nil, 1))
-	t.TChild.AttachMesh(geometry.LoadObj("../rapidengine/assets/obj/sphere.obj", 10000))
+	sphere, _ := tc.engine.GeometryControl.LoadObj("engine/obj/sphere.obj", 10000)
+	t.TChild.AttachMesh(sphere)
 	t.TChild.SetInstanceRenderDistance(1000000000)
 
 	t.TChild.PreRender(tc.engine.Renderer.MainCamera)
@@ -144,12 +156,12 @@ func (terrainControl *TerrainControl) NewSkyBox(
 	shaderControl.GetShader("skybox").Bind()
 
 	textureControl.NewCubeMap(
-		fmt.Sprintf("../rapidengine/assets/skybox/%s/%s_LF.%s", path, path, ext),
-		fmt.Sprintf("../rapidengine/assets/skybox/%s/%s_RT.%s", path, path, ext),
-		fmt.Sprintf("../rapidengine/assets/skybox/%s/%s_UP.%s", path, path, ext),
-		fmt.Sprintf("../rapidengine/assets/skybox/%s/%s_DN.%s", path, path, ext),
-		fmt.Sprintf("../rapidengine/assets/skybox/%s/%s_FR.%s", path, path, ext),
-		fmt.Sprintf("../rapidengine/assets/skybox/%s/%s_BK.%s", path, path, ext),
+		fmt.Sprintf("engine/skybox/%s/%s_LF.%s", path, path, ext),
+		fmt.Sprintf("engine/skybox/%s/%s_RT.%s", path, path, ext),
+		fmt.Sprintf("engine/skybox/%s/%s_UP.%s", path, path, ext),
+		fmt.Sprintf("engine/skybox/%s/%s_DN.%s", path, path, ext),
+		fmt.Sprintf("engine/skybox/%s/%s_FR.%s", path, path, ext),
+		fmt.Sprintf("engine/skybox/%s/%s_BK.%s", path, path, ext),
 		"skybox",
 	)
 
@@ -167,11 +179,6 @@ func (terrainControl *TerrainControl) NewSkyBox(
 	return terrain.NewSkyBox(
 		cmaterial,
 		vao,
-		mgl32.Perspective(
-			mgl32.DegToRad(45),
-			float32(config.ScreenWidth)/float32(config.ScreenHeight),
-			0.1, 100,
-		),
 		mgl32.Ident4(),
 		[]*material.ShaderProgram{
 			terrainControl.engine.ShaderControl.GetShader("standard"),
@@ -2,9 +2,9 @@ package cmd
 
 import (
 	"fmt"
-	"os"
+	"rapidengine/assets"
 	"rapidengine/configuration"
-	"rapidengine/state"
+	"rapidengine/input"
 	"rapidengine/ui"
 
 	"github.com/4ydx/gltext"
@@ -29,19 +29,18 @@ func (tc *TextControl) Initialize(engine *Engine) {
 	tc.engine = engine
 }
 
-func (tc *TextControl) Update() {
+func (tc *TextControl) Update(renderer *Renderer, inputs *input.Input) {
 	for _, t := range tc.engine.SceneControl.GetCurrentTexts() {
 		t.Update(tc.engine.Config)
 	}
-	state.BoundTexture0 = 999
+
+	// Text is drawn without the backend, so the GL state is unknown
+	tc.engine.Renderer.State.Invalidate()
 }
 
-func (tc *TextControl) NewTextBox(text string, font string, x, y, scale float32, color [3]float32) *ui.TextBox {
-	t := v41.NewText(tc.Fonts[font], 0.2, 10)
-	t.SetString(text)
-	t.SetColor(mgl32.Vec3{1, 1, 1})
-	t.AddScale(scale)
+func (tc *TextControl) Shutdown() {}
 
+func (tc *TextControl) NewTextBox(text string, font string, x, y, scale float32, color [3]float32) *ui.TextBox {
 	textbox := &ui.TextBox{
 		Text:  text,
 		Font:  font,
@@ -50,24 +49,40 @@ func (tc *TextControl) NewTextBox(text string, font string, x, y, scale float32,
 		Y:     y,
 		Scale: scale,
 	}
-	textbox.SetV41Text(t)
+
+	// The font is missing when running headlessly, or if it failed to load
+	if f, ok := tc.Fonts[font]; ok {
+		t := v41.NewText(f, 0.2, 10)
+		t.SetString("%s", text)
+		t.SetColor(mgl32.Vec3{1, 1, 1})
+		t.AddScale(scale)
+		textbox.SetV41Text(t)
+	}
 
 	return textbox
 }
 
-func (tc *TextControl) LoadFont(path string, name string, scale float32, offset int) {
+// LoadFont loads a truetype font, caching the generated font config
+// in fontconfigs. It returns an assets.NotFoundError if the font is
+// missing, or an assets.DecodeError if it can't be parsed. Text boxes
+// using a font that failed to load are not drawn.
+func (tc *TextControl) LoadFont(path string, name string, scale float32, offset int) error {
+	if tc.engine.Config.Headless {
+		return nil
+	}
+
 	var font *v41.Font
 	config, err := gltext.LoadTruetypeFontConfig("fontconfigs", name)
 	if err == nil {
 		font, err = v41.NewFont(config)
 		if err != nil {
-			panic(err)
+			return tc.fontError(name, path, &assets.DecodeError{Path: path, Format: "font", Err: err})
 		}
 		fmt.Println("Font loaded from disk...")
 	} else {
-		fd, err := os.Open(path)
+		fd, err := tc.engine.Assets.OpenAsset(path)
 		if err != nil {
-			panic(err)
+			return tc.fontError(name, path, err)
 		}
 		defer fd.Close()
 
@@ -85,20 +100,28 @@ func (tc *TextControl) LoadFont(path string, name string, scale float32, offset
 		runesPerRow := fixed.Int26_6(128)
 		config, err = gltext.NewTruetypeFontConfig(fd, scale, runeRanges, runesPerRow, fixed.Int26_6(offset))
 		if err != nil {
-			panic(err)
+			return tc.fontError(name, path, &assets.DecodeError{Path: path, Format: "font", Err: err})
 		}
 		err = config.Save("fontconfigs", name)
 		if err != nil {
-			panic(err)
+			tc.engine.Logger.WithField("font", name).Warn("couldn't cache font config: ", err)
 		}
 		font, err = v41.NewFont(config)
 		if err != nil {
-			panic(err)
+			return tc.fontError(name, path, &assets.DecodeError{Path: path, Format: "font", Err: err})
 		}
 	}
 
 	font.ResizeWindow(float32(tc.engine.Config.ScreenWidth), float32(tc.engine.Config.ScreenHeight))
 
 	tc.Fonts[name] = font
+	tc.engine.Events.Publish(AssetLoaded{Kind: AssetFont, Name: name, Path: path})
+
+	return nil
+}
 
+func (tc *TextControl) fontError(name, path string, err error) error {
+	tc.engine.Logger.WithField("font", name).Error(err)
+	tc.engine.Events.Publish(AssetLoaded{Kind: AssetFont, Name: name, Path: path, Err: err})
+	return err
 }
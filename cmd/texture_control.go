@@ -2,17 +2,31 @@ package cmd
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
 	"io/ioutil"
+	"rapidengine/assets"
+	"rapidengine/backend"
 	"rapidengine/configuration"
 	"rapidengine/material"
+	"sort"
 
 	"github.com/go-gl/gl/v4.1-core/gl"
 )
 
+var errNoTexture = errors.New("no texture with that name")
+
 type TextureControl struct {
 	TexMap map[string]*material.Texture `json:"textures"`
 
+	// Substituted for textures that are missing or fail to load.
+	// A checkerboard is generated the first time it's needed.
+	Fallback *material.Texture `json:"-"`
+
 	config *configuration.EngineConfig
+	engine *Engine
 }
 
 func NewTextureControl(config *configuration.EngineConfig) TextureControl {
@@ -22,50 +36,95 @@ func NewTextureControl(config *configuration.EngineConfig) TextureControl {
 	}
 }
 
+func (textureControl *TextureControl) Initialize(engine *Engine) {
+	textureControl.engine = engine
+}
+
+// GetTexture returns the named texture, or the fallback
+// texture if it doesn't exist
 func (textureControl *TextureControl) GetTexture(name string) *material.Texture {
+	tx, err := textureControl.LookupTexture(name)
+	if err != nil {
+		textureControl.config.Logger.Error(err)
+		return textureControl.GetFallback()
+	}
+	return tx
+}
+
+// LookupTexture returns the named texture, or an assets.NotFoundError
+func (textureControl *TextureControl) LookupTexture(name string) (*material.Texture, error) {
 	if tx, ok := textureControl.TexMap[name]; ok {
-		return tx
-	} else {
-		panic("couldn't find texture: " + name)
+		return tx, nil
 	}
+	return nil, &assets.NotFoundError{Path: name, Err: errNoTexture}
 }
 
+// GetFallback returns the fallback texture, generating
+// it if none has been set
+func (textureControl *TextureControl) GetFallback() *material.Texture {
+	if textureControl.Fallback == nil {
+		texture := textureControl.upload(checkerboard(64, 8), "pixel")
+		textureControl.Fallback = &material.Texture{
+			Name:   "fallback",
+			Filter: "pixel",
+			Addr:   &texture,
+		}
+	}
+	return textureControl.Fallback
+}
+
+// NewTexture loads an image and registers it under name. If the image
+// can't be loaded, the error is logged and returned, and the fallback
+// texture is registered in its place.
 func (textureControl *TextureControl) NewTexture(path string, name string, filter string) error {
-	rgba, err := material.LoadImage(path)
+	rgba, err := material.LoadImageFrom(textureControl.engine.Assets, path)
 	if err != nil {
-		panic(err)
+		textureControl.config.Logger.WithField("texture", name).Error(err)
+		textureControl.TexMap[name] = textureControl.GetFallback()
+		textureControl.engine.Events.Publish(AssetLoaded{Kind: AssetTexture, Name: name, Path: path, Err: err})
+		return err
 	}
 
-	var texture uint32
+	texture := textureControl.upload(rgba, filter)
+	textureControl.TexMap[name] = &material.Texture{
+		Name:   name,
+		Path:   path,
+		Filter: filter,
+		Addr:   &texture,
+	}
+	textureControl.engine.Events.Publish(AssetLoaded{Kind: AssetTexture, Name: name, Path: path})
+	return nil
+}
 
-	gl.GenTextures(1, &texture)
-	gl.BindTexture(gl.TEXTURE_2D, texture)
+func (textureControl *TextureControl) upload(rgba *image.RGBA, filter string) uint32 {
+	texture := backend.Current.GenTexture()
+	backend.Current.BindTexture(gl.TEXTURE_2D, texture)
 
 	switch filter {
 
 	case "pixel":
-		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.NEAREST)
-		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.NEAREST)
-		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.REPEAT)
-		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.REPEAT)
+		backend.Current.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.NEAREST)
+		backend.Current.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.NEAREST)
+		backend.Current.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.REPEAT)
+		backend.Current.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.REPEAT)
 
 	case "mipmap":
-		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR_MIPMAP_LINEAR)
-		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR_MIPMAP_LINEAR)
-		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.REPEAT)
-		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.REPEAT)
+		backend.Current.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR_MIPMAP_LINEAR)
+		backend.Current.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR_MIPMAP_LINEAR)
+		backend.Current.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.REPEAT)
+		backend.Current.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.REPEAT)
 
 	case "linear":
-		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.NEAREST)
-		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
-		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.REPEAT)
-		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.REPEAT)
+		backend.Current.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.NEAREST)
+		backend.Current.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
+		backend.Current.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.REPEAT)
+		backend.Current.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.REPEAT)
 
 	case "anisotropic":
-		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR_MIPMAP_LINEAR)
-		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR_MIPMAP_LINEAR)
-		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.REPEAT)
-		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.REPEAT)
+		backend.Current.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR_MIPMAP_LINEAR)
+		backend.Current.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR_MIPMAP_LINEAR)
+		backend.Current.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.REPEAT)
+		backend.Current.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.REPEAT)
 
 	}
 
@@ -74,52 +133,52 @@ func (textureControl *TextureControl) NewTexture(path string, name string, filte
 		texFormat = gl.SRGB_ALPHA
 	}
 
-	gl.TexImage2D(
+	backend.Current.TexImage2D(
 		gl.TEXTURE_2D,
 		0,
 		int32(texFormat),
 		int32(rgba.Rect.Size().X),
 		int32(rgba.Rect.Size().Y),
-		0,
 		gl.RGBA,
 		gl.UNSIGNED_BYTE,
-		gl.Ptr(rgba.Pix),
+		rgba.Pix,
 	)
 
-	gl.GenerateMipmap(gl.TEXTURE_2D)
-	gl.BindTexture(gl.TEXTURE_2D, 0)
-	textureControl.TexMap[name] = &material.Texture{
-		Name:   name,
-		Path:   path,
-		Filter: filter,
-		Addr:   &texture,
-	}
-	return nil
+	backend.Current.GenerateMipmap(gl.TEXTURE_2D)
+	backend.Current.BindTexture(gl.TEXTURE_2D, 0)
+
+	return texture
 }
 
-func (textureControl *TextureControl) NewCubeMap(right, left, top, bottom, front, back, name string) {
-	var cubeMap uint32
+// NewCubeMap loads six images into a cube map. Faces that can't be loaded
+// are logged and replaced with a checkerboard, and the first error is returned.
+func (textureControl *TextureControl) NewCubeMap(right, left, top, bottom, front, back, name string) error {
+	var firstErr error
 
-	gl.GenTextures(1, &cubeMap)
-	gl.BindTexture(gl.TEXTURE_CUBE_MAP, cubeMap)
+	cubeMap := backend.Current.GenTexture()
+	backend.Current.BindTexture(gl.TEXTURE_CUBE_MAP, cubeMap)
 
-	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
-	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
-	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
-	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
-	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_WRAP_R, gl.CLAMP_TO_EDGE)
+	backend.Current.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
+	backend.Current.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
+	backend.Current.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+	backend.Current.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+	backend.Current.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_WRAP_R, gl.CLAMP_TO_EDGE)
 
 	paths := []string{right, left, top, bottom, front, back}
 
 	for i, path := range paths {
-		rgba, err := material.LoadImage(path)
+		rgba, err := material.LoadImageFrom(textureControl.engine.Assets, path)
 		if err != nil {
-			panic(err)
+			textureControl.config.Logger.WithField("texture", name).Error(err)
+			if firstErr == nil {
+				firstErr = err
+			}
+			rgba = checkerboard(64, 8)
 		}
 
-		gl.TexImage2D(uint32(gl.TEXTURE_CUBE_MAP_POSITIVE_X+i), 0, gl.RGBA,
+		backend.Current.TexImage2D(uint32(gl.TEXTURE_CUBE_MAP_POSITIVE_X+i), 0, gl.RGBA,
 			int32(rgba.Rect.Size().X), int32(rgba.Rect.Size().Y),
-			0, gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(rgba.Pix))
+			gl.RGBA, gl.UNSIGNED_BYTE, rgba.Pix)
 	}
 
 	textureControl.TexMap[name] = &material.Texture{
@@ -127,41 +186,71 @@ func (textureControl *TextureControl) NewCubeMap(right, left, top, bottom, front
 		Path: right,
 		Addr: &cubeMap,
 	}
+	textureControl.engine.Events.Publish(AssetLoaded{Kind: AssetCubeMap, Name: name, Path: right, Err: firstErr})
+	return firstErr
+}
+
+// checkerboard generates a magenta and black checkerboard image
+func checkerboard(size, squares int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	square := size / squares
+	for x := 0; x < size; x++ {
+		for y := 0; y < size; y++ {
+			if (x/square+y/square)%2 == 0 {
+				img.Set(x, y, color.RGBA{R: 255, B: 255, A: 255})
+			} else {
+				img.Set(x, y, color.RGBA{A: 255})
+			}
+		}
+	}
+	return img
 }
 
 //   --------------------------------------------------
 //   Disk
 //   --------------------------------------------------
 
-func (textureControl *TextureControl) Save(path string) {
+// Save writes the name, path and filter of every texture to a JSON file
+func (textureControl *TextureControl) Save(path string) error {
 	blob, err := json.Marshal(&textureControl.TexMap)
 	if err != nil {
-		panic(err)
-	}
-
-	err = ioutil.WriteFile(path, blob, 0644)
-	if err != nil {
-		panic(err)
+		return err
 	}
+	return ioutil.WriteFile(path, blob, 0644)
 }
 
-func (textureControl *TextureControl) Load(path string) {
-	blob, err := ioutil.ReadFile(path)
+// Load loads every texture listed in a file written by Save. It returns
+// an assets.NotFoundError or assets.DecodeError if the file can't be
+// read, or else the first texture that failed to load, after loading
+// the rest.
+func (textureControl *TextureControl) Load(path string) error {
+	blob, err := textureControl.engine.Assets.ReadAsset(path)
 	if err != nil {
-		panic(err)
+		return err
 	}
 
-	temp := TextureControl{
-		TexMap: make(map[string]*material.Texture),
+	textures := make(map[string]*material.Texture)
+	if err := json.Unmarshal(blob, &textures); err != nil {
+		return &assets.DecodeError{Path: path, Format: "json", Err: err}
 	}
 
-	err = json.Unmarshal(blob, &temp.TexMap)
-	if err != nil {
-		panic(err)
+	names := make([]string, 0, len(textures))
+	for name := range textures {
+		names = append(names, name)
 	}
-
-	println("Loading textures from file...")
-	for n, t := range temp.TexMap {
-		textureControl.NewTexture(t.Path, n, t.Filter)
+	sort.Strings(names)
+
+	var firstErr error
+	for _, name := range names {
+		t := textures[name]
+		if t == nil {
+			err = &assets.DecodeError{Path: path, Format: "json", Err: fmt.Errorf("texture %q is null", name)}
+		} else {
+			err = textureControl.NewTexture(t.Path, name, t.Filter)
+		}
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
 	}
+	return firstErr
 }
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"errors"
+	"image"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"rapidengine/assets"
+)
+
+func TestTextureSaveLoad(t *testing.T) {
+	dir := t.TempDir()
+	imgPath := filepath.Join(dir, "red.png")
+	f, err := os.Create(imgPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := png.Encode(f, image.NewRGBA(image.Rect(0, 0, 4, 4))); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	e := newHeadlessEngine(t, 2, func() {})
+	if err := e.TextureControl.NewTexture(imgPath, "red", "linear"); err != nil {
+		t.Fatal(err)
+	}
+	saved := filepath.Join(dir, "textures.json")
+	if err := e.TextureControl.Save(saved); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded := newHeadlessEngine(t, 2, func() {})
+	if err := loaded.TextureControl.Load(saved); err != nil {
+		t.Fatal(err)
+	}
+	if tex, ok := loaded.TextureControl.TexMap["red"]; !ok || tex.Path != imgPath {
+		t.Errorf("loaded %v", tex)
+	}
+}
+
+func TestTextureLoadErrors(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, data string) string {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	e := newHeadlessEngine(t, 2, func() {})
+	var notFound *assets.NotFoundError
+	var decode *assets.DecodeError
+
+	if err := e.TextureControl.Load(filepath.Join(dir, "none.json")); !errors.As(err, &notFound) {
+		t.Errorf("missing file: got %v, want a NotFoundError", err)
+	}
+	if err := e.TextureControl.Load(write("bad.json", "{")); !errors.As(err, &decode) {
+		t.Errorf("bad json: got %v, want a DecodeError", err)
+	}
+
+	// A missing image is reported, and the fallback is used for it
+	path := write("missing.json", `{"gone": {"path": "`+filepath.Join(dir, "gone.png")+`"}}`)
+	if err := e.TextureControl.Load(path); !errors.As(err, &notFound) {
+		t.Errorf("missing image: got %v, want a NotFoundError", err)
+	}
+	if e.TextureControl.TexMap["gone"] != e.TextureControl.GetFallback() {
+		t.Error("missing image isn't the fallback")
+	}
+}
@@ -20,12 +20,14 @@ func (uiControl *UIControl) Initialize(engine *Engine) {
 	uiControl.engine = engine
 }
 
-func (uiControl *UIControl) Update(inputs *input.Input) {
+func (uiControl *UIControl) Update(renderer *Renderer, inputs *input.Input) {
 	for _, element := range uiControl.Elements {
 		element.Update(inputs)
 	}
 }
 
+func (uiControl *UIControl) Shutdown() {}
+
 func (uiControl *UIControl) InstanceElement(e ui.Element, scene *Scene) {
 	for _, c := range e.GetChildren() {
 		scene.InstanceChild(c)
@@ -43,7 +45,8 @@ func (uiControl *UIControl) addElement(e ui.Element) {
 }
 
 func (uiControl *UIControl) AlignCenter(e ui.Element) {
-	e.SetPosition(float32(uiControl.engine.Config.ScreenWidth/2)-e.GetTransform().SX/2, e.GetTransform().Y)
+	t := e.GetTransform()
+	e.SetPosition(float32(uiControl.engine.Config.ScreenWidth/2)-t.Scale.X()/2, t.Position.Y())
 }
 
 //  --------------------------------------------------
@@ -52,6 +55,7 @@ func (uiControl *UIControl) AlignCenter(e ui.Element) {
 
 func (uiControl *UIControl) NewUIButton(x, y, width, height float32) *ui.Button {
 	button := ui.NewUIButton(x, y, width, height)
+	button.Events = uiControl.engine.Events
 
 	button.ButtonChild = uiControl.engine.ChildControl.NewChild2D()
 	button.ButtonChild.AttachMaterial(uiControl.engine.Renderer.DefaultMaterial2)
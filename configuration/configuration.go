@@ -3,30 +3,51 @@ package configuration
 import "github.com/sirupsen/logrus"
 
 type EngineConfig struct {
-	ScreenWidth  int
-	ScreenHeight int
-	FullScreen   bool
+	ScreenWidth  int  `json:"screenWidth"`
+	ScreenHeight int  `json:"screenHeight"`
+	FullScreen   bool `json:"fullScreen"`
 
-	VSync           bool
-	GammaCorrection bool
-	AntiAliasing    bool
+	VSync           bool `json:"vsync"`
+	GammaCorrection bool `json:"gammaCorrection"`
+	AntiAliasing    bool `json:"antiAliasing"`
 
-	Blending bool
+	Blending bool `json:"blending"`
 
-	WindowTitle    string
-	PolygonLines   bool
-	CollisionLines bool
+	WindowTitle    string `json:"windowTitle"`
+	PolygonLines   bool   `json:"polygonLines"`
+	CollisionLines bool   `json:"collisionLines"`
 
-	ShowFPS bool
+	ShowFPS bool `json:"showFPS"`
 
-	MaxFPS int
+	MaxFPS int `json:"maxFPS"`
 
-	Dimensions int
+	// Fixed simulation rate, in steps per second
+	StepRate int `json:"stepRate"`
 
-	Profiling      bool
-	SingleMaterial bool
+	Dimensions int `json:"dimensions"`
 
-	Logger *logrus.Logger
+	Profiling      bool `json:"profiling"`
+	SingleMaterial bool `json:"singleMaterial"`
+
+	// Frame profiler, optionally with GPU timer queries and an overlay
+	FrameProfiler bool `json:"frameProfiler"`
+	GPUProfiler   bool `json:"gpuProfiler"`
+	ShowProfiler  bool `json:"showProfiler"`
+
+	// Address of the local debug HTTP server, e.g. "localhost:6060".
+	// The server is only started if this is set.
+	DebugAddress string `json:"debugAddress"`
+
+	// Input recording to write, or to replay instead of polling input
+	RecordInput string `json:"recordInput"`
+	ReplayInput string `json:"replayInput"`
+
+	Headless bool `json:"headless"`
+
+	// Directories searched for assets before the defaults, in order
+	AssetPaths []string `json:"assetPaths"`
+
+	Logger *logrus.Logger `json:"-"`
 }
 
 func NewEngineConfig(
@@ -53,8 +74,11 @@ func NewEngineConfig(
 		CollisionLines: false,
 		ShowFPS:        false,
 		MaxFPS:         70,
+		StepRate:       60,
 		Profiling:      false,
+		FrameProfiler:  false,
 		SingleMaterial: false,
+		Headless:       false,
 		Logger:         logrus.New(),
 	}
 }
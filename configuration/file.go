@@ -0,0 +1,427 @@
+package configuration
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+//  --------------------------------------------------
+//  File.go loads and saves EngineConfig as JSON, TOML
+//  or YAML, picked by file extension. Keys are the json
+//  names of the config fields. Only flat files are
+//  supported: values are strings, numbers, bools, or
+//  lists of strings, read by the kind of the field they
+//  set, like environment variables and flags.
+//  --------------------------------------------------
+
+// EnvPrefix prefixes the environment variables that override config
+// fields, e.g. RAPID_FULLSCREEN or RAPID_VSYNC
+const EnvPrefix = "RAPID_"
+
+// LoadEngineConfig builds a config from defaults, then the file at path
+// if it exists, then environment variables, then command line args.
+// The result is validated before it's returned.
+func LoadEngineConfig(path string, defaults EngineConfig, args []string) (EngineConfig, error) {
+	config := defaults
+
+	if path != "" {
+		if err := ReadEngineConfig(path, &config); err != nil && !os.IsNotExist(err) {
+			return defaults, err
+		}
+	}
+
+	if err := config.ApplyEnv(); err != nil {
+		return defaults, err
+	}
+
+	fs := flag.NewFlagSet("rapidengine", flag.ContinueOnError)
+	config.RegisterFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return defaults, err
+	}
+
+	if err := config.Validate(); err != nil {
+		return defaults, err
+	}
+
+	return config, nil
+}
+
+// ReadEngineConfig reads the file at path over the values already in config.
+// Unknown keys are an error, so typos in a config file don't go unnoticed.
+func ReadEngineConfig(path string, config *EngineConfig) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	switch format(path) {
+	case "toml":
+		err = config.readFlat(data, parseTOMLLine)
+	case "yaml":
+		err = config.readFlat(data, parseYAMLLine)
+	default:
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(config)
+	}
+	if err != nil {
+		return fmt.Errorf("%v: %v", path, err)
+	}
+	return nil
+}
+
+// Save writes the config to path, in the format given by its extension
+func (config *EngineConfig) Save(path string) error {
+	var data []byte
+	var err error
+
+	switch format(path) {
+	case "toml", "yaml":
+		data = config.encodeFlat(format(path))
+	default:
+		data, err = json.MarshalIndent(config, "", "    ")
+	}
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, data, 0644)
+}
+
+// Validate checks that the config can be used to start the engine
+func (config *EngineConfig) Validate() error {
+	problems := []string{}
+
+	if config.ScreenWidth <= 0 || config.ScreenHeight <= 0 {
+		problems = append(problems, fmt.Sprintf("invalid resolution %vx%v", config.ScreenWidth, config.ScreenHeight))
+	}
+	if config.Dimensions != 2 && config.Dimensions != 3 {
+		problems = append(problems, fmt.Sprintf("dimensions must be 2 or 3, not %v", config.Dimensions))
+	}
+	if config.MaxFPS <= 0 {
+		problems = append(problems, fmt.Sprintf("maxFPS must be positive, not %v", config.MaxFPS))
+	}
+	if config.StepRate <= 0 {
+		problems = append(problems, fmt.Sprintf("stepRate must be positive, not %v", config.StepRate))
+	}
+
+	if len(problems) > 0 {
+		return errors.New("invalid engine config: " + strings.Join(problems, ", "))
+	}
+	return nil
+}
+
+//  --------------------------------------------------
+//  Overrides
+//  --------------------------------------------------
+
+// ApplyEnv overrides fields from environment variables named
+// EnvPrefix followed by the upper case json name of the field
+func (config *EngineConfig) ApplyEnv() error {
+	for _, f := range config.fields() {
+		value, ok := os.LookupEnv(EnvPrefix + strings.ToUpper(f.name))
+		if !ok {
+			continue
+		}
+		if err := f.set(value); err != nil {
+			return fmt.Errorf("%v%v: %v", EnvPrefix, strings.ToUpper(f.name), err)
+		}
+	}
+	return nil
+}
+
+// RegisterFlags adds a flag for every field to fs, named by the lower
+// case json name of the field, e.g. -fullscreen or -maxfps
+func (config *EngineConfig) RegisterFlags(fs *flag.FlagSet) {
+	for _, f := range config.fields() {
+		fs.Var(f, strings.ToLower(f.name), "overrides "+f.name)
+	}
+}
+
+// configField is a single overridable field. It implements flag.Value.
+type configField struct {
+	name  string
+	value reflect.Value
+}
+
+func (config *EngineConfig) fields() []*configField {
+	fields := []*configField{}
+
+	v := reflect.ValueOf(config).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		name := v.Type().Field(i).Tag.Get("json")
+		if name == "" || name == "-" {
+			continue
+		}
+		fields = append(fields, &configField{name: name, value: v.Field(i)})
+	}
+
+	return fields
+}
+
+func (f *configField) String() string {
+	if !f.value.IsValid() {
+		return ""
+	}
+	if f.value.Kind() == reflect.Slice {
+		return strings.Join(f.value.Interface().([]string), ",")
+	}
+	return fmt.Sprint(f.value.Interface())
+}
+
+func (f *configField) Set(s string) error {
+	return f.set(s)
+}
+
+// IsBoolFlag lets bool fields be passed as just -name
+func (f *configField) IsBoolFlag() bool {
+	return f.value.Kind() == reflect.Bool
+}
+
+func (f *configField) set(s string) error {
+	switch f.value.Kind() {
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		f.value.SetBool(b)
+	case reflect.Int:
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return err
+		}
+		f.value.SetInt(int64(n))
+	case reflect.String:
+		f.value.SetString(s)
+	case reflect.Slice:
+		f.value.Set(reflect.ValueOf(strings.Split(s, ",")))
+	}
+	return nil
+}
+
+//  --------------------------------------------------
+//  Flat TOML / YAML
+//  --------------------------------------------------
+
+func format(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".toml":
+		return "toml"
+	case ".yaml", ".yml":
+		return "yaml"
+	}
+	return "json"
+}
+
+// lineParser splits a line into a key and a raw value. An empty
+// value is an empty string, or an empty list that YAML list
+// items may follow.
+type lineParser func(line string) (string, string, error)
+
+func parseTOMLLine(line string) (string, string, error) {
+	if strings.HasPrefix(line, "[") && !strings.Contains(line, "=") {
+		return "", "", fmt.Errorf("tables are not supported: %v", line)
+	}
+	parts := strings.SplitN(line, "=", 2)
+	if len(parts) != 2 {
+		return "", "", fmt.Errorf("expected key = value: %v", line)
+	}
+	return strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]), nil
+}
+
+func parseYAMLLine(line string) (string, string, error) {
+	parts := strings.SplitN(line, ":", 2)
+	if len(parts) != 2 {
+		return "", "", fmt.Errorf("expected key: value: %v", line)
+	}
+	return strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]), nil
+}
+
+// readFlat reads a flat TOML or YAML file over the values in config.
+// Each value is read by the kind of the field it sets, so nothing
+// in config changes unless the whole file can be read.
+func (config *EngineConfig) readFlat(data []byte, parse lineParser) error {
+	read := *config
+	fields := make(map[string]*configField)
+	for _, f := range read.fields() {
+		fields[f.name] = f
+	}
+
+	var list *configField
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(stripComment(line))
+		if line == "" || line == "---" {
+			continue
+		}
+
+		// YAML list item
+		if strings.HasPrefix(line, "- ") {
+			if list == nil {
+				return fmt.Errorf("line %v: list item without a list key", i+1)
+			}
+			item := reflect.ValueOf(unquote(strings.TrimSpace(line[2:])))
+			list.value.Set(reflect.Append(list.value, item))
+			continue
+		}
+
+		key, raw, err := parse(line)
+		if err != nil {
+			return fmt.Errorf("line %v: %v", i+1, err)
+		}
+		key = unquote(key)
+
+		f, ok := fields[key]
+		if !ok {
+			return fmt.Errorf("line %v: unknown key %q", i+1, key)
+		}
+
+		list = nil
+		if f.value.Kind() == reflect.Slice {
+			switch {
+			case raw == "":
+				list = f
+				f.value.Set(reflect.ValueOf([]string{}))
+			case strings.HasPrefix(raw, "["):
+				f.value.Set(reflect.ValueOf(parseList(raw)))
+			default:
+				return fmt.Errorf("line %v: %v: expected a list, not %v", i+1, key, raw)
+			}
+			continue
+		}
+		if err := f.set(unquote(raw)); err != nil {
+			return fmt.Errorf("line %v: %v: %v", i+1, key, err)
+		}
+	}
+
+	*config = read
+	return nil
+}
+
+// stripComment cuts a # comment off the end of a line. Inside
+// double quotes a backslash escapes the next character. Inside
+// single quotes a doubled quote is an escaped quote, which
+// toggling on each quote already handles.
+func stripComment(line string) string {
+	var inQuote rune
+	escaped := false
+	for i, c := range line {
+		switch {
+		case escaped:
+			escaped = false
+		case inQuote != 0:
+			if c == '\\' && inQuote == '"' {
+				escaped = true
+			} else if c == inQuote {
+				inQuote = 0
+			}
+		case c == '"' || c == '\'':
+			inQuote = c
+		case c == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// parseList parses an inline list of strings, splitting it
+// on the commas that aren't inside quotes
+func parseList(raw string) []string {
+	out := []string{}
+	inner := strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(raw, "["), "]"))
+	if inner == "" {
+		return out
+	}
+
+	var inQuote rune
+	escaped := false
+	start := 0
+	for i, c := range inner {
+		switch {
+		case escaped:
+			escaped = false
+		case inQuote != 0:
+			if c == '\\' && inQuote == '"' {
+				escaped = true
+			} else if c == inQuote {
+				inQuote = 0
+			}
+		case c == '"' || c == '\'':
+			inQuote = c
+		case c == ',':
+			out = append(out, unquote(strings.TrimSpace(inner[start:i])))
+			start = i + 1
+		}
+	}
+	return append(out, unquote(strings.TrimSpace(inner[start:])))
+}
+
+// unquote strips quotes from a value. Double quoted values take
+// Go escapes; single quoted values are literal, except that a
+// doubled single quote stands for one.
+func unquote(s string) string {
+	if len(s) < 2 || s[len(s)-1] != s[0] {
+		return s
+	}
+	switch s[0] {
+	case '"':
+		if u, err := strconv.Unquote(s); err == nil {
+			return u
+		}
+		return s[1 : len(s)-1]
+	case '\'':
+		return strings.Replace(s[1:len(s)-1], "''", "'", -1)
+	}
+	return s
+}
+
+func quote(s string) string {
+	return strconv.Quote(s)
+}
+
+// encodeFlat writes every field on its own line, in the given style
+func (config *EngineConfig) encodeFlat(style string) []byte {
+	var buf bytes.Buffer
+
+	for _, f := range config.fields() {
+		var value string
+		switch f.value.Kind() {
+		case reflect.String:
+			value = quote(f.value.String())
+		case reflect.Slice:
+			items := []string{}
+			for _, item := range f.value.Interface().([]string) {
+				items = append(items, quote(item))
+			}
+
+			if style == "yaml" && len(items) > 0 {
+				fmt.Fprintf(&buf, "%v:\n", f.name)
+				for _, item := range items {
+					fmt.Fprintf(&buf, "  - %v\n", item)
+				}
+				continue
+			}
+			value = "[" + strings.Join(items, ", ") + "]"
+		default:
+			value = f.String()
+		}
+
+		if style == "toml" {
+			fmt.Fprintf(&buf, "%v = %v\n", f.name, value)
+		} else {
+			fmt.Fprintf(&buf, "%v: %v\n", f.name, value)
+		}
+	}
+
+	return buf.Bytes()
+}
@@ -0,0 +1,115 @@
+package configuration
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func readConfig(t *testing.T, name, data string) (EngineConfig, error) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	config := EngineConfig{WindowTitle: "default", MaxFPS: 60, AssetPaths: []string{"default"}}
+	err := ReadEngineConfig(path, &config)
+	return config, err
+}
+
+func TestReadFlatConfig(t *testing.T) {
+	for _, test := range []struct {
+		name, data string
+		title      string
+		maxFPS     int
+		vsync      bool
+		paths      []string
+	}{
+		{"plain.toml", "windowTitle = \"Game\"\nmaxFPS = 30\nvsync = true", "Game", 30, true, []string{"default"}},
+		{"plain.yaml", "windowTitle: Game\nmaxFPS: 30\nvsync: true", "Game", 30, true, []string{"default"}},
+
+		// Comments, but not inside quotes
+		{"comment.toml", "windowTitle = \"My # Game\" # title\nmaxFPS = 30 # cap", "My # Game", 30, false, []string{"default"}},
+		{"comment.yaml", "windowTitle: 'My # Game' # title\n# maxFPS: 10", "My # Game", 60, false, []string{"default"}},
+		{"escaped.toml", `windowTitle = "say \"hi\" # there"`, `say "hi" # there`, 60, false, []string{"default"}},
+		{"single.yaml", "windowTitle: 'it''s # mine' # title", "it's # mine", 60, false, []string{"default"}},
+
+		// Values are read by the field's kind
+		{"number.yaml", "windowTitle: 1942", "1942", 60, false, []string{"default"}},
+		{"bool.toml", "windowTitle = true", "true", 60, false, []string{"default"}},
+		{"empty.yaml", "windowTitle:", "", 60, false, []string{"default"}},
+
+		// Lists
+		{"list.toml", `assetPaths = ["a, b", 'c', d]`, "default", 60, false, []string{"a, b", "c", "d"}},
+		{"list.yaml", "assetPaths:\n  - a # first\n  - 'b # c'\nmaxFPS: 5", "default", 5, false, []string{"a", "b # c"}},
+		{"empty.toml", "assetPaths = []", "default", 60, false, []string{}},
+	} {
+		config, err := readConfig(t, test.name, test.data)
+		if err != nil {
+			t.Errorf("%v: %v", test.name, err)
+			continue
+		}
+		if config.WindowTitle != test.title {
+			t.Errorf("%v: windowTitle %q, want %q", test.name, config.WindowTitle, test.title)
+		}
+		if config.MaxFPS != test.maxFPS {
+			t.Errorf("%v: maxFPS %v, want %v", test.name, config.MaxFPS, test.maxFPS)
+		}
+		if config.VSync != test.vsync {
+			t.Errorf("%v: vsync %v, want %v", test.name, config.VSync, test.vsync)
+		}
+		if !reflect.DeepEqual(config.AssetPaths, test.paths) {
+			t.Errorf("%v: assetPaths %q, want %q", test.name, config.AssetPaths, test.paths)
+		}
+	}
+}
+
+func TestReadFlatConfigErrors(t *testing.T) {
+	for _, test := range []struct{ name, data string }{
+		{"unknown.toml", "windowTitle = \"Game\"\nwindowTitel = \"Game\""},
+		{"int.yaml", "maxFPS: fast"},
+		{"float.toml", "maxFPS = 1.5"},
+		{"bool.yaml", "vsync: 'maybe'"},
+		{"list.toml", "assetPaths = \"a\""},
+		{"item.yaml", "- a"},
+		{"item.yaml", "maxFPS:\n  - 5"},
+		{"table.toml", "[window]\ntitle = \"Game\""},
+		{"line.yaml", "windowTitle"},
+	} {
+		config, err := readConfig(t, test.name, test.data)
+		if err == nil {
+			t.Errorf("%v: %q accepted", test.name, test.data)
+			continue
+		}
+		if config.WindowTitle != "default" || config.MaxFPS != 60 {
+			t.Errorf("%v: %q changed the config", test.name, test.data)
+		}
+	}
+}
+
+func TestSaveConfig(t *testing.T) {
+	want := EngineConfig{
+		WindowTitle: "it's \"#1\"",
+		MaxFPS:      144,
+		VSync:       true,
+		AssetPaths:  []string{"a, b", "c#d"},
+	}
+	for _, name := range []string{"saved.json", "saved.toml", "saved.yaml"} {
+		path := filepath.Join(t.TempDir(), name)
+		if err := want.Save(path); err != nil {
+			t.Fatal(err)
+		}
+
+		var got EngineConfig
+		if err := ReadEngineConfig(path, &got); err != nil {
+			t.Errorf("%v: %v", name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%v: read back %+v, want %+v", name, got, want)
+		}
+	}
+}
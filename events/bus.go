@@ -0,0 +1,175 @@
+package events
+
+import (
+	"reflect"
+	"sync"
+)
+
+//  --------------------------------------------------
+//  Bus.go contains the event bus. Events are plain
+//  structs, and handlers subscribe to a single event
+//  type. Handlers are either synchronous, called as
+//  soon as an event is published, or deferred, called
+//  when the bus is flushed at the end of the frame.
+//
+//  Event types are declared next to whatever publishes
+//  them, e.g. cmd.SceneChanged or ui.ButtonClicked.
+//  --------------------------------------------------
+
+// Bus dispatches events to subscribed handlers. A nil
+// *Bus is valid, and drops everything published to it.
+// Subscribing to it returns a nil *Subscription.
+type Bus struct {
+	handlers map[reflect.Type][]*Subscription
+	nextID   int
+
+	// Events waiting for Flush, and whether they
+	// should also go to synchronous handlers
+	queue []queued
+
+	mu sync.Mutex
+}
+
+type queued struct {
+	event interface{}
+	all   bool
+}
+
+// Subscription is a registered handler
+type Subscription struct {
+	id       int
+	t        reflect.Type
+	deferred bool
+	handle   func(interface{})
+	bus      *Bus
+}
+
+func NewBus() *Bus {
+	return &Bus{
+		handlers: make(map[reflect.Type][]*Subscription),
+	}
+}
+
+// Subscribe calls handler synchronously for every published event of type E
+func Subscribe[E any](b *Bus, handler func(E)) *Subscription {
+	return b.subscribe(typeOf[E](), false, func(e interface{}) { handler(e.(E)) })
+}
+
+// SubscribeDeferred calls handler for every published event of type E,
+// when the bus is next flushed
+func SubscribeDeferred[E any](b *Bus, handler func(E)) *Subscription {
+	return b.subscribe(typeOf[E](), true, func(e interface{}) { handler(e.(E)) })
+}
+
+func typeOf[E any]() reflect.Type {
+	return reflect.TypeOf((*E)(nil)).Elem()
+}
+
+func (b *Bus) subscribe(t reflect.Type, deferred bool, handle func(interface{})) *Subscription {
+	if b == nil {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.nextID++
+	s := &Subscription{
+		id:       b.nextID,
+		t:        t,
+		deferred: deferred,
+		handle:   handle,
+		bus:      b,
+	}
+	b.handlers[t] = append(b.handlers[t], s)
+	return s
+}
+
+// Unsubscribe removes the handler from its bus. It does
+// nothing on a nil *Subscription.
+func (s *Subscription) Unsubscribe() {
+	if s == nil {
+		return
+	}
+
+	b := s.bus
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	subs := b.handlers[s.t]
+	for i, other := range subs {
+		if other.id == s.id {
+			b.handlers[s.t] = append(subs[:i:i], subs[i+1:]...)
+			return
+		}
+	}
+}
+
+// Publish calls the synchronous handlers of the event now, and
+// queues it for the deferred handlers
+func (b *Bus) Publish(event interface{}) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	subs := b.subscriptions(event, false)
+	if len(b.subscriptions(event, true)) > 0 {
+		b.queue = append(b.queue, queued{event: event})
+	}
+	b.mu.Unlock()
+
+	for _, s := range subs {
+		s.handle(event)
+	}
+}
+
+// PublishDeferred queues the event for every handler, synchronous or
+// deferred, until the next Flush. It's safe to call from any goroutine.
+func (b *Bus) PublishDeferred(event interface{}) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	b.queue = append(b.queue, queued{event: event, all: true})
+	b.mu.Unlock()
+}
+
+// Flush delivers the queued events. Events published while
+// flushing are delivered on the next Flush.
+func (b *Bus) Flush() {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	queue := b.queue
+	b.queue = nil
+	b.mu.Unlock()
+
+	for _, q := range queue {
+		b.mu.Lock()
+		subs := b.subscriptions(q.event, true)
+		if q.all {
+			subs = append(b.subscriptions(q.event, false), subs...)
+		}
+		b.mu.Unlock()
+
+		for _, s := range subs {
+			s.handle(q.event)
+		}
+	}
+}
+
+// subscriptions returns a copy of the handlers of an event, so
+// handlers can subscribe and unsubscribe while it's dispatched
+func (b *Bus) subscriptions(event interface{}, deferred bool) []*Subscription {
+	subs := []*Subscription{}
+	for _, s := range b.handlers[reflect.TypeOf(event)] {
+		if s.deferred == deferred {
+			subs = append(subs, s)
+		}
+	}
+	return subs
+}
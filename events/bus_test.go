@@ -0,0 +1,123 @@
+package events
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+type testEvent struct{ N int }
+
+type otherEvent struct{}
+
+func TestSyncAndDeferred(t *testing.T) {
+	b := NewBus()
+	var sync, deferred []int
+	Subscribe(b, func(e testEvent) { sync = append(sync, e.N) })
+	SubscribeDeferred(b, func(e testEvent) { deferred = append(deferred, e.N) })
+	Subscribe(b, func(e otherEvent) { t.Error("got an event of another type") })
+
+	b.Publish(testEvent{1})
+	if !reflect.DeepEqual(sync, []int{1}) || len(deferred) != 0 {
+		t.Fatalf("after Publish: sync %v, deferred %v", sync, deferred)
+	}
+
+	b.PublishDeferred(testEvent{2})
+	if !reflect.DeepEqual(sync, []int{1}) {
+		t.Fatalf("PublishDeferred called a handler straight away: %v", sync)
+	}
+
+	b.Flush()
+	if !reflect.DeepEqual(sync, []int{1, 2}) || !reflect.DeepEqual(deferred, []int{1, 2}) {
+		t.Errorf("after Flush: sync %v, deferred %v", sync, deferred)
+	}
+
+	b.Flush()
+	if len(sync) != 2 || len(deferred) != 2 {
+		t.Errorf("events delivered twice: sync %v, deferred %v", sync, deferred)
+	}
+}
+
+func TestFlushOrder(t *testing.T) {
+	b := NewBus()
+	got := []string{}
+	Subscribe(b, func(e testEvent) { got = append(got, "sync", strconv.Itoa(e.N)) })
+	SubscribeDeferred(b, func(e testEvent) {
+		got = append(got, "deferred", strconv.Itoa(e.N))
+		if e.N == 1 {
+			// Published while flushing, so it waits for the next Flush
+			b.PublishDeferred(testEvent{9})
+		}
+	})
+
+	b.PublishDeferred(testEvent{1})
+	b.PublishDeferred(testEvent{2})
+	got = got[:0]
+	b.Flush()
+
+	want := []string{"sync", "1", "deferred", "1", "sync", "2", "deferred", "2"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("first Flush delivered %v, want %v", got, want)
+	}
+
+	got = got[:0]
+	b.Flush()
+	if want := []string{"sync", "9", "deferred", "9"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("second Flush delivered %v, want %v", got, want)
+	}
+}
+
+func TestUnsubscribeDuringDispatch(t *testing.T) {
+	b := NewBus()
+
+	calls := 0
+	var s *Subscription
+	s = Subscribe(b, func(e testEvent) {
+		calls++
+		s.Unsubscribe()
+	})
+
+	// Subscribed during dispatch, so only sees later events
+	var late []int
+	Subscribe(b, func(e testEvent) {
+		if late == nil {
+			late = []int{}
+			Subscribe(b, func(e testEvent) { late = append(late, e.N) })
+		}
+	})
+
+	b.Publish(testEvent{1})
+	b.Publish(testEvent{2})
+	if calls != 1 {
+		t.Errorf("handler that unsubscribed itself was called %v times", calls)
+	}
+	if !reflect.DeepEqual(late, []int{2}) {
+		t.Errorf("handler subscribed during dispatch got %v, want [2]", late)
+	}
+
+	// A deferred handler that unsubscribes misses the rest of the queue
+	deferred := 0
+	var d *Subscription
+	d = SubscribeDeferred(b, func(e testEvent) {
+		deferred++
+		d.Unsubscribe()
+	})
+	b.Publish(testEvent{3})
+	b.Publish(testEvent{4})
+	b.Flush()
+	if deferred != 1 {
+		t.Errorf("deferred handler that unsubscribed itself was called %v times", deferred)
+	}
+}
+
+func TestNilBus(t *testing.T) {
+	var b *Bus
+	s := Subscribe(b, func(e testEvent) { t.Error("nil bus delivered an event") })
+	if s != nil {
+		t.Errorf("nil bus returned subscription %v", s)
+	}
+	b.Publish(testEvent{})
+	b.PublishDeferred(testEvent{})
+	b.Flush()
+	s.Unsubscribe()
+}
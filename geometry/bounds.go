@@ -0,0 +1,218 @@
+package geometry
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+//  --------------------------------------------------
+//  Bounds.go contains the bounding volumes used for
+//  culling. Every VAO computes an axis aligned box and
+//  a sphere around its vertices when it's created, and
+//  a Frustum, built from a projection * view matrix,
+//  tests them against what the camera can see.
+//  --------------------------------------------------
+
+// AABB is an axis aligned bounding box
+type AABB struct {
+	Min mgl32.Vec3
+	Max mgl32.Vec3
+}
+
+// Sphere is a bounding sphere
+type Sphere struct {
+	Center mgl32.Vec3
+	Radius float32
+}
+
+// ComputeBounds returns the box and sphere around a
+// list of vertex positions, stored as x, y, z triples
+func ComputeBounds(vertices []float32) (AABB, Sphere) {
+	if len(vertices) < 3 {
+		return AABB{}, Sphere{}
+	}
+
+	box := AABB{
+		Min: mgl32.Vec3{vertices[0], vertices[1], vertices[2]},
+		Max: mgl32.Vec3{vertices[0], vertices[1], vertices[2]},
+	}
+	for i := 3; i+2 < len(vertices); i += 3 {
+		box = box.Extend(mgl32.Vec3{vertices[i], vertices[i+1], vertices[i+2]})
+	}
+
+	sphere := Sphere{Center: box.Center()}
+	for i := 0; i+2 < len(vertices); i += 3 {
+		d := mgl32.Vec3{vertices[i], vertices[i+1], vertices[i+2]}.Sub(sphere.Center).Len()
+		if d > sphere.Radius {
+			sphere.Radius = d
+		}
+	}
+
+	return box, sphere
+}
+
+func (b AABB) Center() mgl32.Vec3 {
+	return b.Min.Add(b.Max).Mul(0.5)
+}
+
+// Extents returns half the size of the box on each axis
+func (b AABB) Extents() mgl32.Vec3 {
+	return b.Max.Sub(b.Min).Mul(0.5)
+}
+
+// Extend returns the box grown to contain p
+func (b AABB) Extend(p mgl32.Vec3) AABB {
+	for i := 0; i < 3; i++ {
+		b.Min[i] = float32(math.Min(float64(b.Min[i]), float64(p[i])))
+		b.Max[i] = float32(math.Max(float64(b.Max[i]), float64(p[i])))
+	}
+	return b
+}
+
+// Union returns the box containing both boxes
+func (b AABB) Union(other AABB) AABB {
+	return b.Extend(other.Min).Extend(other.Max)
+}
+
+// Transform returns the box containing b after it's transformed by m
+func (b AABB) Transform(m mgl32.Mat4) AABB {
+	center := m.Mul4x1(b.Center().Vec4(1)).Vec3()
+	extents := b.Extents()
+
+	var e mgl32.Vec3
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			e[i] += float32(math.Abs(float64(m.At(i, j)))) * extents[j]
+		}
+	}
+
+	return AABB{Min: center.Sub(e), Max: center.Add(e)}
+}
+
+// Contains checks if a point is inside the box
+func (b AABB) Contains(p mgl32.Vec3) bool {
+	for i := 0; i < 3; i++ {
+		if p[i] < b.Min[i] || p[i] > b.Max[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// IntersectRay returns the distance along a ray to where it enters the
+// box, and false if it misses. dir must be normalized. Rays starting
+// inside the box hit it at distance 0.
+func (b AABB) IntersectRay(origin, dir mgl32.Vec3) (float32, bool) {
+	near, far := float32(math.Inf(-1)), float32(math.Inf(1))
+
+	for i := 0; i < 3; i++ {
+		if dir[i] == 0 {
+			if origin[i] < b.Min[i] || origin[i] > b.Max[i] {
+				return 0, false
+			}
+			continue
+		}
+
+		t1 := (b.Min[i] - origin[i]) / dir[i]
+		t2 := (b.Max[i] - origin[i]) / dir[i]
+		if t1 > t2 {
+			t1, t2 = t2, t1
+		}
+		if t1 > near {
+			near = t1
+		}
+		if t2 < far {
+			far = t2
+		}
+		if near > far || far < 0 {
+			return 0, false
+		}
+	}
+
+	if near < 0 {
+		return 0, true
+	}
+	return near, true
+}
+
+// Transform returns the sphere containing s after it's transformed by m
+func (s Sphere) Transform(m mgl32.Mat4) Sphere {
+	scale := float32(0)
+	for j := 0; j < 3; j++ {
+		if l := m.Col(j).Vec3().Len(); l > scale {
+			scale = l
+		}
+	}
+
+	return Sphere{
+		Center: m.Mul4x1(s.Center.Vec4(1)).Vec3(),
+		Radius: s.Radius * scale,
+	}
+}
+
+//  --------------------------------------------------
+//  Frustum
+//  --------------------------------------------------
+
+// Frustum is the volume visible to a camera, as six planes
+// facing inwards: left, right, bottom, top, near and far
+type Frustum struct {
+	Planes [6]mgl32.Vec4
+}
+
+// NewFrustum extracts the frustum planes from a projection * view
+// matrix. With a projection matrix alone, the planes are in view space.
+func NewFrustum(m mgl32.Mat4) Frustum {
+	row := func(i int) mgl32.Vec4 { return m.Row(i) }
+
+	f := Frustum{Planes: [6]mgl32.Vec4{
+		row(3).Add(row(0)),
+		row(3).Sub(row(0)),
+		row(3).Add(row(1)),
+		row(3).Sub(row(1)),
+		row(3).Add(row(2)),
+		row(3).Sub(row(2)),
+	}}
+
+	for i, p := range f.Planes {
+		if l := p.Vec3().Len(); l > 0 {
+			f.Planes[i] = p.Mul(1 / l)
+		}
+	}
+
+	return f
+}
+
+// ContainsPoint checks if a point is inside the frustum
+func (f Frustum) ContainsPoint(p mgl32.Vec3) bool {
+	return f.IntersectsSphere(Sphere{Center: p})
+}
+
+// IntersectsSphere checks if any part of a sphere is inside the frustum
+func (f Frustum) IntersectsSphere(s Sphere) bool {
+	for _, p := range f.Planes {
+		if p.Vec3().Dot(s.Center)+p.W() < -s.Radius {
+			return false
+		}
+	}
+	return true
+}
+
+// IntersectsAABB checks if any part of a box may be inside the frustum.
+// Boxes near a corner of the frustum can pass without being visible.
+func (f Frustum) IntersectsAABB(b AABB) bool {
+	for _, p := range f.Planes {
+		// The corner of the box furthest along the plane's normal
+		corner := b.Min
+		for i := 0; i < 3; i++ {
+			if p[i] >= 0 {
+				corner[i] = b.Max[i]
+			}
+		}
+		if p.Vec3().Dot(corner)+p.W() < 0 {
+			return false
+		}
+	}
+	return true
+}
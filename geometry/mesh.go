@@ -7,6 +7,7 @@ package geometry
 //  --------------------------------------------------
 
 import (
+	"rapidengine/backend"
 	"rapidengine/material"
 
 	"github.com/go-gl/gl/v4.1-core/gl"
@@ -15,9 +16,12 @@ import (
 
 type Mesh struct {
 
-	// Mesh type
+	// Mesh type, or the path of the file it was loaded from
 	ID string
 
+	// Scale the mesh was loaded at, if it was loaded from a file
+	FileScale float32
+
 	// VAO containing vertices & indices
 	VAO *VertexArray
 
@@ -46,40 +50,30 @@ type Mesh struct {
 	InstancingEnabled bool
 	NumInstances      int
 
+	// Per-instance model matrices, see SetInstanceMatrices
+	InstanceMatrices bool
+	instanceBuffer   uint32
+
 	// Tesselation
 	TesselationEnabled bool
 }
 
 func (p *Mesh) Render(mat material.Material, viewMtx, modelMtx, projMtx *float32, delta, totalTime float64, darkness float32) {
-	gl.BindVertexArray(p.VAO.id)
+	p.Bind()
 	mat.GetShader().Bind()
+	p.BindInstancing(mat.GetShader())
 
-	gl.EnableVertexAttribArray(0)
-
-	if p.TexCoordsEnabled {
-		gl.EnableVertexAttribArray(1)
-	}
-	if p.NormalsEnabled {
-		gl.EnableVertexAttribArray(2)
-	}
-	if p.TangentsEnabled {
-		gl.EnableVertexAttribArray(3)
-	}
-	if p.BitangentsEnabled {
-		gl.EnableVertexAttribArray(4)
-	}
-
-	gl.UniformMatrix4fv(
+	backend.Current.UniformMatrix4fv(
 		mat.GetShader().GetUniform("viewMtx"),
 		1, false, viewMtx,
 	)
 
-	gl.UniformMatrix4fv(
+	backend.Current.UniformMatrix4fv(
 		mat.GetShader().GetUniform("modelMtx"),
 		1, false, modelMtx,
 	)
 
-	gl.UniformMatrix4fv(
+	backend.Current.UniformMatrix4fv(
 		mat.GetShader().GetUniform("projectionMtx"),
 		1, false, projMtx,
 	)
@@ -89,18 +83,103 @@ func (p *Mesh) Render(mat material.Material, viewMtx, modelMtx, projMtx *float32
 	p.Draw()
 }
 
+// Bind binds the mesh's VAO and enables its vertex attributes
+func (p *Mesh) Bind() {
+	backend.Current.BindVertexArray(p.VAO.id)
+
+	backend.Current.EnableVertexAttribArray(0)
+
+	if p.TexCoordsEnabled {
+		backend.Current.EnableVertexAttribArray(1)
+	}
+	if p.NormalsEnabled {
+		backend.Current.EnableVertexAttribArray(2)
+	}
+	if p.TangentsEnabled {
+		backend.Current.EnableVertexAttribArray(3)
+	}
+	if p.BitangentsEnabled {
+		backend.Current.EnableVertexAttribArray(4)
+	}
+
+	// Meshes sharing a VAO may or may not be instanced, so
+	// the instance attributes are pointed at this mesh's buffer
+	if p.InstanceMatrices {
+		backend.Current.BindBuffer(gl.ARRAY_BUFFER, p.instanceBuffer)
+		for i := uint32(0); i < 4; i++ {
+			backend.Current.VertexAttribPointer(instanceMatrixAttrib+i, 4, gl.FLOAT, false, 64, int(16*i))
+			backend.Current.EnableVertexAttribArray(instanceMatrixAttrib + i)
+			backend.Current.VertexAttribDivisor(instanceMatrixAttrib+i, 1)
+		}
+		p.VAO.instanceAttribs = true
+	} else if p.VAO.instanceAttribs {
+		for i := uint32(0); i < 4; i++ {
+			backend.Current.DisableVertexAttribArray(instanceMatrixAttrib + i)
+		}
+		p.VAO.instanceAttribs = false
+	}
+}
+
+// instanceMatrixAttrib is the first of the four attributes
+// holding the columns of the per-instance model matrix
+const instanceMatrixAttrib = 6
+
+// SetInstanceMatrices uploads a model matrix per instance, and
+// makes the mesh draw one instance of itself for each of them.
+// The shader's modelMtx is ignored for instanced meshes.
+func (p *Mesh) SetInstanceMatrices(matrices []mgl32.Mat4) {
+	if p.instanceBuffer == 0 {
+		p.instanceBuffer = backend.Current.GenBuffer()
+	}
+
+	data := make([]float32, 0, 16*len(matrices))
+	for _, m := range matrices {
+		data = append(data, m[:]...)
+	}
+	backend.Current.BindBuffer(gl.ARRAY_BUFFER, p.instanceBuffer)
+	backend.Current.BufferFloats(gl.ARRAY_BUFFER, data, gl.DYNAMIC_DRAW)
+
+	p.InstanceMatrices = true
+	p.InstancingEnabled = true
+	p.NumInstances = len(matrices)
+}
+
+// BindInstancing tells the shader whether to read the
+// per-instance model matrices, if it supports them
+func (p *Mesh) BindInstancing(shader *material.ShaderProgram) {
+	if !shader.HasUniform("instanceMatrices") {
+		return
+	}
+	instanced := int32(0)
+	if p.InstanceMatrices {
+		instanced = 1
+	}
+	backend.Current.Uniform1i(shader.GetUniform("instanceMatrices"), instanced)
+}
+
+// GetBounds returns the bounds of the mesh in model space. Meshes
+// that are instanced or tesselated are placed by their shaders,
+// so they have no bounds, and false is returned.
+func (p *Mesh) GetBounds() (AABB, Sphere, bool) {
+	if p.VAO == nil || p.InstancingEnabled || p.TesselationEnabled {
+		return AABB{}, Sphere{}, false
+	}
+	box, sphere := p.VAO.GetBounds()
+	return box, sphere, true
+}
+
 func (p *Mesh) Draw() {
 	if p.InstancingEnabled {
-		gl.DrawElementsInstanced(gl.TRIANGLES, p.NumVertices, gl.UNSIGNED_INT, gl.PtrOffset(0), int32(p.NumInstances))
+		backend.Current.DrawElementsInstanced(gl.TRIANGLES, p.NumVertices, gl.UNSIGNED_INT, 0, int32(p.NumInstances))
 		return
 	}
 
 	if p.TesselationEnabled {
-		gl.DrawElements(gl.PATCHES, p.NumVertices, gl.UNSIGNED_INT, gl.PtrOffset(0))
+		backend.Current.DrawElements(gl.PATCHES, p.NumVertices, gl.UNSIGNED_INT, 0)
 		return
 	}
 
-	gl.DrawElements(gl.TRIANGLES, p.NumVertices, gl.UNSIGNED_INT, gl.PtrOffset(0))
+	backend.Current.DrawElements(gl.TRIANGLES, p.NumVertices, gl.UNSIGNED_INT, 0)
 }
 
 // NormalizeSizes takes in a size in pixels and normalizes to [0, 1]
@@ -11,6 +11,14 @@ import (
 type Model struct {
 	Meshes    []Mesh
 	Materials map[int]material.Material
+
+	// Path of the file the model was imported from, if it was
+	Path string
+
+	// Bounds of every mesh, see ComputeBounds
+	bounds  AABB
+	sphere  Sphere
+	bounded bool
 }
 
 func (m *Model) Render(viewMtx *float32, modelMtx *float32, projMtx *float32, totalTime float64) {
@@ -19,6 +27,48 @@ func (m *Model) Render(viewMtx *float32, modelMtx *float32, projMtx *float32, to
 	}
 }
 
+// ComputeBounds computes the box and sphere around all of the
+// model's meshes. It's called when a model is created or attached
+// to a child, and must be called again if the meshes change.
+func (m *Model) ComputeBounds() {
+	m.bounded = false
+	for i := range m.Meshes {
+		box, _, ok := m.Meshes[i].GetBounds()
+		if !ok {
+			m.bounded = false
+			return
+		}
+		if !m.bounded {
+			m.bounds = box
+			m.bounded = true
+		} else {
+			m.bounds = m.bounds.Union(box)
+		}
+	}
+
+	m.sphere = Sphere{Center: m.bounds.Center()}
+	for i := range m.Meshes {
+		_, s, _ := m.Meshes[i].GetBounds()
+		if r := s.Center.Sub(m.sphere.Center).Len() + s.Radius; r > m.sphere.Radius {
+			m.sphere.Radius = r
+		}
+	}
+}
+
+// GetBounds returns the bounds of the model in model space, and
+// false if any of its meshes has no bounds
+func (m *Model) GetBounds() (AABB, Sphere, bool) {
+	if !m.bounded {
+		return AABB{}, Sphere{}, false
+	}
+	for i := range m.Meshes {
+		if m.Meshes[i].InstancingEnabled || m.Meshes[i].TesselationEnabled {
+			return AABB{}, Sphere{}, false
+		}
+	}
+	return m.bounds, m.sphere, true
+}
+
 func (m *Model) ComputeTangents() {
 	for _, ms := range m.Meshes {
 		ms.ComputeTangents()
@@ -33,8 +83,10 @@ func (m *Model) EnableInstancing(num int) {
 }
 
 func NewModel(m Mesh, mat material.Material) Model {
-	return Model{
+	model := Model{
 		Meshes:    []Mesh{m},
 		Materials: map[int]material.Material{0: mat},
 	}
+	model.ComputeBounds()
+	return model
 }
@@ -2,21 +2,41 @@ package geometry
 
 import (
 	"bufio"
-	"os"
+	"bytes"
+	"fmt"
 	"strconv"
 	"strings"
 
 	"github.com/go-gl/mathgl/mgl32"
+
+	"rapidengine/assets"
 )
 
-func LoadObj(path string, scale float32) Mesh {
-	f, err := os.Open(path)
+// LoadObj loads a triangulated obj file into a Mesh. It returns an
+// assets.NotFoundError if the file is missing, or an assets.DecodeError
+// with the offending line if the file is malformed.
+func LoadObj(path string, scale float32) (Mesh, error) {
+	return LoadObjFrom(assets.Default, path, scale)
+}
+
+// LoadObjFrom loads an obj file found through fsys, see LoadObj
+func LoadObjFrom(fsys *assets.FileSystem, path string, scale float32) (Mesh, error) {
+	data, err := fsys.ReadAsset(path)
 	if err != nil {
-		panic(err)
+		return Mesh{}, err
 	}
-	defer f.Close()
 
-	scanner := bufio.NewScanner(f)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	lineNum := 0
+
+	decodeError := func(err error) error {
+		return &assets.DecodeError{
+			Path:    path,
+			Format:  "obj",
+			Context: fmt.Sprintf("line %v", lineNum),
+			Err:     err,
+		}
+	}
 
 	vertices := []mgl32.Vec3{}
 	normals := []mgl32.Vec3{}
@@ -28,31 +48,36 @@ func LoadObj(path string, scale float32) Mesh {
 
 	// Load vertex/normal/texture data into slices. This is randomly ordered.
 	for scanner.Scan() {
+		lineNum++
 		line = strings.Split(scanner.Text(), " ")
 		if line[0] == "v" {
-			x, _ := strconv.ParseFloat(line[1], 32)
-			y, _ := strconv.ParseFloat(line[2], 32)
-			z, _ := strconv.ParseFloat(line[3], 32)
+			v, err := parseObjFloats(line, 3)
+			if err != nil {
+				return Mesh{}, decodeError(err)
+			}
 
-			vertex := mgl32.Vec3{float32(x), float32(y), float32(z)}
+			vertex := mgl32.Vec3{v[0], v[1], v[2]}
 			vertex = scaleMatrix.Mul4x1(vertex.Vec4(1.0)).Vec3()
 
 			vertices = append(vertices, vertex)
 		}
 
 		if line[0] == "vn" {
-			x, _ := strconv.ParseFloat(line[1], 32)
-			y, _ := strconv.ParseFloat(line[2], 32)
-			z, _ := strconv.ParseFloat(line[3], 32)
+			n, err := parseObjFloats(line, 3)
+			if err != nil {
+				return Mesh{}, decodeError(err)
+			}
 
-			normals = append(normals, mgl32.Vec3{float32(x), float32(y), float32(z)})
+			normals = append(normals, mgl32.Vec3{n[0], n[1], n[2]})
 		}
 
 		if line[0] == "vt" {
-			x, _ := strconv.ParseFloat(line[1], 32)
-			y, _ := strconv.ParseFloat(line[2], 32)
+			t, err := parseObjFloats(line, 2)
+			if err != nil {
+				return Mesh{}, decodeError(err)
+			}
 
-			textures = append(textures, mgl32.Vec2{float32(x), float32(y)})
+			textures = append(textures, mgl32.Vec2{t[0], t[1]})
 		}
 
 		if line[0] == "f" {
@@ -60,6 +85,10 @@ func LoadObj(path string, scale float32) Mesh {
 		}
 	}
 
+	if len(vertices) == 0 {
+		return Mesh{}, decodeError(fmt.Errorf("no vertices"))
+	}
+
 	indicesArray := []uint32{}
 
 	var verticesArray []float32
@@ -73,77 +102,29 @@ func LoadObj(path string, scale float32) Mesh {
 	// Load faces into arrays
 	for {
 		if line[0] == "f" {
-			v1 := strings.Split(line[1], "/")
-			v2 := strings.Split(line[2], "/")
-			v3 := strings.Split(line[3], "/")
-
-			// Vertex 1
-			vertexIndex, _ := strconv.ParseInt(v1[0], 10, 32)
-			textureIndex, _ := strconv.ParseInt(v1[1], 10, 32)
-			normalIndex, _ := strconv.ParseInt(v1[2], 10, 32)
-
-			vertexIndex--
-			textureIndex--
-			normalIndex--
-
-			indicesArray = append(indicesArray, uint32(vertexIndex))
-
-			currentTexture := textures[textureIndex]
-			currentNormal := normals[normalIndex]
-
-			texturesArray[vertexIndex*3] = currentTexture.X()
-			texturesArray[vertexIndex*3+1] = 1 - currentTexture.Y()
-			texturesArray[vertexIndex*3+2] = 0
-
-			normalsArray[vertexIndex*3] = currentNormal.X()
-			normalsArray[vertexIndex*3+1] = currentNormal.Y()
-			normalsArray[vertexIndex*3+2] = currentNormal.Z()
-
-			// Vertex 2
-			vertexIndex, _ = strconv.ParseInt(v2[0], 10, 32)
-			textureIndex, _ = strconv.ParseInt(v2[1], 10, 32)
-			normalIndex, _ = strconv.ParseInt(v2[2], 10, 32)
-
-			vertexIndex--
-			textureIndex--
-			normalIndex--
-
-			indicesArray = append(indicesArray, uint32(vertexIndex))
-
-			currentTexture = textures[textureIndex]
-			currentNormal = normals[normalIndex]
-
-			texturesArray[vertexIndex*3] = currentTexture.X()
-			texturesArray[vertexIndex*3+1] = 1 - currentTexture.Y()
-			texturesArray[vertexIndex*3+2] = 0
+			if len(line) < 4 {
+				return Mesh{}, decodeError(fmt.Errorf("face has %v vertices, expected 3", len(line)-1))
+			}
 
-			normalsArray[vertexIndex*3] = currentNormal.X()
-			normalsArray[vertexIndex*3+1] = currentNormal.Y()
-			normalsArray[vertexIndex*3+2] = currentNormal.Z()
+			for _, field := range line[1:4] {
+				vertexIndex, textureIndex, normalIndex, err := parseObjFaceVertex(field, len(vertices), len(textures), len(normals))
+				if err != nil {
+					return Mesh{}, decodeError(err)
+				}
 
-			// Vertex 3
-			vertexIndex, _ = strconv.ParseInt(v3[0], 10, 32)
-			textureIndex, _ = strconv.ParseInt(v3[1], 10, 32)
-			normalIndex, _ = strconv.ParseInt(v3[2], 10, 32)
+				indicesArray = append(indicesArray, uint32(vertexIndex))
 
-			vertexIndex--
-			textureIndex--
-			normalIndex--
+				currentTexture := textures[textureIndex]
+				currentNormal := normals[normalIndex]
 
-			indicesArray = append(indicesArray, uint32(vertexIndex))
+				texturesArray[vertexIndex*3] = currentTexture.X()
+				texturesArray[vertexIndex*3+1] = 1 - currentTexture.Y()
+				texturesArray[vertexIndex*3+2] = 0
 
-			//println(textureIndex)
-
-			currentTexture = textures[textureIndex]
-			currentNormal = normals[normalIndex]
-
-			texturesArray[vertexIndex*3] = currentTexture.X()
-			texturesArray[vertexIndex*3+1] = 1 - currentTexture.Y()
-			texturesArray[vertexIndex*3+2] = 0
-
-			normalsArray[vertexIndex*3] = currentNormal.X()
-			normalsArray[vertexIndex*3+1] = currentNormal.Y()
-			normalsArray[vertexIndex*3+2] = currentNormal.Z()
+				normalsArray[vertexIndex*3] = currentNormal.X()
+				normalsArray[vertexIndex*3+1] = currentNormal.Y()
+				normalsArray[vertexIndex*3+2] = currentNormal.Z()
+			}
 		}
 
 		if line[0] == "usemtl" {
@@ -154,9 +135,14 @@ func LoadObj(path string, scale float32) Mesh {
 			break
 		}
 
+		lineNum++
 		line = strings.Split(scanner.Text(), " ")
 	}
 
+	if err := scanner.Err(); err != nil {
+		return Mesh{}, decodeError(err)
+	}
+
 	for i, v := range vertices {
 		verticesArray[i*3] = v.X()
 		verticesArray[i*3+1] = v.Y()
@@ -165,6 +151,7 @@ func LoadObj(path string, scale float32) Mesh {
 
 	m := Mesh{
 		ID:          path,
+		FileScale:   scale,
 		VAO:         NewVertexArray(verticesArray, indicesArray),
 		Normals:     normalsArray,
 		TexCoords:   texturesArray,
@@ -176,5 +163,46 @@ func LoadObj(path string, scale float32) Mesh {
 	m.TexCoordsEnabled = true
 	m.NormalsEnabled = true
 
-	return m
+	return m, nil
+}
+
+// parseObjFloats parses the first n values following the keyword of a line
+func parseObjFloats(line []string, n int) ([]float32, error) {
+	if len(line) < n+1 {
+		return nil, fmt.Errorf("%v has %v values, expected %v", line[0], len(line)-1, n)
+	}
+
+	out := make([]float32, n)
+	for i := range out {
+		f, err := strconv.ParseFloat(line[i+1], 32)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = float32(f)
+	}
+	return out, nil
+}
+
+// parseObjFaceVertex parses a v/vt/vn face vertex into zero-based
+// indices, checking each against the number of values loaded
+func parseObjFaceVertex(field string, numVertices, numTextures, numNormals int) (int64, int64, int64, error) {
+	parts := strings.Split(field, "/")
+	if len(parts) != 3 {
+		return 0, 0, 0, fmt.Errorf("face vertex %q is not in v/vt/vn form", field)
+	}
+
+	indices := [3]int64{}
+	limits := [3]int{numVertices, numTextures, numNormals}
+	for i, part := range parts {
+		index, err := strconv.ParseInt(part, 10, 32)
+		if err != nil {
+			return 0, 0, 0, err
+		}
+		if index < 1 || int(index) > limits[i] {
+			return 0, 0, 0, fmt.Errorf("face vertex %q index %v out of range", field, index)
+		}
+		indices[i] = index - 1
+	}
+
+	return indices[0], indices[1], indices[2], nil
 }
@@ -2,10 +2,10 @@ package geometry
 
 import (
 	"math"
+	"rapidengine/backend"
 	"rapidengine/configuration"
 	"rapidengine/material"
 
-	"github.com/go-gl/gl/v4.1-core/gl"
 	"github.com/go-gl/mathgl/mgl32"
 )
 
@@ -238,7 +238,7 @@ func NewPlane(wIDth, height, density int, heightData [][]float32, scale float32)
 	m.VAO.AddVertexAttribute(m.TexCoords, 1, 3)
 	m.VAO.AddVertexAttribute(m.Normals, 2, 3)
 
-	gl.BindVertexArray(0)
+	backend.Current.BindVertexArray(0)
 
 	return m
 }
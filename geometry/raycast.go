@@ -0,0 +1,89 @@
+package geometry
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+//  --------------------------------------------------
+//  Raycast.go contains exact ray tests against the
+//  triangles of meshes, for picking. Rays are tested
+//  in model space, so the distances returned are in
+//  multiples of the ray's direction, which doesn't
+//  need to be normalized.
+//  --------------------------------------------------
+
+// IntersectTriangle returns the distance along a ray to where it
+// crosses the triangle abc from either side, and false if it misses
+func IntersectTriangle(origin, dir, a, b, c mgl32.Vec3) (float32, bool) {
+	const epsilon = 1e-7
+
+	e1, e2 := b.Sub(a), c.Sub(a)
+	p := dir.Cross(e2)
+	det := e1.Dot(p)
+	if det > -epsilon && det < epsilon {
+		return 0, false
+	}
+	inv := 1 / det
+
+	s := origin.Sub(a)
+	u := s.Dot(p) * inv
+	if u < 0 || u > 1 {
+		return 0, false
+	}
+
+	q := s.Cross(e1)
+	v := dir.Dot(q) * inv
+	if v < 0 || u+v > 1 {
+		return 0, false
+	}
+
+	t := e2.Dot(q) * inv
+	return t, t >= 0
+}
+
+// IntersectRay returns the distance along a ray to the nearest
+// triangle of the vertex array, and false if it misses them all
+func (vertexArray *VertexArray) IntersectRay(origin, dir mgl32.Vec3) (float32, bool) {
+	v, indices := vertexArray.vertices, vertexArray.indices
+	vertex := func(i uint32) mgl32.Vec3 {
+		return mgl32.Vec3{v[i*3], v[i*3+1], v[i*3+2]}
+	}
+
+	n := uint32(len(v) / 3)
+	nearest, hit := float32(0), false
+	for i := 0; i+2 < len(indices); i += 3 {
+		if indices[i] >= n || indices[i+1] >= n || indices[i+2] >= n {
+			continue
+		}
+		t, ok := IntersectTriangle(origin, dir, vertex(indices[i]), vertex(indices[i+1]), vertex(indices[i+2]))
+		if ok && (!hit || t < nearest) {
+			nearest, hit = t, true
+		}
+	}
+	return nearest, hit
+}
+
+// IntersectRay tests a ray against the mesh's triangles. Meshes without
+// bounds, see GetBounds, are placed by their shaders and are never hit.
+func (p *Mesh) IntersectRay(origin, dir mgl32.Vec3) (float32, bool) {
+	box, _, ok := p.GetBounds()
+	if !ok {
+		return 0, false
+	}
+	if _, ok := box.IntersectRay(origin, dir); !ok {
+		return 0, false
+	}
+	return p.VAO.IntersectRay(origin, dir)
+}
+
+// IntersectRay returns the distance along a ray to the nearest
+// triangle of any of the model's meshes
+func (m *Model) IntersectRay(origin, dir mgl32.Vec3) (float32, bool) {
+	nearest, hit := float32(0), false
+	for i := range m.Meshes {
+		if t, ok := m.Meshes[i].IntersectRay(origin, dir); ok && (!hit || t < nearest) {
+			nearest, hit = t, true
+		}
+	}
+	return nearest, hit
+}
@@ -1,15 +1,216 @@
 package geometry
 
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+//  --------------------------------------------------
+//  Transform.go contains Transform, the position,
+//  rotation and scale of anything in the world. The
+//  rotation is a quaternion, so it can be turned about
+//  any axis without the gimbal lock of Euler angles,
+//  which are still available through SetEuler and
+//  Euler.
+//
+//  The model matrix is built as translation, then
+//  rotation, then scale, and cached until one of the
+//  fields changes.
+//
+//  Objects face down -Z, with +Y up and +X right, the
+//  same as the camera.
+//  --------------------------------------------------
+
 type Transform struct {
-	X float32
-	Y float32
-	Z float32
+	Position mgl32.Vec3
+	Rotation mgl32.Quat
+	Scale    mgl32.Vec3
 
-	SX float32
-	SY float32
-	SZ float32
+	// Cached model matrix, and the fields it was built from
+	matrix        mgl32.Mat4
+	matrixValid   bool
+	builtPosition mgl32.Vec3
+	builtRotation mgl32.Quat
+	builtScale    mgl32.Vec3
 }
 
+// NewTransform returns a transform at a position
+// and scale, with no rotation
 func NewTransform(x, y, z, sx, sy, sz float32) Transform {
-	return Transform{x, y, z, sx, sy, sz}
+	return Transform{
+		Position: mgl32.Vec3{x, y, z},
+		Rotation: mgl32.QuatIdent(),
+		Scale:    mgl32.Vec3{sx, sy, sz},
+	}
+}
+
+// Matrix returns the model matrix of the transform
+func (t *Transform) Matrix() mgl32.Mat4 {
+	if t.matrixValid && t.Position == t.builtPosition && t.Rotation == t.builtRotation && t.Scale == t.builtScale {
+		return t.matrix
+	}
+
+	m := mgl32.Translate3D(t.Position.X(), t.Position.Y(), t.Position.Z())
+	m = m.Mul4(t.Rotation.Normalize().Mat4())
+	m = m.Mul4(mgl32.Scale3D(t.Scale.X(), t.Scale.Y(), t.Scale.Z()))
+
+	t.matrix, t.matrixValid = m, true
+	t.builtPosition, t.builtRotation, t.builtScale = t.Position, t.Rotation, t.Scale
+	return m
+}
+
+// TransformPoint converts a point from the transform's space to the world
+func (t *Transform) TransformPoint(p mgl32.Vec3) mgl32.Vec3 {
+	return t.Matrix().Mul4x1(p.Vec4(1)).Vec3()
+}
+
+//  --------------------------------------------------
+//  Position and Scale
+//  --------------------------------------------------
+
+func (t *Transform) SetPosition(x, y, z float32) {
+	t.Position = mgl32.Vec3{x, y, z}
+}
+
+// Translate moves the transform in world space
+func (t *Transform) Translate(x, y, z float32) {
+	t.Position = t.Position.Add(mgl32.Vec3{x, y, z})
+}
+
+// TranslateLocal moves the transform along its own axes, so
+// TranslateLocal(0, 0, -1) moves it forward by one
+func (t *Transform) TranslateLocal(x, y, z float32) {
+	t.Position = t.Position.Add(t.Rotation.Normalize().Rotate(mgl32.Vec3{x, y, z}))
+}
+
+func (t *Transform) SetScale(x, y, z float32) {
+	t.Scale = mgl32.Vec3{x, y, z}
+}
+
+//  --------------------------------------------------
+//  Rotation
+//  --------------------------------------------------
+
+func (t *Transform) SetRotation(q mgl32.Quat) {
+	t.Rotation = q.Normalize()
+}
+
+// Rotate turns the transform about an axis in world space,
+// by an angle in radians
+func (t *Transform) Rotate(axis mgl32.Vec3, angle float32) {
+	t.Rotation = mgl32.QuatRotate(angle, axis.Normalize()).Mul(t.Rotation).Normalize()
+}
+
+// RotateLocal turns the transform about one of its own axes,
+// so RotateLocal(Vec3{0, 1, 0}, a) always yaws it
+func (t *Transform) RotateLocal(axis mgl32.Vec3, angle float32) {
+	t.Rotation = t.Rotation.Mul(mgl32.QuatRotate(angle, axis.Normalize())).Normalize()
+}
+
+// RotateAround orbits the transform around a point in world space,
+// turning it by the same angle so it keeps facing the same way
+// relative to the point
+func (t *Transform) RotateAround(point, axis mgl32.Vec3, angle float32) {
+	q := mgl32.QuatRotate(angle, axis.Normalize())
+	t.Position = point.Add(q.Rotate(t.Position.Sub(point)))
+	t.Rotation = q.Mul(t.Rotation).Normalize()
+}
+
+// LookAt turns the transform so it faces a point, with its up as
+// close to up as it can be. Nothing changes if the point is at
+// the transform's position.
+func (t *Transform) LookAt(target, up mgl32.Vec3) {
+	forward := target.Sub(t.Position)
+	if forward.Len() < 1e-6 {
+		return
+	}
+	t.Rotation = LookRotation(forward, up)
+}
+
+// SetEuler sets the rotation from Euler angles in radians, applied
+// about the Z axis, then Y, then X
+func (t *Transform) SetEuler(x, y, z float32) {
+	t.Rotation = QuatFromEuler(x, y, z)
+}
+
+// Euler returns the rotation as Euler angles in radians, see SetEuler
+func (t *Transform) Euler() (float32, float32, float32) {
+	return EulerFromQuat(t.Rotation)
+}
+
+//  --------------------------------------------------
+//  Directions
+//  --------------------------------------------------
+
+// Forward returns the direction the transform faces, in world space
+func (t *Transform) Forward() mgl32.Vec3 {
+	return t.Rotation.Normalize().Rotate(mgl32.Vec3{0, 0, -1})
+}
+
+// Right returns the transform's right, in world space
+func (t *Transform) Right() mgl32.Vec3 {
+	return t.Rotation.Normalize().Rotate(mgl32.Vec3{1, 0, 0})
+}
+
+// Up returns the transform's up, in world space
+func (t *Transform) Up() mgl32.Vec3 {
+	return t.Rotation.Normalize().Rotate(mgl32.Vec3{0, 1, 0})
+}
+
+//  --------------------------------------------------
+//  Conversions
+//  --------------------------------------------------
+
+// QuatFromEuler returns the rotation of Euler angles in radians,
+// applied about the Z axis, then Y, then X. This is the order
+// children were rotated in before they had a Transform.
+func QuatFromEuler(x, y, z float32) mgl32.Quat {
+	qx := mgl32.QuatRotate(x, mgl32.Vec3{1, 0, 0})
+	qy := mgl32.QuatRotate(y, mgl32.Vec3{0, 1, 0})
+	qz := mgl32.QuatRotate(z, mgl32.Vec3{0, 0, 1})
+	return qx.Mul(qy).Mul(qz)
+}
+
+// EulerFromQuat returns the Euler angles of a rotation, see
+// QuatFromEuler. When Y is a quarter turn, X and Z turn about the
+// same axis, so all of the turn is returned in X.
+func EulerFromQuat(q mgl32.Quat) (float32, float32, float32) {
+	m := q.Normalize().Mat4()
+
+	sy := float64(m.At(0, 2))
+	if sy > 1 {
+		sy = 1
+	} else if sy < -1 {
+		sy = -1
+	}
+	y := math.Asin(sy)
+
+	if math.Abs(sy) > 0.99999 {
+		x := math.Atan2(float64(m.At(2, 1)), float64(m.At(1, 1)))
+		return float32(x), float32(y), 0
+	}
+
+	x := math.Atan2(float64(-m.At(1, 2)), float64(m.At(2, 2)))
+	z := math.Atan2(float64(-m.At(0, 1)), float64(m.At(0, 0)))
+	return float32(x), float32(y), float32(z)
+}
+
+// LookRotation returns the rotation that turns -Z to face forward,
+// with +Y as close to up as it can be
+func LookRotation(forward, up mgl32.Vec3) mgl32.Quat {
+	f := forward.Normalize()
+
+	// Any up will do when looking straight along it
+	r := f.Cross(up)
+	if r.Len() < 1e-6 {
+		r = f.Cross(mgl32.Vec3{0, 0, 1})
+		if r.Len() < 1e-6 {
+			r = f.Cross(mgl32.Vec3{1, 0, 0})
+		}
+	}
+	r = r.Normalize()
+	u := r.Cross(f)
+
+	return mgl32.Mat4ToQuat(mgl32.Mat3FromCols(r, u, f.Mul(-1)).Mat4()).Normalize()
 }
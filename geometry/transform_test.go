@@ -0,0 +1,95 @@
+package geometry
+
+import (
+	"math"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+func nearVec(a, b mgl32.Vec3) bool {
+	return a.Sub(b).Len() < 1e-4
+}
+
+func TestEulerRoundTrip(t *testing.T) {
+	for _, angles := range [][3]float32{
+		{0, 0, 0},
+		{0.1, 0.5, 0},
+		{-1, 0.3, 2},
+		{3, -1.2, -0.7},
+		{0, 0, math.Pi / 2},
+	} {
+		var tf Transform
+		tf.SetEuler(angles[0], angles[1], angles[2])
+		x, y, z := tf.Euler()
+
+		// The angles may come back different, but must rotate the same
+		back := QuatFromEuler(x, y, z)
+		if math.Abs(float64(back.Dot(tf.Rotation))) < 1-1e-4 {
+			t.Errorf("%v came back as %v, %v, %v", angles, x, y, z)
+		}
+		if math.Abs(float64(angles[1])) < math.Pi/2 && !nearVec(mgl32.Vec3{x, y, z}, mgl32.Vec3(angles)) {
+			t.Errorf("%v came back as %v, %v, %v", angles, x, y, z)
+		}
+	}
+
+	// At a quarter turn about Y, the whole turn is returned in X
+	var tf Transform
+	tf.SetEuler(0.4, math.Pi/2, 0)
+	if x, y, z := tf.Euler(); !nearVec(mgl32.Vec3{x, y, z}, mgl32.Vec3{0.4, math.Pi / 2, 0}) {
+		t.Errorf("gimbal lock came back as %v, %v, %v", x, y, z)
+	}
+}
+
+func TestDirections(t *testing.T) {
+	tf := NewTransform(0, 0, 0, 1, 1, 1)
+	if !nearVec(tf.Forward(), mgl32.Vec3{0, 0, -1}) || !nearVec(tf.Right(), mgl32.Vec3{1, 0, 0}) || !nearVec(tf.Up(), mgl32.Vec3{0, 1, 0}) {
+		t.Errorf("unrotated: forward %v, right %v, up %v", tf.Forward(), tf.Right(), tf.Up())
+	}
+
+	// A quarter turn left about Y faces -X
+	tf.Rotate(mgl32.Vec3{0, 1, 0}, math.Pi/2)
+	if !nearVec(tf.Forward(), mgl32.Vec3{-1, 0, 0}) || !nearVec(tf.Right(), mgl32.Vec3{0, 0, -1}) || !nearVec(tf.Up(), mgl32.Vec3{0, 1, 0}) {
+		t.Errorf("turned: forward %v, right %v, up %v", tf.Forward(), tf.Right(), tf.Up())
+	}
+
+	// Directions match the columns of the model matrix
+	tf.RotateLocal(mgl32.Vec3{1, 0, 0}, 0.3)
+	m := tf.Matrix()
+	if !nearVec(tf.Right(), m.Col(0).Vec3()) || !nearVec(tf.Up(), m.Col(1).Vec3()) || !nearVec(tf.Forward(), m.Col(2).Vec3().Mul(-1)) {
+		t.Errorf("directions %v, %v, %v don't match matrix %v", tf.Right(), tf.Up(), tf.Forward(), m)
+	}
+}
+
+func TestLookAt(t *testing.T) {
+	up := mgl32.Vec3{0, 1, 0}
+	for _, target := range []mgl32.Vec3{
+		{0, 0, -5},
+		{5, 0, 0},
+		{1, 2, 3},
+		{0, 10, 0}, // straight up
+		{0, -10, 0},
+	} {
+		tf := NewTransform(0, 0, 0, 1, 1, 1)
+		tf.LookAt(target, up)
+
+		if !nearVec(tf.Forward(), target.Normalize()) {
+			t.Errorf("looking at %v faces %v", target, tf.Forward())
+		}
+		if math.Abs(float64(tf.Right().Dot(up))) > 1e-4 {
+			t.Errorf("looking at %v rolled, right is %v", target, tf.Right())
+		}
+		if tf.Up().Dot(up) < -1e-4 {
+			t.Errorf("looking at %v upside down, up is %v", target, tf.Up())
+		}
+	}
+
+	// Looking at its own position changes nothing
+	tf := NewTransform(1, 2, 3, 1, 1, 1)
+	tf.SetEuler(0.2, 0.4, 0)
+	before := tf.Rotation
+	tf.LookAt(mgl32.Vec3{1, 2, 3}, up)
+	if tf.Rotation != before {
+		t.Errorf("looking at itself turned it to %v", tf.Rotation)
+	}
+}
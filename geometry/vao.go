@@ -2,6 +2,8 @@ package geometry
 
 import (
 	"github.com/go-gl/gl/v4.1-core/gl"
+
+	"rapidengine/backend"
 )
 
 type VertexArray struct {
@@ -11,6 +13,13 @@ type VertexArray struct {
 
 	vertices []float32
 	indices  []uint32
+
+	// Bounds of the vertices
+	bounds AABB
+	sphere Sphere
+
+	// Whether the instance matrix attributes are enabled
+	instanceAttribs bool
 }
 
 func NewVertexArray(vertices []float32, elements []uint32) *VertexArray {
@@ -20,65 +29,72 @@ func NewVertexArray(vertices []float32, elements []uint32) *VertexArray {
 		vertices: vertices,
 		indices:  elements,
 	}
-	gl.GenVertexArrays(1, &vertexArray.id)
-	gl.BindVertexArray(vertexArray.id)
+	vertexArray.id = backend.Current.GenVertexArray()
+	backend.Current.BindVertexArray(vertexArray.id)
 	vertexArray.vertexBuffer = vertexArray.AddVertexAttribute(vertices, 0, 3)
 	vertexArray.elementBuffer = vertexArray.AddElementAttribute(elements)
+	vertexArray.bounds, vertexArray.sphere = ComputeBounds(vertices)
 	return &vertexArray
 }
 
 func (vertexArray *VertexArray) AddVertexAttribute(data []float32, index, size int32) uint32 {
-	gl.BindVertexArray(vertexArray.id)
+	backend.Current.BindVertexArray(vertexArray.id)
 	vbo := NewVertexBuffer(data)
-	gl.VertexAttribPointer(
+	backend.Current.VertexAttribPointer(
 		uint32(index),
 		size,
 		gl.FLOAT,
 		false,
 		0,
-		gl.PtrOffset(0),
+		0,
 	)
 	return vbo
 }
 
 func (vertexArray *VertexArray) AddElementAttribute(data []uint32) uint32 {
-	gl.BindVertexArray(vertexArray.id)
+	backend.Current.BindVertexArray(vertexArray.id)
 	veo := NewElementBuffer(data)
 	return veo
 }
 
 func NewVertexBuffer(points []float32) uint32 {
-	var vertexBufferID uint32
-	gl.GenBuffers(1, &vertexBufferID)
-	gl.BindBuffer(gl.ARRAY_BUFFER, vertexBufferID)
-	gl.BufferData(gl.ARRAY_BUFFER, 4*len(points), gl.Ptr(points), gl.STATIC_DRAW)
+	vertexBufferID := backend.Current.GenBuffer()
+	backend.Current.BindBuffer(gl.ARRAY_BUFFER, vertexBufferID)
+	backend.Current.BufferFloats(gl.ARRAY_BUFFER, points, gl.STATIC_DRAW)
 	return vertexBufferID
 }
 
 func NewElementBuffer(indices []uint32) uint32 {
-	var elementBufferID uint32
-	gl.GenBuffers(1, &elementBufferID)
-	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, elementBufferID)
-	gl.BufferData(gl.ELEMENT_ARRAY_BUFFER, 4*len(indices), gl.Ptr(indices), gl.STATIC_DRAW)
+	elementBufferID := backend.Current.GenBuffer()
+	backend.Current.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, elementBufferID)
+	backend.Current.BufferUints(gl.ELEMENT_ARRAY_BUFFER, indices, gl.STATIC_DRAW)
 	return elementBufferID
 }
 
 func (vertexArray *VertexArray) RebindVertexArray() {
-	gl.BindVertexArray(vertexArray.id)
-	gl.BindBuffer(gl.ARRAY_BUFFER, vertexArray.vertexBuffer)
-	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, vertexArray.elementBuffer)
+	backend.Current.BindVertexArray(vertexArray.id)
+	backend.Current.BindBuffer(gl.ARRAY_BUFFER, vertexArray.vertexBuffer)
+	backend.Current.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, vertexArray.elementBuffer)
 }
 
 func UnbindBuffers() {
-	gl.BindVertexArray(0)
-	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
-	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, 0)
+	backend.Current.BindVertexArray(0)
+	backend.Current.BindBuffer(gl.ARRAY_BUFFER, 0)
+	backend.Current.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, 0)
 }
 
 func (vertexArray *VertexArray) GetID() uint32 {
 	return vertexArray.id
 }
 
+func (vertexArray *VertexArray) GetVertices() []float32 {
+	return vertexArray.vertices
+}
+
 func (vertexArray *VertexArray) GetIndices() []uint32 {
 	return vertexArray.indices
 }
+
+func (vertexArray *VertexArray) GetBounds() (AABB, Sphere) {
+	return vertexArray.bounds, vertexArray.sphere
+}
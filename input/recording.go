@@ -0,0 +1,297 @@
+package input
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+
+	"github.com/go-gl/glfw/v3.2/glfw"
+)
+
+//  --------------------------------------------------
+//  Recording.go reads and writes input recordings. A
+//  recording is a gzipped stream of frames, each holding
+//  the frame's delta time and the Input of every fixed
+//  step run during it. Keys are stored as a bitmask over
+//  the key names listed in the header.
+//  --------------------------------------------------
+
+// RecordingVersion is the version of the recording format written
+const RecordingVersion = 1
+
+var recordingMagic = [4]byte{'R', 'I', 'N', 'P'}
+
+// ErrBadRecording is returned when a file isn't an input recording
+var ErrBadRecording = errors.New("not an input recording")
+
+// Frame is the input of a single rendered frame
+type Frame struct {
+	Delta float64
+	Steps []Input
+}
+
+// Recording is a whole decoded recording
+type Recording struct {
+	Keys   []string
+	Frames []Frame
+}
+
+// KeyNames returns the names in a key map, sorted
+func KeyNames(keyMap map[string]glfw.Key) []string {
+	names := []string{}
+	for name := range keyMap {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+//  --------------------------------------------------
+//  Recorder
+//  --------------------------------------------------
+
+// Recorder writes frames to a recording as they happen
+type Recorder struct {
+	keys []string
+
+	// Frame being recorded, written when the next one begins
+	frame   Frame
+	started bool
+	frames  int
+
+	gz  *gzip.Writer
+	buf *bufio.Writer
+}
+
+// recorderFlushRate is the number of frames between flushes,
+// so little is lost if the game crashes while recording
+const recorderFlushRate = 60
+
+// NewRecorder writes the header of a recording of the given keys to w
+func NewRecorder(w io.Writer, keys []string) (*Recorder, error) {
+	if len(keys) > math.MaxUint16 {
+		return nil, fmt.Errorf("too many keys to record: %v", len(keys))
+	}
+	for _, key := range keys {
+		if len(key) > math.MaxUint8 {
+			return nil, fmt.Errorf("key name too long to record: %v", key)
+		}
+	}
+
+	gz := gzip.NewWriter(w)
+	r := &Recorder{
+		keys: keys,
+		gz:   gz,
+		buf:  bufio.NewWriter(gz),
+	}
+
+	r.buf.Write(recordingMagic[:])
+	binary.Write(r.buf, binary.LittleEndian, uint16(RecordingVersion))
+	binary.Write(r.buf, binary.LittleEndian, uint16(len(keys)))
+	for _, key := range keys {
+		r.buf.WriteByte(byte(len(key)))
+		r.buf.WriteString(key)
+	}
+
+	return r, nil
+}
+
+// BeginFrame writes the previous frame and starts a new one
+func (r *Recorder) BeginFrame(delta float64) error {
+	if err := r.writeFrame(); err != nil {
+		return err
+	}
+
+	r.frame = Frame{Delta: delta}
+	r.started = true
+	return nil
+}
+
+// RecordStep adds the input of a fixed step to the current frame
+func (r *Recorder) RecordStep(in *Input) {
+	r.frame.Steps = append(r.frame.Steps, *in)
+}
+
+// Close writes the last frame and finishes the recording.
+// It doesn't close the underlying writer.
+func (r *Recorder) Close() error {
+	if err := r.writeFrame(); err != nil {
+		return err
+	}
+	if err := r.buf.Flush(); err != nil {
+		return err
+	}
+	return r.gz.Close()
+}
+
+// Frames returns the number of frames written so far
+func (r *Recorder) Frames() int {
+	return r.frames
+}
+
+func (r *Recorder) writeFrame() error {
+	if !r.started {
+		return nil
+	}
+	r.started = false
+
+	if len(r.frame.Steps) > math.MaxUint8 {
+		return fmt.Errorf("too many steps in frame %v: %v", r.frames, len(r.frame.Steps))
+	}
+
+	w := r.buf
+	writeFloat(w, r.frame.Delta)
+	w.WriteByte(byte(len(r.frame.Steps)))
+
+	mask := make([]byte, (len(r.keys)+7)/8)
+	for _, step := range r.frame.Steps {
+		for i := range mask {
+			mask[i] = 0
+		}
+		for i, key := range r.keys {
+			if step.Keys[key] {
+				mask[i/8] |= 1 << uint(i%8)
+			}
+		}
+		w.Write(mask)
+
+		for _, f := range []float64{step.MouseX, step.MouseY, step.LastMouseX, step.LastMouseY, step.ScrollX, step.ScrollY, step.Scroll} {
+			writeFloat(w, f)
+		}
+		w.WriteByte(packButtons(step.LeftMouseButton, step.RightMouseButton, step.MiddleMouseButton))
+	}
+
+	r.frames++
+	if r.frames%recorderFlushRate == 0 {
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		return r.gz.Flush()
+	}
+	return nil
+}
+
+func writeFloat(w io.Writer, f float64) {
+	binary.Write(w, binary.LittleEndian, math.Float64bits(f))
+}
+
+func packButtons(buttons ...bool) byte {
+	b := byte(0)
+	for i, pressed := range buttons {
+		if pressed {
+			b |= 1 << uint(i)
+		}
+	}
+	return b
+}
+
+//  --------------------------------------------------
+//  Reading
+//  --------------------------------------------------
+
+// ReadRecording decodes a whole recording. If the recording was cut
+// off, e.g. by a crash, the complete frames before the cut are returned
+// along with io.ErrUnexpectedEOF.
+func ReadRecording(r io.Reader) (*Recording, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, ErrBadRecording
+	}
+	in := bufio.NewReader(gz)
+
+	var magic [4]byte
+	if _, err := io.ReadFull(in, magic[:]); err != nil || magic != recordingMagic {
+		return nil, ErrBadRecording
+	}
+
+	var version, numKeys uint16
+	binary.Read(in, binary.LittleEndian, &version)
+	if version != RecordingVersion {
+		return nil, fmt.Errorf("unsupported input recording version %v", version)
+	}
+	if err := binary.Read(in, binary.LittleEndian, &numKeys); err != nil {
+		return nil, ErrBadRecording
+	}
+
+	rec := &Recording{}
+	for i := 0; i < int(numKeys); i++ {
+		n, err := in.ReadByte()
+		if err != nil {
+			return nil, ErrBadRecording
+		}
+		key := make([]byte, n)
+		if _, err := io.ReadFull(in, key); err != nil {
+			return nil, ErrBadRecording
+		}
+		rec.Keys = append(rec.Keys, string(key))
+	}
+
+	for {
+		frame, err := readFrame(in, rec.Keys)
+		if err == io.EOF {
+			return rec, nil
+		}
+		if err != nil {
+			return rec, io.ErrUnexpectedEOF
+		}
+		rec.Frames = append(rec.Frames, frame)
+	}
+}
+
+func readFrame(in *bufio.Reader, keys []string) (Frame, error) {
+	frame := Frame{}
+
+	delta, err := readFloat(in)
+	if err != nil {
+		return frame, err
+	}
+	frame.Delta = delta
+
+	numSteps, err := in.ReadByte()
+	if err != nil {
+		return frame, io.ErrUnexpectedEOF
+	}
+
+	mask := make([]byte, (len(keys)+7)/8)
+	for s := 0; s < int(numSteps); s++ {
+		if _, err := io.ReadFull(in, mask); err != nil {
+			return frame, io.ErrUnexpectedEOF
+		}
+
+		step := Input{Keys: make(map[string]bool, len(keys))}
+		for i, key := range keys {
+			step.Keys[key] = mask[i/8]&(1<<uint(i%8)) != 0
+		}
+
+		for _, f := range []*float64{&step.MouseX, &step.MouseY, &step.LastMouseX, &step.LastMouseY, &step.ScrollX, &step.ScrollY, &step.Scroll} {
+			if *f, err = readFloat(in); err != nil {
+				return frame, io.ErrUnexpectedEOF
+			}
+		}
+
+		buttons, err := in.ReadByte()
+		if err != nil {
+			return frame, io.ErrUnexpectedEOF
+		}
+		step.LeftMouseButton = buttons&1 != 0
+		step.RightMouseButton = buttons&2 != 0
+		step.MiddleMouseButton = buttons&4 != 0
+
+		frame.Steps = append(frame.Steps, step)
+	}
+
+	return frame, nil
+}
+
+func readFloat(in io.Reader) (float64, error) {
+	var bits uint64
+	if err := binary.Read(in, binary.LittleEndian, &bits); err != nil {
+		return 0, err
+	}
+	return math.Float64frombits(bits), nil
+}
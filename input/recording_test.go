@@ -0,0 +1,96 @@
+package input
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func testRecording() *Recording {
+	keys := []string{"a", "space", "w"}
+	step := func(a, space, w bool, x, y float64) Input {
+		return Input{
+			Keys:   map[string]bool{"a": a, "space": space, "w": w},
+			MouseX: x, MouseY: y,
+			LastMouseX: x - 1, LastMouseY: y - 2,
+			LeftMouseButton: a, MiddleMouseButton: w,
+			ScrollY: y / 10, Scroll: x / 10,
+		}
+	}
+
+	return &Recording{
+		Keys: keys,
+		Frames: []Frame{
+			{Delta: 1.0 / 60, Steps: []Input{step(true, false, false, 10, 20)}},
+			{Delta: 0.034, Steps: []Input{step(false, true, false, 11, 21), step(true, true, true, 12, 22)}},
+
+			// A frame too short to run a step
+			{Delta: 0.004},
+			{Delta: 1.0 / 30, Steps: []Input{step(false, false, true, -5, 0.5)}},
+		},
+	}
+}
+
+func writeRecording(t *testing.T, rec *Recording) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	r, err := NewRecorder(&buf, rec.Keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, frame := range rec.Frames {
+		if err := r.BeginFrame(frame.Delta); err != nil {
+			t.Fatal(err)
+		}
+		for i := range frame.Steps {
+			r.RecordStep(&frame.Steps[i])
+		}
+	}
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if r.Frames() != len(rec.Frames) {
+		t.Errorf("wrote %v frames, want %v", r.Frames(), len(rec.Frames))
+	}
+	return buf.Bytes()
+}
+
+func TestRecordingRoundTrip(t *testing.T) {
+	want := testRecording()
+
+	got, err := ReadRecording(bytes.NewReader(writeRecording(t, want)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("replayed\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestTruncatedRecording(t *testing.T) {
+	want := testRecording()
+	for len(want.Frames) < 500 {
+		want.Frames = append(want.Frames, want.Frames[:4]...)
+	}
+	data := writeRecording(t, want)
+
+	got, err := ReadRecording(bytes.NewReader(data[:len(data)/2]))
+	if err != io.ErrUnexpectedEOF {
+		t.Fatalf("got %v, want io.ErrUnexpectedEOF", err)
+	}
+	if len(got.Frames) == 0 || len(got.Frames) >= len(want.Frames) {
+		t.Fatalf("read %v of %v frames", len(got.Frames), len(want.Frames))
+	}
+	if !reflect.DeepEqual(got.Frames, want.Frames[:len(got.Frames)]) {
+		t.Error("frames before the cut don't match")
+	}
+}
+
+func TestBadRecording(t *testing.T) {
+	if _, err := ReadRecording(bytes.NewReader([]byte("not gzip"))); !errors.Is(err, ErrBadRecording) {
+		t.Errorf("got %v, want ErrBadRecording", err)
+	}
+}
@@ -2,9 +2,8 @@ package lighting
 
 import (
 	"fmt"
+	"rapidengine/backend"
 	"rapidengine/material"
-
-	"github.com/go-gl/gl/v4.1-core/gl"
 )
 
 type PointLight struct {
@@ -37,43 +36,43 @@ func (light *PointLight) UpdateShader(cx, cy, cz float32, ind int, shader *mater
 	c := []float32{cx, cy, cz}
 	shader.Bind()
 
-	gl.Uniform3fv(
-		gl.GetUniformLocation(shader.GetID(), gl.Str("pointLights["+fmt.Sprint(ind)+"].ambient"+"\x00")),
+	backend.Current.Uniform3fv(
+		backend.Current.GetUniformLocation(shader.GetID(), "pointLights["+fmt.Sprint(ind)+"].ambient"),
 		1, &light.Ambient[0],
 	)
 
-	gl.Uniform3fv(
-		gl.GetUniformLocation(shader.GetID(), gl.Str("pointLights["+fmt.Sprint(ind)+"].diffuse"+"\x00")),
+	backend.Current.Uniform3fv(
+		backend.Current.GetUniformLocation(shader.GetID(), "pointLights["+fmt.Sprint(ind)+"].diffuse"),
 		1, &light.Diffuse[0],
 	)
 
-	gl.Uniform3fv(
-		gl.GetUniformLocation(shader.GetID(), gl.Str("pointLights["+fmt.Sprint(ind)+"].specular"+"\x00")),
+	backend.Current.Uniform3fv(
+		backend.Current.GetUniformLocation(shader.GetID(), "pointLights["+fmt.Sprint(ind)+"].specular"),
 		1, &light.specular[0],
 	)
 
-	gl.Uniform1f(
-		gl.GetUniformLocation(shader.GetID(), gl.Str("pointLights["+fmt.Sprint(ind)+"].constant"+"\x00")),
+	backend.Current.Uniform1f(
+		backend.Current.GetUniformLocation(shader.GetID(), "pointLights["+fmt.Sprint(ind)+"].constant"),
 		light.constant,
 	)
 
-	gl.Uniform1f(
-		gl.GetUniformLocation(shader.GetID(), gl.Str("pointLights["+fmt.Sprint(ind)+"].linear"+"\x00")),
+	backend.Current.Uniform1f(
+		backend.Current.GetUniformLocation(shader.GetID(), "pointLights["+fmt.Sprint(ind)+"].linear"),
 		light.linear,
 	)
 
-	gl.Uniform1f(
-		gl.GetUniformLocation(shader.GetID(), gl.Str("pointLights["+fmt.Sprint(ind)+"].quadratic"+"\x00")),
+	backend.Current.Uniform1f(
+		backend.Current.GetUniformLocation(shader.GetID(), "pointLights["+fmt.Sprint(ind)+"].quadratic"),
 		light.quadratic,
 	)
 
-	gl.Uniform3fv(
-		gl.GetUniformLocation(shader.GetID(), gl.Str("pointLights["+fmt.Sprint(ind)+"].position"+"\x00")),
+	backend.Current.Uniform3fv(
+		backend.Current.GetUniformLocation(shader.GetID(), "pointLights["+fmt.Sprint(ind)+"].position"),
 		1, &light.Position[0],
 	)
 
-	gl.Uniform3fv(
-		gl.GetUniformLocation(shader.GetID(), gl.Str("viewPos"+"\x00")),
+	backend.Current.Uniform3fv(
+		backend.Current.GetUniformLocation(shader.GetID(), "viewPos"),
 		1, &c[0],
 	)
 }
@@ -1,7 +1,8 @@
 package material
 
 import (
-	"rapidengine/state"
+	"rapidengine/backend"
+	"rapidengine/events"
 
 	"github.com/go-gl/gl/v4.1-core/gl"
 )
@@ -21,6 +22,11 @@ type BasicMaterial struct {
 
 	Flipped int
 
+	// Columns and rows of tiles in DiffuseMap, if it's a texture
+	// atlas. Instanced copies pick their tile with AtlasIndex.
+	AtlasColumns int
+	AtlasRows    int
+
 	ScatterLevel float32
 
 	Blending bool
@@ -36,6 +42,16 @@ type BasicMaterial struct {
 	animationPlayingOnce  bool
 	animationOnceCallback func()
 	animationHitCallback  func()
+
+	// Receives AnimationFinished events
+	Events *events.Bus `json:"-"`
+}
+
+// AnimationFinished is published when an animation
+// played with PlayAnimationOnce reaches its last frame
+type AnimationFinished struct {
+	Material  *BasicMaterial
+	Animation string
 }
 
 func NewBasicMaterial(Shader *ShaderProgram) *BasicMaterial {
@@ -58,39 +74,40 @@ func NewBasicMaterial(Shader *ShaderProgram) *BasicMaterial {
 func (bm *BasicMaterial) Render(delta float64, darkness float32, totalTime float64) {
 	bm.UpdateAnimation(delta)
 
-	if bm.DiffuseMap != nil && state.BoundTexture0 != *bm.DiffuseMap.Addr {
-		gl.ActiveTexture(gl.TEXTURE0)
-		gl.BindTexture(gl.TEXTURE_2D, *bm.DiffuseMap.Addr)
-		state.BoundTexture0 = *bm.DiffuseMap.Addr
+	if bm.DiffuseMap != nil {
+		backend.Current.ActiveTexture(gl.TEXTURE0)
+		backend.Current.BindTexture(gl.TEXTURE_2D, *bm.DiffuseMap.Addr)
 	}
 
-	if bm.AlphaMap != nil && state.BoundTexture1 != *bm.AlphaMap.Addr {
-		gl.ActiveTexture(gl.TEXTURE1)
-		gl.BindTexture(gl.TEXTURE_2D, *bm.AlphaMap.Addr)
-		state.BoundTexture1 = *bm.AlphaMap.Addr
+	if bm.AlphaMap != nil {
+		backend.Current.ActiveTexture(gl.TEXTURE1)
+		backend.Current.BindTexture(gl.TEXTURE_2D, *bm.AlphaMap.Addr)
 	}
 
-	gl.Uniform1f(bm.Shader.GetUniform("diffuseLevel"), bm.DiffuseLevel)
+	backend.Current.Uniform1f(bm.Shader.GetUniform("diffuseLevel"), bm.DiffuseLevel)
+
+	backend.Current.Uniform4fv(bm.Shader.GetUniform("hue"), 1, &bm.Hue[0])
 
-	gl.Uniform4fv(bm.Shader.GetUniform("hue"), 1, &bm.Hue[0])
+	backend.Current.Uniform1i(bm.Shader.GetUniform("diffuseMap"), 0)
+	backend.Current.Uniform1f(bm.Shader.GetUniform("scale"), bm.DiffuseMapScale)
 
-	gl.Uniform1i(bm.Shader.GetUniform("diffuseMap"), 0)
-	gl.Uniform1f(bm.Shader.GetUniform("scale"), bm.DiffuseMapScale)
+	backend.Current.Uniform1f(bm.Shader.GetUniform("alphaMapLevel"), bm.AlphaMapLevel)
+	backend.Current.Uniform1i(bm.Shader.GetUniform("alphaMap"), 1)
 
-	gl.Uniform1f(bm.Shader.GetUniform("alphaMapLevel"), bm.AlphaMapLevel)
-	gl.Uniform1i(bm.Shader.GetUniform("alphaMap"), 1)
+	backend.Current.Uniform1f(bm.Shader.GetUniform("darkness"), darkness)
 
-	gl.Uniform1f(bm.Shader.GetUniform("darkness"), darkness)
+	backend.Current.Uniform1f(bm.Shader.GetUniform("scatterLevel"), bm.ScatterLevel)
 
-	gl.Uniform1f(bm.Shader.GetUniform("scatterLevel"), bm.ScatterLevel)
+	backend.Current.Uniform1i(bm.Shader.GetUniform("flipped"), int32(bm.Flipped))
 
-	gl.Uniform1i(bm.Shader.GetUniform("flipped"), int32(bm.Flipped))
+	atlas := [2]float32{float32(bm.AtlasColumns), float32(bm.AtlasRows)}
+	backend.Current.Uniform2fv(bm.Shader.GetUniform("atlasSize"), 1, &atlas[0])
 
 	if bm.Blending {
-		gl.Enable(gl.BLEND)
-		gl.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
+		backend.Current.Enable(gl.BLEND)
+		backend.Current.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
 	} else {
-		gl.Disable(gl.BLEND)
+		backend.Current.Disable(gl.BLEND)
 	}
 }
 
@@ -98,6 +115,40 @@ func (bm *BasicMaterial) GetShader() *ShaderProgram {
 	return bm.Shader
 }
 
+func (bm *BasicMaterial) IsTransparent() bool {
+	return bm.Blending
+}
+
+func (bm *BasicMaterial) MainTexture() uint32 {
+	if bm.DiffuseMap == nil {
+		return 0
+	}
+	return *bm.DiffuseMap.Addr
+}
+
+// SpriteParams returns how the sprite batcher draws the material. The
+// batcher doesn't mix the hue with the diffuse map, so materials with
+// any DiffuseLevel are drawn fully textured, and ScatterLevel and
+// AlphaMap are ignored.
+func (bm *BasicMaterial) SpriteParams(delta float64, atlasIndex int) (uint32, [4]float32, [4]float32) {
+	bm.UpdateAnimation(delta)
+
+	uv := [4]float32{0, 0, 1, 1}
+	if bm.AtlasColumns > 0 && bm.AtlasRows > 0 {
+		w, h := 1/float32(bm.AtlasColumns), 1/float32(bm.AtlasRows)
+		col, row := atlasIndex%bm.AtlasColumns, atlasIndex/bm.AtlasColumns
+		uv = [4]float32{float32(col) * w, float32(row) * h, float32(col+1) * w, float32(row+1) * h}
+	}
+	if bm.Flipped != 0 {
+		uv[0], uv[2] = uv[2], uv[0]
+	}
+
+	if bm.DiffuseLevel > 0 && bm.DiffuseMap != nil {
+		return *bm.DiffuseMap.Addr, [4]float32{1, 1, 1, 1}, uv
+	}
+	return 0, [4]float32{bm.Hue[0] / 255, bm.Hue[1] / 255, bm.Hue[2] / 255, bm.Hue[3] / 255}, uv
+}
+
 func (bm *BasicMaterial) UpdateAnimation(delta float64) {
 	if bm.animationEnabled && bm.animationPlaying != "" {
 		if bm.animationFrame > 1/bm.animationFPS[bm.animationPlaying] {
@@ -106,12 +157,14 @@ func (bm *BasicMaterial) UpdateAnimation(delta float64) {
 			} else {
 
 				if bm.animationPlayingOnce {
+					finished := bm.animationPlaying
 					bm.animationPlaying = ""
 					bm.animationPlayingOnce = false
 					if bm.animationOnceCallback != nil {
 						bm.animationOnceCallback()
 						bm.animationOnceCallback = nil
 					}
+					bm.Events.Publish(AnimationFinished{Material: bm, Animation: finished})
 					return
 				}
 				bm.animationCurrent = 0
@@ -120,7 +173,7 @@ func (bm *BasicMaterial) UpdateAnimation(delta float64) {
 			bm.animationFrame = 0
 			bm.DiffuseMap = bm.animationTextures[bm.animationPlaying][bm.animationCurrent]
 
-			if bm.animationHitframes[bm.animationPlaying][bm.animationCurrent] {
+			if bm.animationHitframes[bm.animationPlaying][bm.animationCurrent] && bm.animationHitCallback != nil {
 				bm.animationHitCallback()
 			}
 
@@ -1,6 +1,10 @@
 package material
 
-import "github.com/go-gl/gl/v4.1-core/gl"
+import (
+	"github.com/go-gl/gl/v4.1-core/gl"
+
+	"rapidengine/backend"
+)
 
 type CubemapMaterial struct {
 	shader *ShaderProgram
@@ -16,17 +20,17 @@ func NewCubemapMaterial(shader *ShaderProgram) *CubemapMaterial {
 
 func (cm *CubemapMaterial) Render(delta float64, darkness float32, totalTime float64) {
 	if cm.CubeDiffuseMap != nil {
-		gl.ActiveTexture(gl.TEXTURE6)
-		gl.BindTexture(gl.TEXTURE_CUBE_MAP, *cm.CubeDiffuseMap)
+		backend.Current.ActiveTexture(gl.TEXTURE6)
+		backend.Current.BindTexture(gl.TEXTURE_CUBE_MAP, *cm.CubeDiffuseMap)
 
-		gl.Uniform1i(cm.GetShader().GetUniform("cubeDiffuseMap"), 6)
+		backend.Current.Uniform1i(cm.GetShader().GetUniform("cubeDiffuseMap"), 6)
 	}
 }
 
 func (cm *CubemapMaterial) UpdateAttribArrays() {
-	gl.EnableVertexAttribArray(0)
-	gl.EnableVertexAttribArray(1)
-	gl.EnableVertexAttribArray(2)
+	backend.Current.EnableVertexAttribArray(0)
+	backend.Current.EnableVertexAttribArray(1)
+	backend.Current.EnableVertexAttribArray(2)
 }
 
 func (cm *CubemapMaterial) GetShader() *ShaderProgram {
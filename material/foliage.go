@@ -1,6 +1,10 @@
 package material
 
-import "github.com/go-gl/gl/v4.1-core/gl"
+import (
+	"github.com/go-gl/gl/v4.1-core/gl"
+
+	"rapidengine/backend"
+)
 
 type FoliageMaterial struct {
 	shader *ShaderProgram
@@ -59,74 +63,74 @@ func (fm *FoliageMaterial) Render(delta float64, darkness float32, totalTime flo
 	//   --------------------------------------------------
 
 	if fm.DiffuseMap != nil {
-		gl.ActiveTexture(gl.TEXTURE0)
-		gl.BindTexture(gl.TEXTURE_2D, *fm.DiffuseMap.Addr)
+		backend.Current.ActiveTexture(gl.TEXTURE0)
+		backend.Current.BindTexture(gl.TEXTURE_2D, *fm.DiffuseMap.Addr)
 	}
-	gl.Uniform1i(fm.shader.GetUniform("diffuseMap"), 0)
+	backend.Current.Uniform1i(fm.shader.GetUniform("diffuseMap"), 0)
 
 	if fm.NormalMap != nil {
-		gl.ActiveTexture(gl.TEXTURE1)
-		gl.BindTexture(gl.TEXTURE_2D, *fm.NormalMap.Addr)
+		backend.Current.ActiveTexture(gl.TEXTURE1)
+		backend.Current.BindTexture(gl.TEXTURE_2D, *fm.NormalMap.Addr)
 	}
-	gl.Uniform1i(fm.shader.GetUniform("normalMap"), 1)
+	backend.Current.Uniform1i(fm.shader.GetUniform("normalMap"), 1)
 
 	if fm.HeightMap != nil {
-		gl.ActiveTexture(gl.TEXTURE2)
-		gl.BindTexture(gl.TEXTURE_2D, *fm.HeightMap.Addr)
+		backend.Current.ActiveTexture(gl.TEXTURE2)
+		backend.Current.BindTexture(gl.TEXTURE_2D, *fm.HeightMap.Addr)
 	}
-	gl.Uniform1i(fm.shader.GetUniform("heightMap"), 2)
+	backend.Current.Uniform1i(fm.shader.GetUniform("heightMap"), 2)
 
 	if fm.SpecularMap != nil {
-		gl.ActiveTexture(gl.TEXTURE3)
-		gl.BindTexture(gl.TEXTURE_2D, *fm.SpecularMap.Addr)
+		backend.Current.ActiveTexture(gl.TEXTURE3)
+		backend.Current.BindTexture(gl.TEXTURE_2D, *fm.SpecularMap.Addr)
 	}
-	gl.Uniform1i(fm.shader.GetUniform("specularMap"), 3)
+	backend.Current.Uniform1i(fm.shader.GetUniform("specularMap"), 3)
 
-	gl.Uniform1f(fm.shader.GetUniform("diffuseLevel"), fm.DiffuseLevel)
-	gl.Uniform1f(fm.shader.GetUniform("normalLevel"), fm.NormalLevel)
-	gl.Uniform1f(fm.shader.GetUniform("specularLevel"), fm.SpecularLevel)
-	gl.Uniform1f(fm.shader.GetUniform("heightLevel"), fm.HeightLevel)
+	backend.Current.Uniform1f(fm.shader.GetUniform("diffuseLevel"), fm.DiffuseLevel)
+	backend.Current.Uniform1f(fm.shader.GetUniform("normalLevel"), fm.NormalLevel)
+	backend.Current.Uniform1f(fm.shader.GetUniform("specularLevel"), fm.SpecularLevel)
+	backend.Current.Uniform1f(fm.shader.GetUniform("heightLevel"), fm.HeightLevel)
 
-	gl.Uniform4fv(fm.shader.GetUniform("hue"), 1, &fm.Hue[0])
+	backend.Current.Uniform4fv(fm.shader.GetUniform("hue"), 1, &fm.Hue[0])
 
-	gl.Uniform1f(fm.shader.GetUniform("displacement"), fm.Displacement)
-	gl.Uniform1f(fm.shader.GetUniform("scale"), fm.Scale)
+	backend.Current.Uniform1f(fm.shader.GetUniform("displacement"), fm.Displacement)
+	backend.Current.Uniform1f(fm.shader.GetUniform("scale"), fm.Scale)
 
-	gl.Uniform1f(fm.shader.GetUniform("reflectivity"), fm.Reflectivity)
-	gl.Uniform1f(fm.shader.GetUniform("refractivity"), fm.Refractivity)
-	gl.Uniform1f(fm.shader.GetUniform("refractLevel"), fm.RefractLevel)
+	backend.Current.Uniform1f(fm.shader.GetUniform("reflectivity"), fm.Reflectivity)
+	backend.Current.Uniform1f(fm.shader.GetUniform("refractivity"), fm.Refractivity)
+	backend.Current.Uniform1f(fm.shader.GetUniform("refractLevel"), fm.RefractLevel)
 
 	//   --------------------------------------------------
 	//   Foliage Material
 	//   --------------------------------------------------
 
 	if fm.OpacityMap != nil {
-		gl.ActiveTexture(gl.TEXTURE4)
-		gl.BindTexture(gl.TEXTURE_2D, *fm.OpacityMap.Addr)
+		backend.Current.ActiveTexture(gl.TEXTURE4)
+		backend.Current.BindTexture(gl.TEXTURE_2D, *fm.OpacityMap.Addr)
 	}
-	gl.Uniform1i(fm.shader.GetUniform("opacityMap"), 4)
+	backend.Current.Uniform1i(fm.shader.GetUniform("opacityMap"), 4)
 
 	if fm.TerrainHeightMap != nil {
-		gl.ActiveTexture(gl.TEXTURE5)
-		gl.BindTexture(gl.TEXTURE_2D, *fm.TerrainHeightMap.Addr)
+		backend.Current.ActiveTexture(gl.TEXTURE5)
+		backend.Current.BindTexture(gl.TEXTURE_2D, *fm.TerrainHeightMap.Addr)
 	}
-	gl.Uniform1i(fm.shader.GetUniform("terrainHeightMap"), 5)
+	backend.Current.Uniform1i(fm.shader.GetUniform("terrainHeightMap"), 5)
 
 	if fm.TerrainNormalMap != nil {
-		gl.ActiveTexture(gl.TEXTURE6)
-		gl.BindTexture(gl.TEXTURE_2D, *fm.TerrainNormalMap.Addr)
+		backend.Current.ActiveTexture(gl.TEXTURE6)
+		backend.Current.BindTexture(gl.TEXTURE_2D, *fm.TerrainNormalMap.Addr)
 	}
-	gl.Uniform1i(fm.shader.GetUniform("terrainNormalMap"), 6)
+	backend.Current.Uniform1i(fm.shader.GetUniform("terrainNormalMap"), 6)
 
-	gl.Uniform1f(fm.shader.GetUniform("terrainDisplacement"), fm.TerrainDisplacement)
-	gl.Uniform1f(fm.shader.GetUniform("terrainWidth"), fm.TerrainWidth)
-	gl.Uniform1f(fm.shader.GetUniform("terrainLength"), fm.TerrainLength)
+	backend.Current.Uniform1f(fm.shader.GetUniform("terrainDisplacement"), fm.TerrainDisplacement)
+	backend.Current.Uniform1f(fm.shader.GetUniform("terrainWidth"), fm.TerrainWidth)
+	backend.Current.Uniform1f(fm.shader.GetUniform("terrainLength"), fm.TerrainLength)
 
-	gl.Uniform1f(fm.shader.GetUniform("foliageDisplacement"), fm.FoliageDisplacement)
-	gl.Uniform1f(fm.shader.GetUniform("foliageNoiseSeed"), fm.FoliageNoiseSeed)
-	gl.Uniform1f(fm.shader.GetUniform("foliageVariation"), fm.FoliageVariation)
+	backend.Current.Uniform1f(fm.shader.GetUniform("foliageDisplacement"), fm.FoliageDisplacement)
+	backend.Current.Uniform1f(fm.shader.GetUniform("foliageNoiseSeed"), fm.FoliageNoiseSeed)
+	backend.Current.Uniform1f(fm.shader.GetUniform("foliageVariation"), fm.FoliageVariation)
 
-	gl.Uniform1f(fm.shader.GetUniform("totalTime"), float32(totalTime))
+	backend.Current.Uniform1f(fm.shader.GetUniform("totalTime"), float32(totalTime))
 }
 
 func (fm *FoliageMaterial) GetShader() *ShaderProgram {
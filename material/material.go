@@ -5,3 +5,24 @@ type Material interface {
 
 	GetShader() *ShaderProgram
 }
+
+// Transparent is implemented by materials that can be drawn
+// see-through. The render queue draws them after opaque
+// materials, from back to front.
+type Transparent interface {
+	IsTransparent() bool
+}
+
+// Textured is implemented by materials with a main texture. The
+// render queue groups draws that share it, to save texture binds.
+type Textured interface {
+	MainTexture() uint32
+}
+
+// SpriteSource is implemented by materials that can be drawn by the
+// sprite batcher. SpriteParams advances any animation, and returns the
+// texture to draw (0 for none), the colour to multiply it by, and the
+// UV rectangle (u0, v0, u1, v1) of an atlas tile.
+type SpriteSource interface {
+	SpriteParams(delta float64, atlasIndex int) (texture uint32, tint [4]float32, uv [4]float32)
+}
@@ -1,6 +1,10 @@
 package material
 
-import "github.com/go-gl/gl/v4.1-core/gl"
+import (
+	"github.com/go-gl/gl/v4.1-core/gl"
+
+	"rapidengine/backend"
+)
 
 type PBRMaterial struct {
 	shader *ShaderProgram
@@ -44,65 +48,72 @@ func NewPBRMaterial(shader *ShaderProgram) *PBRMaterial {
 func (pm *PBRMaterial) Render(delta float64, darkness float32, totalTime float64) {
 
 	if pm.AlbedoMap != nil {
-		gl.ActiveTexture(gl.TEXTURE0)
-		gl.BindTexture(gl.TEXTURE_2D, *pm.AlbedoMap.Addr)
+		backend.Current.ActiveTexture(gl.TEXTURE0)
+		backend.Current.BindTexture(gl.TEXTURE_2D, *pm.AlbedoMap.Addr)
 	}
-	gl.Uniform1i(pm.shader.GetUniform("albedoMap"), 0)
+	backend.Current.Uniform1i(pm.shader.GetUniform("albedoMap"), 0)
 
 	if pm.NormalMap != nil {
-		gl.ActiveTexture(gl.TEXTURE1)
-		gl.BindTexture(gl.TEXTURE_2D, *pm.NormalMap.Addr)
+		backend.Current.ActiveTexture(gl.TEXTURE1)
+		backend.Current.BindTexture(gl.TEXTURE_2D, *pm.NormalMap.Addr)
 	}
-	gl.Uniform1i(pm.shader.GetUniform("normalMap"), 1)
+	backend.Current.Uniform1i(pm.shader.GetUniform("normalMap"), 1)
 
 	if pm.HeightMap != nil {
-		gl.ActiveTexture(gl.TEXTURE2)
-		gl.BindTexture(gl.TEXTURE_2D, *pm.HeightMap.Addr)
+		backend.Current.ActiveTexture(gl.TEXTURE2)
+		backend.Current.BindTexture(gl.TEXTURE_2D, *pm.HeightMap.Addr)
 	}
-	gl.Uniform1i(pm.shader.GetUniform("heightMap"), 2)
+	backend.Current.Uniform1i(pm.shader.GetUniform("heightMap"), 2)
 
 	if pm.MetallicMap != nil {
-		gl.ActiveTexture(gl.TEXTURE3)
-		gl.BindTexture(gl.TEXTURE_2D, *pm.MetallicMap.Addr)
+		backend.Current.ActiveTexture(gl.TEXTURE3)
+		backend.Current.BindTexture(gl.TEXTURE_2D, *pm.MetallicMap.Addr)
 	}
-	gl.Uniform1i(pm.shader.GetUniform("metallicMap"), 3)
+	backend.Current.Uniform1i(pm.shader.GetUniform("metallicMap"), 3)
 
 	if pm.RoughnessMap != nil {
-		gl.ActiveTexture(gl.TEXTURE4)
-		gl.BindTexture(gl.TEXTURE_2D, *pm.RoughnessMap.Addr)
+		backend.Current.ActiveTexture(gl.TEXTURE4)
+		backend.Current.BindTexture(gl.TEXTURE_2D, *pm.RoughnessMap.Addr)
 	}
-	gl.Uniform1i(pm.shader.GetUniform("roughnessMap"), 4)
+	backend.Current.Uniform1i(pm.shader.GetUniform("roughnessMap"), 4)
 
 	if pm.AmbientOcclusionMap != nil {
-		gl.ActiveTexture(gl.TEXTURE5)
-		gl.BindTexture(gl.TEXTURE_2D, *pm.AmbientOcclusionMap.Addr)
+		backend.Current.ActiveTexture(gl.TEXTURE5)
+		backend.Current.BindTexture(gl.TEXTURE_2D, *pm.AmbientOcclusionMap.Addr)
 	}
-	gl.Uniform1i(pm.shader.GetUniform("aoMap"), 5)
+	backend.Current.Uniform1i(pm.shader.GetUniform("aoMap"), 5)
 
-	gl.Uniform1f(pm.shader.GetUniform("normalScalar"), pm.NormalScalar)
-	gl.Uniform1f(pm.shader.GetUniform("metallicScalar"), pm.MetallicScalar)
-	gl.Uniform1f(pm.shader.GetUniform("roughnessScalar"), pm.RoughnessScalar)
-	gl.Uniform1f(pm.shader.GetUniform("aoScalar"), pm.AmbientOcclusionScalar)
+	backend.Current.Uniform1f(pm.shader.GetUniform("normalScalar"), pm.NormalScalar)
+	backend.Current.Uniform1f(pm.shader.GetUniform("metallicScalar"), pm.MetallicScalar)
+	backend.Current.Uniform1f(pm.shader.GetUniform("roughnessScalar"), pm.RoughnessScalar)
+	backend.Current.Uniform1f(pm.shader.GetUniform("aoScalar"), pm.AmbientOcclusionScalar)
 
 	if pm.RoughOrSmooth {
-		gl.Uniform1f(pm.shader.GetUniform("roughORsmooth"), 1)
+		backend.Current.Uniform1f(pm.shader.GetUniform("roughORsmooth"), 1)
 	} else {
-		gl.Uniform1f(pm.shader.GetUniform("roughORsmooth"), -1)
+		backend.Current.Uniform1f(pm.shader.GetUniform("roughORsmooth"), -1)
 	}
 
-	gl.Uniform1f(pm.shader.GetUniform("scale"), pm.Scale)
-	gl.Uniform1f(pm.shader.GetUniform("vertexDisplacement"), pm.VertexDisplacement)
-	gl.Uniform1f(pm.shader.GetUniform("parallaxDisplacement"), pm.ParallaxDisplacement)
+	backend.Current.Uniform1f(pm.shader.GetUniform("scale"), pm.Scale)
+	backend.Current.Uniform1f(pm.shader.GetUniform("vertexDisplacement"), pm.VertexDisplacement)
+	backend.Current.Uniform1f(pm.shader.GetUniform("parallaxDisplacement"), pm.ParallaxDisplacement)
 
-	gl.Uniform1f(pm.shader.GetUniform("reflectivity"), pm.Reflectivity)
-	gl.Uniform1f(pm.shader.GetUniform("refractivity"), pm.Refractivity)
-	gl.Uniform1f(pm.shader.GetUniform("refractLevel"), pm.RefractLevel)
+	backend.Current.Uniform1f(pm.shader.GetUniform("reflectivity"), pm.Reflectivity)
+	backend.Current.Uniform1f(pm.shader.GetUniform("refractivity"), pm.Refractivity)
+	backend.Current.Uniform1f(pm.shader.GetUniform("refractLevel"), pm.RefractLevel)
 }
 
 func (pm *PBRMaterial) GetShader() *ShaderProgram {
 	return pm.shader
 }
 
+func (pm *PBRMaterial) MainTexture() uint32 {
+	if pm.AlbedoMap == nil {
+		return 0
+	}
+	return *pm.AlbedoMap.Addr
+}
+
 //   --------------------------------------------------
 //   UI Interface
 //   --------------------------------------------------
@@ -1,7 +1,7 @@
 package material
 
 import (
-	"rapidengine/state"
+	"rapidengine/backend"
 
 	"github.com/go-gl/gl/v4.1-core/gl"
 )
@@ -23,14 +23,13 @@ func NewPostProcessMaterial(shader *ShaderProgram, screenMap *uint32) *PostProce
 }
 
 func (pm *PostProcessMaterial) Render(delta float64, darkness float32, totalTime float64) {
-	gl.ActiveTexture(gl.TEXTURE0)
-	gl.BindTexture(gl.TEXTURE_2D, *pm.ScreenMap)
-	state.BoundTexture0 = *pm.ScreenMap
+	backend.Current.ActiveTexture(gl.TEXTURE0)
+	backend.Current.BindTexture(gl.TEXTURE_2D, *pm.ScreenMap)
 
-	gl.Uniform1i(pm.shader.GetUniform("screen"), 0)
+	backend.Current.Uniform1i(pm.shader.GetUniform("screen"), 0)
 
-	gl.Uniform1f(pm.shader.GetUniform("fboWidth"), pm.FboWidth)
-	gl.Uniform1f(pm.shader.GetUniform("fboHeight"), pm.FboHeight)
+	backend.Current.Uniform1f(pm.shader.GetUniform("fboWidth"), pm.FboWidth)
+	backend.Current.Uniform1f(pm.shader.GetUniform("fboHeight"), pm.FboHeight)
 }
 
 func (pm *PostProcessMaterial) GetShader() *ShaderProgram {
@@ -2,10 +2,11 @@ package material
 
 import (
 	"fmt"
-	"io/ioutil"
-	"strings"
 
 	"github.com/go-gl/gl/v4.1-core/gl"
+
+	"rapidengine/assets"
+	"rapidengine/backend"
 )
 
 type ShaderProgram struct {
@@ -25,12 +26,12 @@ type ShaderProgram struct {
 
 func (shaderProgram *ShaderProgram) Bind() {
 	b := shaderProgram.id
-	gl.UseProgram(b)
+	backend.Current.UseProgram(b)
 }
 
 func (shaderProgram *ShaderProgram) RebindAttribLocations() {
 	for attrib, location := range shaderProgram.attributeLocations {
-		gl.BindAttribLocation(shaderProgram.id, location, gl.Str(attrib+"\x00"))
+		backend.Current.BindAttribLocation(shaderProgram.id, location, attrib)
 	}
 }
 
@@ -38,98 +39,96 @@ func (shaderProgram *ShaderProgram) GetUniform(name string) int32 {
 	return shaderProgram.uniformLocations[name]
 }
 
+// HasUniform checks if the program declares a uniform
+func (shaderProgram *ShaderProgram) HasUniform(name string) bool {
+	_, ok := shaderProgram.uniformLocations[name]
+	return ok
+}
+
 func (shaderProgram *ShaderProgram) GetID() uint32 {
 	return shaderProgram.id
 }
 
-func (shaderProgram *ShaderProgram) Compile() {
-	vert, err := ioutil.ReadFile(shaderProgram.vertexShader)
-	if err != nil {
-		panic(err)
-	}
-	vertexShader, err := CompileShader(string(vert)+"\x00", gl.VERTEX_SHADER)
-	if err != nil {
-		panic(err)
-	}
+// Compile compiles and links every stage of the program. It returns an
+// assets.NotFoundError if a source file is missing, or an
+// assets.CompileError if a stage fails to compile or the program
+// fails to link.
+func (shaderProgram *ShaderProgram) Compile() error {
+	return shaderProgram.CompileFrom(assets.Default)
+}
 
-	frag, err := ioutil.ReadFile(shaderProgram.fragmentShader)
+// CompileFrom compiles the program from sources found through fsys
+func (shaderProgram *ShaderProgram) CompileFrom(fsys *assets.FileSystem) error {
+	vertexShader, err := compileStage(fsys, shaderProgram.vertexShader, "vertex", gl.VERTEX_SHADER)
 	if err != nil {
-		panic(err)
+		return err
 	}
-	fragmentShader, err := CompileShader(string(frag)+"\x00", gl.FRAGMENT_SHADER)
+
+	fragmentShader, err := compileStage(fsys, shaderProgram.fragmentShader, "fragment", gl.FRAGMENT_SHADER)
 	if err != nil {
-		panic(err)
+		return err
 	}
 
-	shaderProgram.id = gl.CreateProgram()
-	gl.AttachShader(shaderProgram.id, vertexShader)
-	gl.AttachShader(shaderProgram.id, fragmentShader)
+	shaderProgram.id = backend.Current.CreateProgram()
+	backend.Current.AttachShader(shaderProgram.id, vertexShader)
+	backend.Current.AttachShader(shaderProgram.id, fragmentShader)
 
 	if shaderProgram.geometryShader != "" {
-		geom, err := ioutil.ReadFile(shaderProgram.geometryShader)
-		if err != nil {
-			panic(err)
-		}
-		geometryShader, err := CompileShader(string(geom)+"\x00", gl.GEOMETRY_SHADER)
+		geometryShader, err := compileStage(fsys, shaderProgram.geometryShader, "geometry", gl.GEOMETRY_SHADER)
 		if err != nil {
-			panic(err)
+			return err
 		}
-		gl.AttachShader(shaderProgram.id, geometryShader)
+		backend.Current.AttachShader(shaderProgram.id, geometryShader)
 	}
 
 	// Tesselation shaders
 	if shaderProgram.controlShader != "" {
 		println("Compiling tesselation shaders")
-		cont, err := ioutil.ReadFile(shaderProgram.controlShader)
-		if err != nil {
-			panic(err)
-		}
-		controlShader, err := CompileShader(string(cont)+"\x00", gl.TESS_CONTROL_SHADER)
+		controlShader, err := compileStage(fsys, shaderProgram.controlShader, "tesselation control", gl.TESS_CONTROL_SHADER)
 		if err != nil {
-			panic(err)
+			return err
 		}
-		gl.AttachShader(shaderProgram.id, controlShader)
+		backend.Current.AttachShader(shaderProgram.id, controlShader)
 
-		eval, err := ioutil.ReadFile(shaderProgram.evalShader)
-		if err != nil {
-			panic(err)
-		}
-		evalShader, err := CompileShader(string(eval)+"\x00", gl.TESS_EVALUATION_SHADER)
+		evalShader, err := compileStage(fsys, shaderProgram.evalShader, "tesselation evaluation", gl.TESS_EVALUATION_SHADER)
 		if err != nil {
-			panic(err)
+			return err
 		}
-		gl.AttachShader(shaderProgram.id, evalShader)
+		backend.Current.AttachShader(shaderProgram.id, evalShader)
 	}
 
-	gl.LinkProgram(shaderProgram.id)
+	if err := backend.Current.LinkProgram(shaderProgram.id); err != nil {
+		return &assets.CompileError{Path: shaderProgram.vertexShader, Stage: "link", Log: err.Error()}
+	}
 
 	for uni := range shaderProgram.uniformLocations {
-		shaderProgram.uniformLocations[uni] = gl.GetUniformLocation(shaderProgram.id, gl.Str(uni+"\x00"))
+		shaderProgram.uniformLocations[uni] = backend.Current.GetUniformLocation(shaderProgram.id, uni)
 	}
 
 	for attrib, location := range shaderProgram.attributeLocations {
-		gl.BindAttribLocation(shaderProgram.id, location, gl.Str(attrib+"\x00"))
+		backend.Current.BindAttribLocation(shaderProgram.id, location, attrib)
 	}
-}
-
-func CompileShader(source string, shaderType uint32) (uint32, error) {
-	shader := gl.CreateShader(shaderType)
 
-	csources, free := gl.Strs(source)
-	gl.ShaderSource(shader, 1, csources, nil)
-	free()
-	gl.CompileShader(shader)
+	return nil
+}
 
-	var status int32
-	gl.GetShaderiv(shader, gl.COMPILE_STATUS, &status)
-	if status == gl.FALSE {
-		var logLength int32
-		gl.GetShaderiv(shader, gl.INFO_LOG_LENGTH, &logLength)
+func compileStage(fsys *assets.FileSystem, path string, stage string, shaderType uint32) (uint32, error) {
+	source, err := fsys.ReadAsset(path)
+	if err != nil {
+		return 0, err
+	}
 
-		log := strings.Repeat("\x00", int(logLength+1))
-		gl.GetShaderInfoLog(shader, logLength, nil, gl.Str(log))
+	shader, err := backend.Current.CompileShader(string(source), shaderType)
+	if err != nil {
+		return 0, &assets.CompileError{Path: path, Stage: stage, Log: err.Error()}
+	}
+	return shader, nil
+}
 
-		return 0, fmt.Errorf("failed to compile %v: %v", source, log)
+func CompileShader(source string, shaderType uint32) (uint32, error) {
+	shader, err := backend.Current.CompileShader(source, shaderType)
+	if err != nil {
+		return 0, fmt.Errorf("failed to compile %v: %v", source, err)
 	}
 	return shader, nil
 }
@@ -138,9 +137,25 @@ func CompileShader(source string, shaderType uint32) (uint32, error) {
 //  Shader Programs
 //  --------------------------------------------------
 
+// FallbackProgram draws everything in magenta. It is used
+// in place of shaders that fail to compile.
+var FallbackProgram = ShaderProgram{
+	vertexShader:   "engine/shaders/fallback/fallback.vert",
+	fragmentShader: "engine/shaders/fallback/fallback.frag",
+	uniformLocations: map[string]int32{
+		// Vertices
+		"modelMtx":      0,
+		"viewMtx":       0,
+		"projectionMtx": 0,
+	},
+	attributeLocations: map[string]uint32{
+		"position": 0,
+	},
+}
+
 var BasicProgram = ShaderProgram{
-	vertexShader:   "../rapidengine/material/shaders/basic/basic.vert",
-	fragmentShader: "../rapidengine/material/shaders/basic/basic.frag",
+	vertexShader:   "engine/shaders/basic/basic.vert",
+	fragmentShader: "engine/shaders/basic/basic.frag",
 	uniformLocations: map[string]int32{
 		// Vertices
 		"modelMtx":      0,
@@ -162,16 +177,22 @@ var BasicProgram = ShaderProgram{
 
 		"alphaMapLevel": 0,
 		"alphaMap":      0,
+
+		"atlasSize": 0,
+
+		"instanceMatrices": 0,
 	},
 	attributeLocations: map[string]uint32{
-		"position": 0,
-		"tex":      1,
+		"position":    0,
+		"tex":         1,
+		"instance":    5,
+		"instanceMtx": 6,
 	},
 }
 
 var StandardProgram = ShaderProgram{
-	vertexShader:   "../rapidengine/material/shaders/standard/standard.vert",
-	fragmentShader: "../rapidengine/material/shaders/standard/standard.frag",
+	vertexShader:   "engine/shaders/standard/standard.vert",
+	fragmentShader: "engine/shaders/standard/standard.frag",
 	uniformLocations: map[string]int32{
 		// Vertices
 		"modelMtx":      0,
@@ -209,6 +230,8 @@ var StandardProgram = ShaderProgram{
 
 		"numPointLights": 0,
 		"pointLights":    0,
+
+		"instanceMatrices": 0,
 	},
 	attributeLocations: map[string]uint32{
 		"position":   0,
@@ -216,12 +239,14 @@ var StandardProgram = ShaderProgram{
 		"normal":     2,
 		"tangent":    3,
 		"bitTangent": 4,
+
+		"instanceMtx": 6,
 	},
 }
 
 var PBRProgram = ShaderProgram{
-	vertexShader:   "../rapidengine/material/shaders/pbr/pbr.vert",
-	fragmentShader: "../rapidengine/material/shaders/pbr/pbr.frag",
+	vertexShader:   "engine/shaders/pbr/pbr.vert",
+	fragmentShader: "engine/shaders/pbr/pbr.frag",
 	uniformLocations: map[string]int32{
 		// Vertices
 		"modelMtx":      0,
@@ -262,6 +287,8 @@ var PBRProgram = ShaderProgram{
 
 		"numPointLights": 0,
 		"pointLights":    0,
+
+		"instanceMatrices": 0,
 	},
 	attributeLocations: map[string]uint32{
 		"position":   0,
@@ -269,14 +296,16 @@ var PBRProgram = ShaderProgram{
 		"normal":     2,
 		"tangent":    3,
 		"bitTangent": 4,
+
+		"instanceMtx": 6,
 	},
 }
 
 var TerrainProgram = ShaderProgram{
-	vertexShader:   "../rapidengine/material/shaders/terrain/terrain.vert",
-	fragmentShader: "../rapidengine/material/shaders/terrain/terrain.frag",
-	controlShader:  "../rapidengine/material/shaders/terrain/terrain.cont",
-	evalShader:     "../rapidengine/material/shaders/terrain/terrain.eval",
+	vertexShader:   "engine/shaders/terrain/terrain.vert",
+	fragmentShader: "engine/shaders/terrain/terrain.frag",
+	controlShader:  "engine/shaders/terrain/terrain.cont",
+	evalShader:     "engine/shaders/terrain/terrain.eval",
 	uniformLocations: map[string]int32{
 		// Vertices
 		"modelMtx":      0,
@@ -313,9 +342,9 @@ var TerrainProgram = ShaderProgram{
 }
 
 var FoliageProgram = ShaderProgram{
-	vertexShader:   "../rapidengine/material/shaders/foliage/nfoliage.vert",
-	fragmentShader: "../rapidengine/material/shaders/foliage/nfoliage.frag",
-	//geometryShader: "../rapidengine/material/shaders/foliage/foliage.geom",
+	vertexShader:   "engine/shaders/foliage/nfoliage.vert",
+	fragmentShader: "engine/shaders/foliage/nfoliage.frag",
+	//geometryShader: "engine/shaders/foliage/foliage.geom",
 	uniformLocations: map[string]int32{
 		// Vertices
 		"modelMtx":      0,
@@ -380,8 +409,8 @@ var FoliageProgram = ShaderProgram{
 }
 
 var WaterProgram = ShaderProgram{
-	vertexShader:   "../rapidengine/material/shaders/water/water.vert",
-	fragmentShader: "../rapidengine/material/shaders/water/water.frag",
+	vertexShader:   "engine/shaders/water/water.vert",
+	fragmentShader: "engine/shaders/water/water.frag",
 	uniformLocations: map[string]int32{
 		// Vertices
 		"modelMtx":      0,
@@ -418,9 +447,29 @@ var WaterProgram = ShaderProgram{
 	},
 }
 
+// SpriteProgram draws quads streamed by render.SpriteBatch
+var SpriteProgram = ShaderProgram{
+	vertexShader:   "engine/shaders/sprite/sprite.vert",
+	fragmentShader: "engine/shaders/sprite/sprite.frag",
+	uniformLocations: map[string]int32{
+		// Vertices
+		"modelMtx":      0,
+		"viewMtx":       0,
+		"projectionMtx": 0,
+
+		"diffuseMap": 0,
+		"textured":   0,
+	},
+	attributeLocations: map[string]uint32{
+		"position": 0,
+		"tex":      1,
+		"tint":     2,
+	},
+}
+
 var SkyBoxProgram = ShaderProgram{
-	vertexShader:   "../rapidengine/material/shaders/skybox/skybox.vert",
-	fragmentShader: "../rapidengine/material/shaders/skybox/skybox.frag",
+	vertexShader:   "engine/shaders/skybox/skybox.vert",
+	fragmentShader: "engine/shaders/skybox/skybox.frag",
 	uniformLocations: map[string]int32{
 		// Vertices
 		"modelMtx":      0,
@@ -436,8 +485,8 @@ var SkyBoxProgram = ShaderProgram{
 }
 
 var SunProgram = ShaderProgram{
-	vertexShader:   "../rapidengine/material/shaders/sun/basic.vert",
-	fragmentShader: "../rapidengine/material/shaders/sun/basic.frag",
+	vertexShader:   "engine/shaders/sun/basic.vert",
+	fragmentShader: "engine/shaders/sun/basic.frag",
 	uniformLocations: map[string]int32{
 		// Vertices
 		"modelMtx":      0,
@@ -467,8 +516,8 @@ var SunProgram = ShaderProgram{
 }
 
 var PostFinalProgram = ShaderProgram{
-	vertexShader:   "../rapidengine/material/shaders/postprocessing/final/final.vert",
-	fragmentShader: "../rapidengine/material/shaders/postprocessing/final/final.frag",
+	vertexShader:   "engine/shaders/postprocessing/final/final.vert",
+	fragmentShader: "engine/shaders/postprocessing/final/final.frag",
 	uniformLocations: map[string]int32{
 		// Vertices
 		"modelMtx":      0,
@@ -486,8 +535,8 @@ var PostFinalProgram = ShaderProgram{
 }
 
 var PostHDRProgram = ShaderProgram{
-	vertexShader:   "../rapidengine/material/shaders/postprocessing/hdr/hdr.vert",
-	fragmentShader: "../rapidengine/material/shaders/postprocessing/hdr/hdr.frag",
+	vertexShader:   "engine/shaders/postprocessing/hdr/hdr.vert",
+	fragmentShader: "engine/shaders/postprocessing/hdr/hdr.frag",
 	uniformLocations: map[string]int32{
 		// Vertices
 		"modelMtx":      0,
@@ -505,8 +554,8 @@ var PostHDRProgram = ShaderProgram{
 }
 
 var PostHorizontalProgram = ShaderProgram{
-	vertexShader:   "../rapidengine/material/shaders/postprocessing/blur/horizontal/horizontal.vert",
-	fragmentShader: "../rapidengine/material/shaders/postprocessing/blur/horizontal/horizontal.frag",
+	vertexShader:   "engine/shaders/postprocessing/blur/horizontal/horizontal.vert",
+	fragmentShader: "engine/shaders/postprocessing/blur/horizontal/horizontal.frag",
 	uniformLocations: map[string]int32{
 		// Vertices
 		"modelMtx":      0,
@@ -524,8 +573,8 @@ var PostHorizontalProgram = ShaderProgram{
 }
 
 var PostVerticalProgram = ShaderProgram{
-	vertexShader:   "../rapidengine/material/shaders/postprocessing/blur/vertical/vertical.vert",
-	fragmentShader: "../rapidengine/material/shaders/postprocessing/blur/vertical/vertical.frag",
+	vertexShader:   "engine/shaders/postprocessing/blur/vertical/vertical.vert",
+	fragmentShader: "engine/shaders/postprocessing/blur/vertical/vertical.frag",
 	uniformLocations: map[string]int32{
 		// Vertices
 		"modelMtx":      0,
@@ -543,8 +592,8 @@ var PostVerticalProgram = ShaderProgram{
 }
 
 var PostPreScatteringProgram = ShaderProgram{
-	vertexShader:   "../rapidengine/material/shaders/postprocessing/scattering/prescattering/prescattering.vert",
-	fragmentShader: "../rapidengine/material/shaders/postprocessing/scattering/prescattering/prescattering.frag",
+	vertexShader:   "engine/shaders/postprocessing/scattering/prescattering/prescattering.vert",
+	fragmentShader: "engine/shaders/postprocessing/scattering/prescattering/prescattering.frag",
 	uniformLocations: map[string]int32{
 		// Vertices
 		"modelMtx":      0,
@@ -569,8 +618,8 @@ var PostPreScatteringProgram = ShaderProgram{
 }
 
 var PostPostScatteringProgram = ShaderProgram{
-	vertexShader:   "../rapidengine/material/shaders/postprocessing/scattering/postscattering/postscattering.vert",
-	fragmentShader: "../rapidengine/material/shaders/postprocessing/scattering/postscattering/postscattering.frag",
+	vertexShader:   "engine/shaders/postprocessing/scattering/postscattering/postscattering.vert",
+	fragmentShader: "engine/shaders/postprocessing/scattering/postscattering/postscattering.frag",
 	uniformLocations: map[string]int32{
 		// Vertices
 		"modelMtx":      0,
@@ -590,8 +639,8 @@ var PostPostScatteringProgram = ShaderProgram{
 }
 
 var PostPreBloomProgram = ShaderProgram{
-	vertexShader:   "../rapidengine/material/shaders/postprocessing/bloom/prebloom/prebloom.vert",
-	fragmentShader: "../rapidengine/material/shaders/postprocessing/bloom/prebloom/prebloom.frag",
+	vertexShader:   "engine/shaders/postprocessing/bloom/prebloom/prebloom.vert",
+	fragmentShader: "engine/shaders/postprocessing/bloom/prebloom/prebloom.frag",
 	uniformLocations: map[string]int32{
 		// Vertices
 		"modelMtx":      0,
@@ -611,8 +660,8 @@ var PostPreBloomProgram = ShaderProgram{
 }
 
 var PostPostBloomProgram = ShaderProgram{
-	vertexShader:   "../rapidengine/material/shaders/postprocessing/bloom/postbloom/postbloom.vert",
-	fragmentShader: "../rapidengine/material/shaders/postprocessing/bloom/postbloom/postbloom.frag",
+	vertexShader:   "engine/shaders/postprocessing/bloom/postbloom/postbloom.vert",
+	fragmentShader: "engine/shaders/postprocessing/bloom/postbloom/postbloom.frag",
 	uniformLocations: map[string]int32{
 		// Vertices
 		"modelMtx":      0,
@@ -14,6 +14,7 @@ uniform float alphaMapLevel;
 uniform float scatterLevel;
 
 in vec3 texCoord;
+in float instanceDarkness;
 
 layout(location = 0) out vec4 outColor;
 layout(location = 1) out vec4 scatterColor;
@@ -33,7 +34,7 @@ void main() {
         discard;
     }
 
-    outColor = vec4(darkness * finalColor.xyz, finalColor.a);
+    outColor = vec4(darkness * instanceDarkness * finalColor.xyz, finalColor.a);
     scatterColor = outColor * scatterLevel;
 }
 
@@ -8,10 +8,22 @@ uniform float scale;
 
 uniform int flipped;
 
+// Columns and rows of the diffuse map, if it's an atlas
+uniform vec2 atlasSize;
+
 layout (location = 0) in vec3 position;
 layout (location = 1) in vec3 tex;
 
+// Per-instance offset, atlas index and darkness. When the
+// mesh isn't instanced it's (0, 0, 0, 1), which changes nothing.
+layout (location = 5) in vec4 instance;
+
+// Set when the mesh has a model matrix per instance
+uniform int instanceMatrices;
+layout (location = 6) in mat4 instanceMtx;
+
 out vec3 texCoord;
+out float instanceDarkness;
 
 void main() {
     if(flipped == 0) {
@@ -20,5 +32,15 @@ void main() {
         texCoord = vec3(1 - tex.x, tex.y, tex.z) / scale;
     }
 
-    gl_Position = projectionMtx * viewMtx * modelMtx * vec4(position, 1.0);
+    if(atlasSize.x > 0 && atlasSize.y > 0) {
+        vec2 tile = vec2(mod(instance.z, atlasSize.x), floor(instance.z / atlasSize.x));
+        texCoord.xy = (texCoord.xy + tile) / atlasSize;
+    }
+
+    instanceDarkness = instance.w;
+
+    mat4 model = instanceMatrices == 1 ? instanceMtx : modelMtx;
+    model[3].xy += instance.xy;
+
+    gl_Position = projectionMtx * viewMtx * model * vec4(position, 1.0);
 }
\ No newline at end of file
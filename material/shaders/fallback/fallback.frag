@@ -0,0 +1,9 @@
+#version 410
+
+layout(location = 0) out vec4 outColor;
+layout(location = 1) out vec4 scatterColor;
+
+void main() {
+    outColor = vec4(1.0, 0.0, 1.0, 1.0);
+    scatterColor = vec4(0.0);
+}
\ No newline at end of file
@@ -0,0 +1,11 @@
+#version 410 
+
+uniform mat4 modelMtx;
+uniform mat4 viewMtx;
+uniform mat4 projectionMtx;
+
+layout (location = 0) in vec3 position;
+
+void main() {
+    gl_Position = projectionMtx * viewMtx * modelMtx * vec4(position, 1.0);
+}
\ No newline at end of file
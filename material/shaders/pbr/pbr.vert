@@ -13,6 +13,10 @@ layout (location = 2) in vec3 normal;
 layout (location = 3) in vec3 tangent;
 layout (location = 4) in vec3 bitTangent;
 
+// Set when the mesh has a model matrix per instance
+uniform int instanceMatrices;
+layout (location = 6) in mat4 instanceMtx;
+
 uniform float scale;
 
 uniform mat4 modelMtx;
@@ -30,13 +34,15 @@ float getDisplacement() {
 }
 
 void main() {
+    mat4 model = instanceMatrices == 1 ? instanceMtx : modelMtx;
+
     vec3 finalPosition = position + (normal * getDisplacement());
 
     // Fragment position
-    FragPos = vec3(modelMtx * vec4(finalPosition, 1.0));
+    FragPos = vec3(model * vec4(finalPosition, 1.0));
 
      // Normal vector
-    Normal = mat3(modelMtx) * normal;
+    Normal = mat3(model) * normal;
 
     //	Vertex position 
     gl_Position = projectionMtx * viewMtx * vec4(FragPos, 1.0);
@@ -45,9 +51,9 @@ void main() {
     TexCoords = tex / scale;
 
     // Normal mapping
-    vec3 T = normalize(vec3(modelMtx * vec4(tangent,   0.0)));
-    vec3 B = normalize(vec3(modelMtx * vec4(bitTangent, 0.0)));
-    vec3 N = normalize(vec3(modelMtx * vec4(normal,    0.0)));
+    vec3 T = normalize(vec3(model * vec4(tangent,   0.0)));
+    vec3 B = normalize(vec3(model * vec4(bitTangent, 0.0)));
+    vec3 N = normalize(vec3(model * vec4(normal,    0.0)));
     TBN = mat3(T, B, N);
 
     // Reflection/refraction
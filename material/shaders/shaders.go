@@ -0,0 +1,10 @@
+// Package shaders embeds the engine's default GLSL shaders, so
+// they are available no matter where the game is run from.
+package shaders
+
+import "embed"
+
+// FS contains every default shader, e.g. basic/basic.vert
+//
+//go:embed basic fallback foliage pbr postprocessing skybox sprite standard sun terrain water
+var FS embed.FS
@@ -0,0 +1,24 @@
+#version 410
+
+uniform sampler2D diffuseMap;
+uniform int textured;
+
+in vec2 texCoord;
+in vec4 spriteTint;
+
+layout(location = 0) out vec4 outColor;
+layout(location = 1) out vec4 scatterColor;
+
+void main() {
+    vec4 color = spriteTint;
+    if(textured == 1) {
+        color *= texture(diffuseMap, texCoord);
+    }
+
+    if(color.a < 0.01) {
+        discard;
+    }
+
+    outColor = color;
+    scatterColor = vec4(0.0);
+}
\ No newline at end of file
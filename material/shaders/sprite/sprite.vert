@@ -0,0 +1,20 @@
+#version 410 
+
+// Converts pixel positions to the -1 to 1 range
+uniform mat4 modelMtx;
+uniform mat4 viewMtx;
+uniform mat4 projectionMtx;
+
+layout (location = 0) in vec2 position;
+layout (location = 1) in vec2 tex;
+layout (location = 2) in vec4 tint;
+
+out vec2 texCoord;
+out vec4 spriteTint;
+
+void main() {
+    texCoord = tex;
+    spriteTint = tint;
+
+    gl_Position = projectionMtx * viewMtx * modelMtx * vec4(position, 0.0, 1.0);
+}
\ No newline at end of file
@@ -1,14 +1,18 @@
 package material
 
-import "github.com/go-gl/gl/v4.1-core/gl"
+import (
+	"github.com/go-gl/gl/v4.1-core/gl"
+
+	"rapidengine/backend"
+)
 
 type StandardMaterial struct {
 	shader *ShaderProgram
 
-	diffuseMap  *Texture
-	normalMap   *Texture
-	heightMap   *Texture
-	specularMap *Texture
+	DiffuseMap  *Texture
+	NormalMap   *Texture
+	HeightMap   *Texture
+	SpecularMap *Texture
 
 	DiffuseLevel  float32
 	NormalLevel   float32
@@ -36,62 +40,69 @@ func NewStandardMaterial(shader *ShaderProgram) *StandardMaterial {
 }
 
 func (sm *StandardMaterial) AttachDiffuseMap(dm *Texture) {
-	sm.diffuseMap = dm
+	sm.DiffuseMap = dm
 }
 
 func (sm *StandardMaterial) AttachNormalMap(nm *Texture) {
-	sm.normalMap = nm
+	sm.NormalMap = nm
 }
 
 func (sm *StandardMaterial) AttachHeightMap(hm *Texture) {
-	sm.heightMap = hm
+	sm.HeightMap = hm
 }
 
 func (sm *StandardMaterial) AttachSpecularMap(hm *Texture) {
-	sm.specularMap = hm
+	sm.SpecularMap = hm
 }
 
 func (sm *StandardMaterial) Render(delta float64, darkness float32, totalTime float64) {
 
-	if sm.diffuseMap != nil {
-		gl.ActiveTexture(gl.TEXTURE0)
-		gl.BindTexture(gl.TEXTURE_2D, *sm.diffuseMap.Addr)
+	if sm.DiffuseMap != nil {
+		backend.Current.ActiveTexture(gl.TEXTURE0)
+		backend.Current.BindTexture(gl.TEXTURE_2D, *sm.DiffuseMap.Addr)
 	}
-	gl.Uniform1i(sm.shader.GetUniform("diffuseMap"), 0)
+	backend.Current.Uniform1i(sm.shader.GetUniform("diffuseMap"), 0)
 
-	if sm.normalMap != nil {
-		gl.ActiveTexture(gl.TEXTURE1)
-		gl.BindTexture(gl.TEXTURE_2D, *sm.normalMap.Addr)
+	if sm.NormalMap != nil {
+		backend.Current.ActiveTexture(gl.TEXTURE1)
+		backend.Current.BindTexture(gl.TEXTURE_2D, *sm.NormalMap.Addr)
 	}
-	gl.Uniform1i(sm.shader.GetUniform("normalMap"), 1)
+	backend.Current.Uniform1i(sm.shader.GetUniform("normalMap"), 1)
 
-	if sm.heightMap != nil {
-		gl.ActiveTexture(gl.TEXTURE2)
-		gl.BindTexture(gl.TEXTURE_2D, *sm.heightMap.Addr)
+	if sm.HeightMap != nil {
+		backend.Current.ActiveTexture(gl.TEXTURE2)
+		backend.Current.BindTexture(gl.TEXTURE_2D, *sm.HeightMap.Addr)
 	}
-	gl.Uniform1i(sm.shader.GetUniform("heightMap"), 2)
+	backend.Current.Uniform1i(sm.shader.GetUniform("heightMap"), 2)
 
-	if sm.specularMap != nil {
-		gl.ActiveTexture(gl.TEXTURE3)
-		gl.BindTexture(gl.TEXTURE_2D, *sm.specularMap.Addr)
+	if sm.SpecularMap != nil {
+		backend.Current.ActiveTexture(gl.TEXTURE3)
+		backend.Current.BindTexture(gl.TEXTURE_2D, *sm.SpecularMap.Addr)
 	}
-	gl.Uniform1i(sm.shader.GetUniform("specularMap"), 3)
+	backend.Current.Uniform1i(sm.shader.GetUniform("specularMap"), 3)
 
-	gl.Uniform1f(sm.shader.GetUniform("diffuseLevel"), sm.DiffuseLevel)
-	gl.Uniform1f(sm.shader.GetUniform("normalLevel"), sm.NormalLevel)
-	gl.Uniform1f(sm.shader.GetUniform("specularLevel"), sm.SpecularLevel)
-	gl.Uniform1f(sm.shader.GetUniform("heightLevel"), sm.HeightLevel)
+	backend.Current.Uniform1f(sm.shader.GetUniform("diffuseLevel"), sm.DiffuseLevel)
+	backend.Current.Uniform1f(sm.shader.GetUniform("normalLevel"), sm.NormalLevel)
+	backend.Current.Uniform1f(sm.shader.GetUniform("specularLevel"), sm.SpecularLevel)
+	backend.Current.Uniform1f(sm.shader.GetUniform("heightLevel"), sm.HeightLevel)
 
-	gl.Uniform4fv(sm.shader.GetUniform("hue"), 1, &sm.Hue[0])
+	backend.Current.Uniform4fv(sm.shader.GetUniform("hue"), 1, &sm.Hue[0])
 
-	gl.Uniform1f(sm.shader.GetUniform("displacement"), sm.Displacement)
-	gl.Uniform1f(sm.shader.GetUniform("scale"), sm.Scale)
+	backend.Current.Uniform1f(sm.shader.GetUniform("displacement"), sm.Displacement)
+	backend.Current.Uniform1f(sm.shader.GetUniform("scale"), sm.Scale)
 
-	gl.Uniform1f(sm.shader.GetUniform("reflectivity"), sm.Reflectivity)
-	gl.Uniform1f(sm.shader.GetUniform("refractivity"), sm.Refractivity)
-	gl.Uniform1f(sm.shader.GetUniform("refractLevel"), sm.RefractLevel)
+	backend.Current.Uniform1f(sm.shader.GetUniform("reflectivity"), sm.Reflectivity)
+	backend.Current.Uniform1f(sm.shader.GetUniform("refractivity"), sm.Refractivity)
+	backend.Current.Uniform1f(sm.shader.GetUniform("refractLevel"), sm.RefractLevel)
 }
 
 func (sm *StandardMaterial) GetShader() *ShaderProgram {
 	return sm.shader
 }
+
+func (sm *StandardMaterial) MainTexture() uint32 {
+	if sm.DiffuseMap == nil {
+		return 0
+	}
+	return *sm.DiffuseMap.Addr
+}
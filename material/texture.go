@@ -1,6 +1,7 @@
 package material
 
 import (
+	"bytes"
 	"fmt"
 	"image"
 	"image/draw"
@@ -8,7 +9,8 @@ import (
 	"image/png"
 	"io"
 	"net/http"
-	"os"
+
+	"rapidengine/assets"
 )
 
 type Texture struct {
@@ -18,21 +20,28 @@ type Texture struct {
 	Addr   *uint32
 }
 
+// LoadImage loads a png or jpeg image as RGBA. It returns an
+// assets.NotFoundError or assets.DecodeError on failure.
 func LoadImage(path string) (*image.RGBA, error) {
-	imgFile, err := os.Open(path)
+	return LoadImageFrom(assets.Default, path)
+}
+
+// LoadImageFrom loads an image found through fsys, see LoadImage
+func LoadImageFrom(fsys *assets.FileSystem, path string) (*image.RGBA, error) {
+	data, err := fsys.ReadAsset(path)
 	if err != nil {
 		return nil, err
 	}
-	defer imgFile.Close()
+	imgFile := bytes.NewReader(data)
 
 	ct, err := detectContentType(imgFile)
 	if err != nil {
-		return nil, err
+		return nil, &assets.DecodeError{Path: path, Format: "image", Err: err}
 	}
 
 	img, err := convert(imgFile, ct)
 	if err != nil {
-		return nil, err
+		return nil, &assets.DecodeError{Path: path, Format: ct, Err: err}
 	}
 
 	return img, nil
@@ -83,16 +92,14 @@ func detectContentType(file io.ReadSeeker) (string, error) {
 }
 
 func LoadImageFullDepth(path string) (image.Image, error) {
-	imgFile, err := os.Open(path)
+	data, err := assets.ReadFile(path)
 	if err != nil {
 		return nil, err
 	}
-	defer imgFile.Close()
-
-	src, _, err := image.Decode(imgFile)
 
+	src, _, err := image.Decode(bytes.NewReader(data))
 	if err != nil {
-		return nil, err
+		return nil, &assets.DecodeError{Path: path, Format: "image", Err: err}
 	}
 
 	return src, nil
@@ -1,6 +1,10 @@
 package material
 
-import "github.com/go-gl/gl/v4.1-core/gl"
+import (
+	"github.com/go-gl/gl/v4.1-core/gl"
+
+	"rapidengine/backend"
+)
 
 type WaterMaterial struct {
 	shader *ShaderProgram
@@ -40,38 +44,38 @@ func (wm *WaterMaterial) Render(delta float64, darkness float32, totalTime float
 	wm.UpdateAttribArrays()
 
 	if wm.diffuseMap != nil {
-		gl.ActiveTexture(gl.TEXTURE0)
-		gl.BindTexture(gl.TEXTURE_2D, *wm.diffuseMap)
+		backend.Current.ActiveTexture(gl.TEXTURE0)
+		backend.Current.BindTexture(gl.TEXTURE_2D, *wm.diffuseMap)
 	}
 
-	gl.Uniform1i(wm.shader.GetUniform("diffuseMap"), 0)
+	backend.Current.Uniform1i(wm.shader.GetUniform("diffuseMap"), 0)
 
 	if wm.normalMap != nil {
-		gl.ActiveTexture(gl.TEXTURE1)
-		gl.BindTexture(gl.TEXTURE_2D, *wm.normalMap)
+		backend.Current.ActiveTexture(gl.TEXTURE1)
+		backend.Current.BindTexture(gl.TEXTURE_2D, *wm.normalMap)
 	}
 
-	gl.Uniform1i(wm.shader.GetUniform("normalMap"), 1)
+	backend.Current.Uniform1i(wm.shader.GetUniform("normalMap"), 1)
 
 	if wm.heightMap != nil {
-		gl.ActiveTexture(gl.TEXTURE2)
-		gl.BindTexture(gl.TEXTURE_2D, *wm.heightMap)
+		backend.Current.ActiveTexture(gl.TEXTURE2)
+		backend.Current.BindTexture(gl.TEXTURE_2D, *wm.heightMap)
 	}
 
-	gl.Uniform1i(wm.shader.GetUniform("heightMap"), 2)
+	backend.Current.Uniform1i(wm.shader.GetUniform("heightMap"), 2)
 
-	gl.Uniform1f(wm.shader.GetUniform("displacement"), wm.displacement)
-	gl.Uniform1f(wm.shader.GetUniform("scale"), wm.Scale)
+	backend.Current.Uniform1f(wm.shader.GetUniform("displacement"), wm.displacement)
+	backend.Current.Uniform1f(wm.shader.GetUniform("scale"), wm.Scale)
 
-	gl.Uniform1f(wm.shader.GetUniform("totalTime"), float32(totalTime))
+	backend.Current.Uniform1f(wm.shader.GetUniform("totalTime"), float32(totalTime))
 }
 
 func (wm *WaterMaterial) UpdateAttribArrays() {
-	gl.EnableVertexAttribArray(0)
-	gl.EnableVertexAttribArray(1)
-	gl.EnableVertexAttribArray(2)
-	gl.EnableVertexAttribArray(3)
-	gl.EnableVertexAttribArray(4)
+	backend.Current.EnableVertexAttribArray(0)
+	backend.Current.EnableVertexAttribArray(1)
+	backend.Current.EnableVertexAttribArray(2)
+	backend.Current.EnableVertexAttribArray(3)
+	backend.Current.EnableVertexAttribArray(4)
 }
 
 func (wm *WaterMaterial) GetShader() *ShaderProgram {
@@ -0,0 +1,272 @@
+package profiler
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+
+	"rapidengine/backend"
+)
+
+//  --------------------------------------------------
+//  Profiler.go times the stages of every frame. Each
+//  stage keeps a rolling history of its per-frame time,
+//  from which averages, percentiles and histograms are
+//  computed. GPU time can optionally be measured with
+//  timer queries.
+//  --------------------------------------------------
+
+// Stages timed by the engine. Systems are timed under their
+// system name, and post processing passes under "post/<pass>".
+const (
+	StageFrame    = "frame"
+	StageInput    = "input"
+	StageUser     = "user"
+	StagePhysics  = "physics"
+	StageSkyBox   = "skybox"
+	StageChildren = "children"
+	StagePost     = "post"
+	StageSwap     = "swap"
+)
+
+// GPUPrefix prefixes the names of stages measured with timer queries
+const GPUPrefix = "gpu/"
+
+// DefaultHistory is the number of frames kept for each stage
+const DefaultHistory = 300
+
+type Profiler struct {
+	// Stages are only timed while enabled
+	Enabled bool
+
+	// Measure GPU time with timer queries, for the stages
+	// wrapped in BeginGPU / EndGPU
+	GPU bool
+
+	// Number of frames kept for each stage
+	History int
+
+	// Frames profiled so far
+	Frames int
+
+	stages map[string]*Stage
+	order  []string
+
+	// Time started for each open stage, and the time
+	// accumulated by each stage in the current frame
+	started map[string]time.Time
+	current map[string]time.Duration
+
+	// Timer queries waiting for results, and finished queries to reuse
+	gpuStage   string
+	gpuPending []gpuQuery
+	gpuFree    []uint32
+
+	mu sync.Mutex
+}
+
+type gpuQuery struct {
+	stage string
+	query uint32
+}
+
+func NewProfiler(history int) *Profiler {
+	if history <= 0 {
+		history = DefaultHistory
+	}
+	return &Profiler{
+		History: history,
+		stages:  make(map[string]*Stage),
+		started: make(map[string]time.Time),
+		current: make(map[string]time.Duration),
+	}
+}
+
+// Begin starts timing a stage. A stage can be timed several
+// times in a frame, e.g. once per fixed step, and its times
+// are added together.
+func (p *Profiler) Begin(stage string) {
+	if !p.Enabled {
+		return
+	}
+
+	p.mu.Lock()
+	p.started[stage] = time.Now()
+	p.mu.Unlock()
+}
+
+// End stops timing a stage started with Begin
+func (p *Profiler) End(stage string) {
+	if !p.Enabled {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if start, ok := p.started[stage]; ok {
+		p.add(stage, time.Since(start))
+		delete(p.started, stage)
+	}
+}
+
+// Time times a call to f as the given stage
+func (p *Profiler) Time(stage string, f func()) {
+	p.Begin(stage)
+	f()
+	p.End(stage)
+}
+
+// Record adds a measured time to a stage for the current frame
+func (p *Profiler) Record(stage string, d time.Duration) {
+	if !p.Enabled {
+		return
+	}
+
+	p.mu.Lock()
+	p.add(stage, d)
+	p.mu.Unlock()
+}
+
+// add accumulates time for a stage. The stage is created the first
+// time it's seen, so stages are reported in the order they finish.
+func (p *Profiler) add(stage string, d time.Duration) {
+	p.stage(stage)
+	p.current[stage] += d
+}
+
+// BeginGPU starts a timer query for a stage. Timer queries can't be
+// nested, so a GPU stage must end before the next one begins. The
+// result is recorded under GPUPrefix + stage once it's available,
+// usually a frame or two later.
+func (p *Profiler) BeginGPU(stage string) {
+	if !p.Enabled || !p.GPU || p.gpuStage != "" {
+		return
+	}
+
+	query := uint32(0)
+	if n := len(p.gpuFree); n > 0 {
+		query = p.gpuFree[n-1]
+		p.gpuFree = p.gpuFree[:n-1]
+	} else {
+		query = backend.Current.GenQuery()
+	}
+
+	backend.Current.BeginQuery(gl.TIME_ELAPSED, query)
+	p.gpuStage = stage
+	p.gpuPending = append(p.gpuPending, gpuQuery{stage: stage, query: query})
+}
+
+// EndGPU ends the timer query started by BeginGPU
+func (p *Profiler) EndGPU() {
+	if p.gpuStage == "" {
+		return
+	}
+
+	backend.Current.EndQuery(gl.TIME_ELAPSED)
+	p.gpuStage = ""
+}
+
+// EndFrame adds the times accumulated during the frame to the
+// history of each stage, and collects any finished timer queries
+func (p *Profiler) EndFrame() {
+	if !p.Enabled {
+		return
+	}
+
+	p.collectGPU()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for name, d := range p.current {
+		p.stage(name).add(float64(d) / float64(time.Millisecond))
+		delete(p.current, name)
+	}
+	p.Frames++
+}
+
+// collectGPU records the results of finished timer queries. Queries
+// finish in order, so collection stops at the first unfinished one.
+func (p *Profiler) collectGPU() {
+	done := 0
+	for _, q := range p.gpuPending {
+		if q.stage == p.gpuStage || !backend.Current.QueryResultAvailable(q.query) {
+			break
+		}
+		p.Record(GPUPrefix+q.stage, time.Duration(backend.Current.QueryResult(q.query)))
+		p.gpuFree = append(p.gpuFree, q.query)
+		done++
+	}
+	p.gpuPending = p.gpuPending[done:]
+}
+
+// Reset clears the history of every stage
+func (p *Profiler) Reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.stages = make(map[string]*Stage)
+	p.order = nil
+	p.current = make(map[string]time.Duration)
+	p.Frames = 0
+}
+
+func (p *Profiler) stage(name string) *Stage {
+	s, ok := p.stages[name]
+	if !ok {
+		s = newStage(name, p.History)
+		p.stages[name] = s
+		p.order = append(p.order, name)
+	}
+	return s
+}
+
+//  --------------------------------------------------
+//  Reporting
+//  --------------------------------------------------
+
+// Stats is a snapshot of every stage's history
+type Stats struct {
+	Frames int          `json:"frames"`
+	Stages []StageStats `json:"stages"`
+}
+
+// Stats returns the stats of every stage, in the order
+// the stages were first timed
+func (p *Profiler) Stats() Stats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	stats := Stats{Frames: p.Frames, Stages: []StageStats{}}
+	for _, name := range p.order {
+		stats.Stages = append(stats.Stages, p.stages[name].Stats())
+	}
+	return stats
+}
+
+// GetStage returns the stats of a single stage
+func (p *Profiler) GetStage(name string) (StageStats, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	s, ok := p.stages[name]
+	if !ok {
+		return StageStats{Name: name}, false
+	}
+	return s.Stats(), true
+}
+
+// ServeHTTP writes the current stats as JSON
+func (p *Profiler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(p.Stats()); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,98 @@
+package profiler
+
+import (
+	"math"
+	"sort"
+)
+
+// Buckets are the upper bounds, in milliseconds, of the histogram
+// buckets. Times above the last bound go in a final overflow bucket.
+var Buckets = []float64{0.25, 0.5, 1, 2, 4, 8, 16, 33, 66}
+
+// Stage is the rolling history of one stage's per-frame time
+type Stage struct {
+	Name string
+
+	// Ring buffer of times in milliseconds
+	samples []float64
+	next    int
+	count   int
+}
+
+// StageStats summarizes a stage's history. Times are in milliseconds.
+type StageStats struct {
+	Name    string  `json:"name"`
+	Samples int     `json:"samples"`
+	Last    float64 `json:"last"`
+	Avg     float64 `json:"avg"`
+	Min     float64 `json:"min"`
+	Max     float64 `json:"max"`
+	P95     float64 `json:"p95"`
+
+	Histogram Histogram `json:"histogram"`
+}
+
+// Histogram counts samples in each of the Buckets, plus an overflow
+// bucket, so Counts has one more entry than Bounds
+type Histogram struct {
+	Bounds []float64 `json:"bounds"`
+	Counts []int     `json:"counts"`
+}
+
+func newStage(name string, history int) *Stage {
+	return &Stage{
+		Name:    name,
+		samples: make([]float64, history),
+	}
+}
+
+func (s *Stage) add(ms float64) {
+	s.samples[s.next] = ms
+	s.next = (s.next + 1) % len(s.samples)
+	if s.count < len(s.samples) {
+		s.count++
+	}
+}
+
+// Values returns the samples in the history, oldest first
+func (s *Stage) Values() []float64 {
+	values := make([]float64, 0, s.count)
+	start := (s.next - s.count + len(s.samples)) % len(s.samples)
+	for i := 0; i < s.count; i++ {
+		values = append(values, s.samples[(start+i)%len(s.samples)])
+	}
+	return values
+}
+
+// Stats summarizes the history of the stage
+func (s *Stage) Stats() StageStats {
+	stats := StageStats{
+		Name:    s.Name,
+		Samples: s.count,
+		Histogram: Histogram{
+			Bounds: Buckets,
+			Counts: make([]int, len(Buckets)+1),
+		},
+	}
+	if s.count == 0 {
+		return stats
+	}
+
+	values := s.Values()
+	stats.Last = values[len(values)-1]
+	stats.Min = math.Inf(1)
+
+	total := 0.0
+	for _, v := range values {
+		total += v
+		stats.Min = math.Min(stats.Min, v)
+		stats.Max = math.Max(stats.Max, v)
+		stats.Histogram.Counts[sort.SearchFloat64s(Buckets, v)]++
+	}
+	stats.Avg = total / float64(len(values))
+
+	sort.Float64s(values)
+	stats.P95 = values[int(math.Ceil(0.95*float64(len(values))))-1]
+
+	return stats
+}
@@ -0,0 +1,295 @@
+package render
+
+import (
+	"sort"
+
+	"github.com/go-gl/mathgl/mgl32"
+
+	"rapidengine/backend"
+	"rapidengine/geometry"
+	"rapidengine/material"
+)
+
+//  --------------------------------------------------
+//  Queue.go contains the render queue. Instead of
+//  drawing immediately, children submit draw items to
+//  the queue, which is sorted to minimise GL state
+//  changes and then executed in one go.
+//
+//  Items are drawn pass by pass. Opaque items are
+//  grouped by shader, material and texture, and drawn
+//  front to back within each group. Transparent items
+//  are drawn back to front, and overlay items in the
+//  order they were submitted.
+//
+//  Sprites submitted with SubmitSprite are drawn by the
+//  queue's SpriteBatch, so runs of sprites next to each
+//  other in the sorted queue share draw calls.
+//  --------------------------------------------------
+
+// Pass is the stage of the frame an item is drawn in
+type Pass int
+
+const (
+	// PassOpaque items are sorted by state, then front to back
+	PassOpaque Pass = iota
+
+	// PassTransparent items are sorted back to front. Items at the
+	// same depth, such as 2D children, keep their submission order.
+	PassTransparent
+
+	// PassOverlay items are drawn last, in submission order
+	PassOverlay
+)
+
+// Item is a single draw call
+type Item struct {
+	Pass Pass
+
+	Mesh     *geometry.Mesh
+	Material material.Material
+
+	View       mgl32.Mat4
+	Model      mgl32.Mat4
+	Projection mgl32.Mat4
+
+	// Distance in front of the camera, see ViewDepth
+	Depth float32
+
+	Darkness  float32
+	Delta     float64
+	TotalTime float64
+
+	// Index of the item's sprite plus one, or 0 for a mesh
+	sprite int
+
+	key sortKey
+}
+
+// sortKey holds the state an item needs bound, in sort order
+type sortKey struct {
+	shader   uint32
+	material int
+	texture  uint32
+	order    int
+}
+
+// Stats counts the work done by the last Execute
+type Stats struct {
+	Items         int
+	ShaderBinds   int
+	MeshBinds     int
+	MaterialBinds int
+	SpriteFlushes int
+}
+
+type Queue struct {
+	items []Item
+
+	// Sprites of sprite items, and the batch that draws them
+	sprites []Sprite
+	Sprites *SpriteBatch
+
+	// Materials numbered in the order they were first submitted,
+	// so items sharing a material sort next to each other
+	materials map[material.Material]int
+
+	// Stats of the last Execute
+	Stats Stats
+}
+
+func NewQueue() *Queue {
+	return &Queue{
+		materials: make(map[material.Material]int),
+	}
+}
+
+// Submit adds an item to the queue
+func (q *Queue) Submit(item Item) {
+	mat, ok := q.materials[item.Material]
+	if !ok {
+		mat = len(q.materials)
+		q.materials[item.Material] = mat
+	}
+
+	item.key = sortKey{
+		shader:   item.Material.GetShader().GetID(),
+		material: mat,
+		order:    len(q.items),
+	}
+	if tm, ok := item.Material.(material.Textured); ok {
+		item.key.texture = tm.MainTexture()
+	}
+
+	q.items = append(q.items, item)
+}
+
+// SubmitSprite adds a sprite to the queue. It's skipped
+// if the queue has no SpriteBatch.
+func (q *Queue) SubmitSprite(pass Pass, view, projection mgl32.Mat4, depth float32, s Sprite) {
+	if q.Sprites == nil {
+		return
+	}
+
+	shader := s.Shader
+	if shader == nil {
+		shader = q.Sprites.Shader
+	}
+
+	q.sprites = append(q.sprites, s)
+	q.items = append(q.items, Item{
+		Pass:       pass,
+		View:       view,
+		Projection: projection,
+		Depth:      depth,
+		sprite:     len(q.sprites),
+		key: sortKey{
+			shader:   shader.GetID(),
+			material: -1,
+			texture:  s.Texture,
+			order:    len(q.items),
+		},
+	})
+}
+
+// Len returns the number of items in the queue
+func (q *Queue) Len() int {
+	return len(q.items)
+}
+
+// Reset empties the queue, keeping its memory for the next frame
+func (q *Queue) Reset() {
+	q.items = q.items[:0]
+	q.sprites = q.sprites[:0]
+	for m := range q.materials {
+		delete(q.materials, m)
+	}
+}
+
+// Sort orders the items for drawing
+func (q *Queue) Sort() {
+	sort.Slice(q.items, func(i, j int) bool {
+		a, b := &q.items[i], &q.items[j]
+		if a.Pass != b.Pass {
+			return a.Pass < b.Pass
+		}
+
+		switch a.Pass {
+		case PassOpaque:
+			if a.key.shader != b.key.shader {
+				return a.key.shader < b.key.shader
+			}
+			if a.key.material != b.key.material {
+				return a.key.material < b.key.material
+			}
+			if a.key.texture != b.key.texture {
+				return a.key.texture < b.key.texture
+			}
+			if a.Depth != b.Depth {
+				return a.Depth < b.Depth
+			}
+		case PassTransparent:
+			if a.Depth != b.Depth {
+				return a.Depth > b.Depth
+			}
+		}
+
+		return a.key.order < b.key.order
+	})
+}
+
+// Execute draws the items in their current order, skipping shader,
+// mesh, matrix and material state that's already set. Call Sort first.
+func (q *Queue) Execute() {
+	q.Stats = Stats{Items: len(q.items)}
+
+	var shader *material.ShaderProgram
+	var mesh *geometry.Mesh
+	var mat material.Material
+	var darkness float32
+
+	// View and projection last uploaded to each shader
+	type camera struct{ view, projection mgl32.Mat4 }
+	uploaded := make(map[*material.ShaderProgram]*camera)
+
+	for i := range q.items {
+		item := &q.items[i]
+
+		if item.sprite > 0 {
+			if !q.Sprites.IsDrawing() {
+				q.Sprites.Begin(item.View, item.Projection)
+			} else {
+				q.Sprites.SetMatrices(item.View, item.Projection)
+			}
+			q.Sprites.Draw(q.sprites[item.sprite-1])
+			continue
+		}
+
+		// The batch binds its own shader, VAO and uniforms
+		if q.Sprites != nil && q.Sprites.IsDrawing() {
+			q.endSprites()
+			shader, mesh, mat = nil, nil, nil
+			uploaded = make(map[*material.ShaderProgram]*camera)
+		}
+
+		if s := item.Material.GetShader(); s != shader {
+			shader = s
+			shader.Bind()
+			mat = nil
+			q.Stats.ShaderBinds++
+		}
+
+		if item.Mesh != mesh {
+			mesh = item.Mesh
+			mesh.Bind()
+			q.Stats.MeshBinds++
+		}
+		mesh.BindInstancing(shader)
+
+		cam, ok := uploaded[shader]
+		if !ok {
+			cam = &camera{}
+			uploaded[shader] = cam
+		}
+		if !ok || cam.view != item.View {
+			cam.view = item.View
+			backend.Current.UniformMatrix4fv(shader.GetUniform("viewMtx"), 1, false, &cam.view[0])
+		}
+		if !ok || cam.projection != item.Projection {
+			cam.projection = item.Projection
+			backend.Current.UniformMatrix4fv(shader.GetUniform("projectionMtx"), 1, false, &cam.projection[0])
+		}
+		backend.Current.UniformMatrix4fv(shader.GetUniform("modelMtx"), 1, false, &item.Model[0])
+
+		if item.Material != mat || item.Darkness != darkness {
+			mat = item.Material
+			darkness = item.Darkness
+			mat.Render(item.Delta, item.Darkness, item.TotalTime)
+			q.Stats.MaterialBinds++
+		}
+
+		item.Mesh.Draw()
+	}
+
+	if q.Sprites != nil && q.Sprites.IsDrawing() {
+		q.endSprites()
+	}
+}
+
+func (q *Queue) endSprites() {
+	q.Sprites.End()
+	q.Stats.SpriteFlushes += q.Sprites.Stats.Flushes
+}
+
+// Flush sorts and executes the queue, then resets it
+func (q *Queue) Flush() {
+	q.Sort()
+	q.Execute()
+	q.Reset()
+}
+
+// ViewDepth returns how far in front of the camera the origin of
+// a model matrix is, for use as an item's depth
+func ViewDepth(view, model mgl32.Mat4) float32 {
+	return -view.Mul4(model).Col(3).Z()
+}
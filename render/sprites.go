@@ -0,0 +1,264 @@
+package render
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+
+	"rapidengine/backend"
+	"rapidengine/configuration"
+	"rapidengine/material"
+)
+
+//  --------------------------------------------------
+//  Sprites.go contains the sprite batcher. Sprites are
+//  quads in screen pixels, which the batcher writes into
+//  a dynamic vertex buffer and draws together, so many
+//  sprites cost a single draw call. The batch is drawn
+//  early whenever the texture, shader or camera changes,
+//  or the buffer is full.
+//
+//  To draw sprites immediately:
+//
+//    batch.Begin(view, projection)
+//    batch.Draw(render.Sprite{...})
+//    batch.End()
+//
+//  Sprites can also be submitted to a Queue, which
+//  draws them in order with the rest of the frame.
+//  --------------------------------------------------
+
+// DefaultMaxSprites is the number of sprites drawn per batch
+const DefaultMaxSprites = 4096
+
+// Floats per sprite vertex: position, UV and tint
+const spriteVertexSize = 2 + 2 + 4
+
+// Sprite is a textured, tinted quad
+type Sprite struct {
+	// Bottom left corner and size, in pixels
+	X      float32
+	Y      float32
+	Width  float32
+	Height float32
+
+	// Rotation around the center, in radians
+	Rotation float32
+
+	// Area of the texture to draw, as u0, v0, u1, v1,
+	// with v0 at the top. Zero draws the whole texture.
+	UV [4]float32
+
+	// Colour the texture is multiplied by, from 0 to 1.
+	// Zero draws the texture as it is.
+	Tint [4]float32
+
+	// Texture to draw, or 0 for a quad of the tint colour
+	Texture uint32
+
+	// Shader to draw with, or nil for the batch's shader. It
+	// must take the same vertex layout as SpriteProgram.
+	Shader *material.ShaderProgram
+}
+
+// SpriteStats counts the work done by a batch since Begin
+type SpriteStats struct {
+	Sprites int
+	Flushes int
+}
+
+type SpriteBatch struct {
+	// Default shader for sprites
+	Shader *material.ShaderProgram
+
+	// Sprites drawn per draw call, which the element buffer is sized for
+	maxSprites int
+
+	vao           uint32
+	vertexBuffer  uint32
+	elementBuffer uint32
+
+	vertices []float32
+	count    int
+
+	// State of the sprites waiting to be drawn
+	texture    uint32
+	shader     *material.ShaderProgram
+	view       mgl32.Mat4
+	projection mgl32.Mat4
+	drawing    bool
+
+	// Stats since the last Begin
+	Stats SpriteStats
+
+	config *configuration.EngineConfig
+}
+
+// NewSpriteBatch creates the buffers for a batch of up to maxSprites
+// sprites, which must be at least 1. Positions are converted from
+// pixels using the screen size in config.
+func NewSpriteBatch(shader *material.ShaderProgram, maxSprites int, config *configuration.EngineConfig) *SpriteBatch {
+	if maxSprites < 1 {
+		panic(fmt.Sprintf("sprite batch of %v sprites, must be at least 1", maxSprites))
+	}
+
+	b := &SpriteBatch{
+		Shader:     shader,
+		maxSprites: maxSprites,
+		vertices:   make([]float32, 0, maxSprites*4*spriteVertexSize),
+		config:     config,
+	}
+
+	b.vao = backend.Current.GenVertexArray()
+	backend.Current.BindVertexArray(b.vao)
+
+	// Every quad uses the same pattern of indices, so they're only uploaded once
+	indices := make([]uint32, 0, maxSprites*6)
+	for i := uint32(0); i < uint32(maxSprites); i++ {
+		v := i * 4
+		indices = append(indices, v, v+1, v+2, v+2, v, v+3)
+	}
+	b.elementBuffer = backend.Current.GenBuffer()
+	backend.Current.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, b.elementBuffer)
+	backend.Current.BufferUints(gl.ELEMENT_ARRAY_BUFFER, indices, gl.STATIC_DRAW)
+
+	b.vertexBuffer = backend.Current.GenBuffer()
+	backend.Current.BindBuffer(gl.ARRAY_BUFFER, b.vertexBuffer)
+
+	stride := int32(spriteVertexSize * 4)
+	backend.Current.VertexAttribPointer(0, 2, gl.FLOAT, false, stride, 0)
+	backend.Current.VertexAttribPointer(1, 2, gl.FLOAT, false, stride, 2*4)
+	backend.Current.VertexAttribPointer(2, 4, gl.FLOAT, false, stride, 4*4)
+	for i := uint32(0); i < 3; i++ {
+		backend.Current.EnableVertexAttribArray(i)
+	}
+
+	return b
+}
+
+// Begin starts a batch drawn with a view and projection matrix
+func (b *SpriteBatch) Begin(view, projection mgl32.Mat4) {
+	b.Stats = SpriteStats{}
+	b.drawing = true
+	b.SetMatrices(view, projection)
+}
+
+// SetMatrices changes the view and projection, drawing
+// any sprites waiting for the old ones
+func (b *SpriteBatch) SetMatrices(view, projection mgl32.Mat4) {
+	if b.count > 0 && (view != b.view || projection != b.projection) {
+		b.Flush()
+	}
+	b.view = view
+	b.projection = projection
+}
+
+// Draw adds a sprite to the batch
+func (b *SpriteBatch) Draw(s Sprite) {
+	shader := s.Shader
+	if shader == nil {
+		shader = b.Shader
+	}
+
+	if b.count > 0 && (s.Texture != b.texture || shader != b.shader) {
+		b.Flush()
+	}
+	if b.count == b.maxSprites {
+		b.Flush()
+	}
+	b.texture = s.Texture
+	b.shader = shader
+
+	uv := s.UV
+	if uv == [4]float32{} {
+		uv = [4]float32{0, 0, 1, 1}
+	}
+	tint := s.Tint
+	if tint == [4]float32{} {
+		tint = [4]float32{1, 1, 1, 1}
+	}
+
+	// Corners relative to the center, counter-clockwise from the bottom left
+	hw, hh := s.Width/2, s.Height/2
+	cx, cy := s.X+hw, s.Y+hh
+	corners := [4][4]float32{
+		{-hw, -hh, uv[0], uv[3]},
+		{hw, -hh, uv[2], uv[3]},
+		{hw, hh, uv[2], uv[1]},
+		{-hw, hh, uv[0], uv[1]},
+	}
+
+	sin, cos := float32(0), float32(1)
+	if s.Rotation != 0 {
+		sin64, cos64 := math.Sincos(float64(s.Rotation))
+		sin, cos = float32(sin64), float32(cos64)
+	}
+
+	for _, c := range corners {
+		b.vertices = append(b.vertices,
+			cx+c[0]*cos-c[1]*sin, cy+c[0]*sin+c[1]*cos,
+			c[2], c[3],
+			tint[0], tint[1], tint[2], tint[3],
+		)
+	}
+
+	b.count++
+	b.Stats.Sprites++
+}
+
+// Flush draws the sprites waiting in the batch
+func (b *SpriteBatch) Flush() {
+	if b.count == 0 {
+		return
+	}
+
+	b.shader.Bind()
+	backend.Current.BindVertexArray(b.vao)
+
+	backend.Current.BindBuffer(gl.ARRAY_BUFFER, b.vertexBuffer)
+	backend.Current.BufferFloats(gl.ARRAY_BUFFER, b.vertices, gl.STREAM_DRAW)
+
+	// Positions are in pixels, and the quad is drawn in the -1 to 1 range
+	sw, sh := float32(b.config.ScreenWidth), float32(b.config.ScreenHeight)
+	model := mgl32.Translate3D(-1, -1, 0).Mul4(mgl32.Scale3D(2/sw, 2/sh, 1))
+
+	backend.Current.UniformMatrix4fv(b.shader.GetUniform("modelMtx"), 1, false, &model[0])
+	backend.Current.UniformMatrix4fv(b.shader.GetUniform("viewMtx"), 1, false, &b.view[0])
+	backend.Current.UniformMatrix4fv(b.shader.GetUniform("projectionMtx"), 1, false, &b.projection[0])
+
+	textured := int32(0)
+	if b.texture != 0 {
+		textured = 1
+		backend.Current.ActiveTexture(gl.TEXTURE0)
+		backend.Current.BindTexture(gl.TEXTURE_2D, b.texture)
+	}
+	backend.Current.Uniform1i(b.shader.GetUniform("diffuseMap"), 0)
+	backend.Current.Uniform1i(b.shader.GetUniform("textured"), textured)
+
+	backend.Current.Enable(gl.BLEND)
+	backend.Current.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
+
+	backend.Current.DrawElements(gl.TRIANGLES, int32(b.count*6), gl.UNSIGNED_INT, 0)
+
+	b.vertices = b.vertices[:0]
+	b.count = 0
+	b.Stats.Flushes++
+}
+
+// End draws the rest of the batch
+func (b *SpriteBatch) End() {
+	b.Flush()
+	b.drawing = false
+}
+
+// MaxSprites returns the number of sprites drawn per draw call
+func (b *SpriteBatch) MaxSprites() int {
+	return b.maxSprites
+}
+
+// IsDrawing checks if the batch is between Begin and End
+func (b *SpriteBatch) IsDrawing() bool {
+	return b.drawing
+}
@@ -0,0 +1,50 @@
+package render
+
+import (
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+
+	"rapidengine/backend"
+	"rapidengine/configuration"
+	"rapidengine/material"
+)
+
+func newTestBatch(t *testing.T, maxSprites int) (*SpriteBatch, *backend.NullBackend) {
+	t.Helper()
+
+	config := configuration.EngineConfig{ScreenWidth: 800, ScreenHeight: 600, MaxFPS: 60}
+	null := backend.NewNullBackend(&config)
+	backend.Current = null
+	return NewSpriteBatch(&material.SpriteProgram, maxSprites, &config), null
+}
+
+func TestSpriteBatchFlushesWhenFull(t *testing.T) {
+	b, null := newTestBatch(t, 2)
+	if b.MaxSprites() != 2 {
+		t.Fatalf("MaxSprites %v, want 2", b.MaxSprites())
+	}
+
+	b.Begin(mgl32.Ident4(), mgl32.Ident4())
+	for i := 0; i < 5; i++ {
+		b.Draw(Sprite{X: float32(i), Width: 1, Height: 1})
+	}
+	b.End()
+
+	if b.Stats.Sprites != 5 || b.Stats.Flushes != 3 || null.DrawCalls != 3 {
+		t.Errorf("drew %v sprites in %v flushes and %v draw calls, want 5 in 3", b.Stats.Sprites, b.Stats.Flushes, null.DrawCalls)
+	}
+}
+
+func TestSpriteBatchSize(t *testing.T) {
+	for _, maxSprites := range []int{0, -1} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("batch of %v sprites accepted", maxSprites)
+				}
+			}()
+			newTestBatch(t, maxSprites)
+		}()
+	}
+}
@@ -0,0 +1,191 @@
+package render
+
+import (
+	"github.com/go-gl/gl/v4.1-core/gl"
+
+	"rapidengine/backend"
+	"rapidengine/camera"
+	"rapidengine/material"
+)
+
+//  --------------------------------------------------
+//  View.go contains views, which are cameras the
+//  renderer draws the scene through. Each view has its
+//  own area of the screen, clear settings and layers,
+//  and can draw into a texture instead of the screen.
+//  Views are drawn in order of priority, so views with
+//  a higher priority are drawn on top.
+//
+//  Split-screen uses two views with half the screen
+//  each. A minimap or security monitor uses a view with
+//  a RenderTarget, whose Texture can be attached to any
+//  material.
+//  --------------------------------------------------
+
+// Rect is an area of the screen or render target, from 0
+// to 1 on each axis, with the origin at the bottom left
+type Rect struct {
+	X      float32
+	Y      float32
+	Width  float32
+	Height float32
+}
+
+// FullRect covers the whole screen
+var FullRect = Rect{0, 0, 1, 1}
+
+// AllLayers is a layer mask that includes every layer
+const AllLayers = ^uint32(0)
+
+// LayerMask returns the mask for a single layer, from 0 to 31
+func LayerMask(layer int) uint32 {
+	return 1 << uint(layer)
+}
+
+type View struct {
+	Name   string
+	Camera camera.Camera
+
+	// Area drawn to
+	Viewport Rect
+
+	// Whether the viewport is cleared before drawing,
+	// and the colour it's cleared to
+	ClearColor bool
+	ClearDepth bool
+	Color      [4]float32
+
+	// Whether the renderer's skybox is drawn behind the view
+	SkyBox bool
+
+	// Layers of children drawn by the view, see LayerMask
+	Layers uint32
+
+	// Views are drawn from lowest to highest priority
+	Priority int
+
+	// Texture the view is drawn into, or nil for the screen
+	Target *RenderTarget
+
+	Enabled bool
+}
+
+// NewView creates a view that draws every layer to the whole screen
+func NewView(name string, c camera.Camera) *View {
+	return &View{
+		Name:       name,
+		Camera:     c,
+		Viewport:   FullRect,
+		ClearColor: true,
+		ClearDepth: true,
+		Color:      [4]float32{0, 0, 0, 1},
+		SkyBox:     true,
+		Layers:     AllLayers,
+		Enabled:    true,
+	}
+}
+
+// Pixels returns the view's viewport in pixels, for a
+// screen or render target of the given size
+func (v *View) Pixels(width, height int) (x, y, w, h int32) {
+	return int32(v.Viewport.X * float32(width)),
+		int32(v.Viewport.Y * float32(height)),
+		int32(v.Viewport.Width * float32(width)),
+		int32(v.Viewport.Height * float32(height))
+}
+
+// Aspect returns the width of the view's viewport over its
+// height, for a screen of the given size
+func (v *View) Aspect(width, height int) float32 {
+	if v.Target != nil {
+		width, height = int(v.Target.Width), int(v.Target.Height)
+	}
+	_, _, w, h := v.Pixels(width, height)
+	if h == 0 {
+		return 1
+	}
+	return float32(w) / float32(h)
+}
+
+// Bind binds the framebuffer the view draws into, sets the viewport
+// and clears it. screen is the framebuffer used for the screen,
+// which is of size width by height.
+func (v *View) Bind(screen uint32, width, height int) {
+	if v.Target != nil {
+		backend.Current.BindFramebuffer(gl.FRAMEBUFFER, v.Target.Framebuffer)
+		width, height = int(v.Target.Width), int(v.Target.Height)
+	} else {
+		backend.Current.BindFramebuffer(gl.FRAMEBUFFER, screen)
+	}
+
+	x, y, w, h := v.Pixels(width, height)
+	backend.Current.Viewport(x, y, w, h)
+
+	var mask uint32
+	if v.ClearColor {
+		mask |= gl.COLOR_BUFFER_BIT
+		backend.Current.ClearColor(v.Color[0], v.Color[1], v.Color[2], v.Color[3])
+	}
+	if v.ClearDepth {
+		mask |= gl.DEPTH_BUFFER_BIT
+	}
+	if mask == 0 {
+		return
+	}
+
+	// Clear ignores the viewport, so it's limited with a scissor
+	backend.Current.Enable(gl.SCISSOR_TEST)
+	backend.Current.Scissor(x, y, w, h)
+	backend.Current.Clear(mask)
+	backend.Current.Disable(gl.SCISSOR_TEST)
+}
+
+//  --------------------------------------------------
+//  Render Targets
+//  --------------------------------------------------
+
+// RenderTarget is a framebuffer with a colour texture
+// and a depth buffer, which views can draw into
+type RenderTarget struct {
+	Framebuffer uint32
+	Depth       uint32
+
+	// The colour attachment, which can be used by any material
+	Texture *material.Texture
+
+	Width  int32
+	Height int32
+}
+
+// NewRenderTarget creates a render target of the given size
+func NewRenderTarget(name string, width, height int32) *RenderTarget {
+	t := &RenderTarget{Width: width, Height: height}
+
+	t.Framebuffer = backend.Current.GenFramebuffer()
+	backend.Current.BindFramebuffer(gl.FRAMEBUFFER, t.Framebuffer)
+
+	texture := backend.Current.GenTexture()
+	backend.Current.BindTexture(gl.TEXTURE_2D, texture)
+	backend.Current.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA, width, height, gl.RGBA, gl.UNSIGNED_BYTE, nil)
+	backend.Current.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
+	backend.Current.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
+	backend.Current.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+	backend.Current.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+	t.Texture = &material.Texture{Name: name, Filter: "linear", Addr: &texture}
+
+	t.Depth = backend.Current.GenRenderbuffer()
+	backend.Current.BindRenderbuffer(gl.RENDERBUFFER, t.Depth)
+	backend.Current.RenderbufferStorage(gl.RENDERBUFFER, gl.DEPTH_COMPONENT, width, height)
+	backend.Current.FramebufferRenderbuffer(gl.FRAMEBUFFER, gl.DEPTH_ATTACHMENT, gl.RENDERBUFFER, t.Depth)
+
+	backend.Current.FramebufferTexture(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0, texture, 0)
+	backend.Current.DrawBuffers([]uint32{gl.COLOR_ATTACHMENT0})
+
+	if backend.Current.CheckFramebufferStatus(gl.FRAMEBUFFER) != gl.FRAMEBUFFER_COMPLETE {
+		panic("Framebuffer Invalid")
+	}
+
+	backend.Current.BindFramebuffer(gl.FRAMEBUFFER, 0)
+
+	return t
+}
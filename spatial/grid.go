@@ -0,0 +1,139 @@
+package spatial
+
+import (
+	"math"
+	"sort"
+)
+
+//  --------------------------------------------------
+//  Grid.go contains a uniform grid, which indexes points
+//  by the square cell they fall in. Rectangle queries
+//  only visit the cells the rectangle covers, so finding
+//  what's near a point doesn't depend on how many points
+//  there are in total.
+//  --------------------------------------------------
+
+// DefaultCellSize is the cell size used for child copies, in pixels
+const DefaultCellSize = 256
+
+type cell struct {
+	x, y int32
+}
+
+type Grid struct {
+	CellSize float32
+
+	cells map[cell][]int
+
+	// Cell of every indexed ID
+	items map[int]cell
+}
+
+func NewGrid(cellSize float32) *Grid {
+	return &Grid{
+		CellSize: cellSize,
+		cells:    make(map[cell][]int),
+		items:    make(map[int]cell),
+	}
+}
+
+func (g *Grid) cellAt(x, y float32) cell {
+	return cell{
+		x: int32(math.Floor(float64(x / g.CellSize))),
+		y: int32(math.Floor(float64(y / g.CellSize))),
+	}
+}
+
+// Insert indexes id at a position. If id is
+// already indexed, it's moved instead.
+func (g *Grid) Insert(id int, x, y float32) {
+	if _, ok := g.items[id]; ok {
+		g.Move(id, x, y)
+		return
+	}
+
+	c := g.cellAt(x, y)
+	g.cells[c] = append(g.cells[c], id)
+	g.items[id] = c
+}
+
+// Move updates the position of id. Only moves
+// between cells change the index.
+func (g *Grid) Move(id int, x, y float32) {
+	old, ok := g.items[id]
+	if !ok {
+		g.Insert(id, x, y)
+		return
+	}
+
+	c := g.cellAt(x, y)
+	if c == old {
+		return
+	}
+
+	g.removeFromCell(old, id)
+	g.cells[c] = append(g.cells[c], id)
+	g.items[id] = c
+}
+
+// Remove removes id from the index
+func (g *Grid) Remove(id int) {
+	if c, ok := g.items[id]; ok {
+		g.removeFromCell(c, id)
+		delete(g.items, id)
+	}
+}
+
+func (g *Grid) removeFromCell(c cell, id int) {
+	ids := g.cells[c]
+	for i, other := range ids {
+		if other == id {
+			ids[i] = ids[len(ids)-1]
+			ids = ids[:len(ids)-1]
+			break
+		}
+	}
+
+	if len(ids) == 0 {
+		delete(g.cells, c)
+	} else {
+		g.cells[c] = ids
+	}
+}
+
+// Clear removes everything from the index
+func (g *Grid) Clear() {
+	g.cells = make(map[cell][]int)
+	g.items = make(map[int]cell)
+}
+
+// Len returns the number of indexed IDs
+func (g *Grid) Len() int {
+	return len(g.items)
+}
+
+// Query appends the IDs in every cell overlapping the rectangle to out,
+// in ascending order. IDs near the edges may lie just outside the
+// rectangle, so callers should check the exact positions.
+func (g *Grid) Query(minX, minY, maxX, maxY float32, out []int) []int {
+	start := len(out)
+	lo, hi := g.cellAt(minX, minY), g.cellAt(maxX, maxY)
+
+	// Large queries over a sparse grid visit the occupied cells instead
+	if area := int64(hi.x-lo.x+1) * int64(hi.y-lo.y+1); area > int64(len(g.cells)) {
+		for c, ids := range g.cells {
+			if c.x >= lo.x && c.x <= hi.x && c.y >= lo.y && c.y <= hi.y {
+				out = append(out, ids...)
+			}
+		}
+	} else {
+		for x := lo.x; x <= hi.x; x++ {
+			for y := lo.y; y <= hi.y; y++ {
+				out = append(out, g.cells[cell{x, y}]...)
+			}
+		}
+	}
+
+	sort.Ints(out[start:])
+	return out
+}
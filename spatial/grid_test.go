@@ -0,0 +1,86 @@
+package spatial
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGridQuery(t *testing.T) {
+	g := NewGrid(10)
+	g.Insert(5, 1, 1)     // cell 0, 0
+	g.Insert(3, 9.9, 0)   // cell 0, 0, at the edge
+	g.Insert(1, 10, 0)    // cell 1, 0
+	g.Insert(4, -0.1, 0)  // cell -1, 0
+	g.Insert(2, -10, -10) // cell -1, -1
+	g.Insert(0, -11, 0)   // cell -2, 0
+	g.Insert(6, 55, 55)   // cell 5, 5
+
+	for _, test := range []struct {
+		minX, minY, maxX, maxY float32
+		want                   []int
+	}{
+		{0, 0, 9, 9, []int{3, 5}},
+		{0, 0, 10, 0, []int{1, 3, 5}},
+		{-1, -1, 1, 1, []int{2, 3, 4, 5}},
+		{-10, -10, -10, -10, []int{2}},
+		{-20, 0, -11, 0, []int{0}},
+		{50, 50, 60, 60, []int{6}},
+		{100, 100, 200, 200, []int{}},
+
+		// Larger than the grid, so the occupied cells are visited instead
+		{-1000, -1000, 1000, 1000, []int{0, 1, 2, 3, 4, 5, 6}},
+	} {
+		got := g.Query(test.minX, test.minY, test.maxX, test.maxY, []int{})
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("query %v, %v to %v, %v: got %v, want %v", test.minX, test.minY, test.maxX, test.maxY, got, test.want)
+		}
+	}
+
+	// Results are appended, and only the new ones are sorted
+	if got := g.Query(0, 0, 9, 9, []int{99}); !reflect.DeepEqual(got, []int{99, 3, 5}) {
+		t.Errorf("appended query got %v", got)
+	}
+}
+
+func TestGridMove(t *testing.T) {
+	g := NewGrid(10)
+	g.Insert(0, 5, 5)
+	g.Insert(1, 5, 5)
+
+	// Within a cell
+	g.Move(0, 6, 6)
+	if got := g.Query(0, 0, 9, 9, nil); !reflect.DeepEqual(got, []int{0, 1}) {
+		t.Errorf("after a move within the cell: %v", got)
+	}
+
+	// Across a cell boundary, into negative coordinates
+	g.Move(0, -5, 5)
+	if got := g.Query(0, 0, 9, 9, nil); !reflect.DeepEqual(got, []int{1}) {
+		t.Errorf("old cell holds %v", got)
+	}
+	if got := g.Query(-9, 0, -1, 9, nil); !reflect.DeepEqual(got, []int{0}) {
+		t.Errorf("new cell holds %v", got)
+	}
+
+	// Inserting an indexed ID moves it, and moving an unindexed one inserts it
+	g.Insert(1, -5, 5)
+	g.Move(2, 25, 5)
+	if got := g.Query(-9, 0, -1, 9, nil); !reflect.DeepEqual(got, []int{0, 1}) {
+		t.Errorf("after re-inserting: %v", got)
+	}
+	if got := g.Query(20, 0, 29, 9, nil); !reflect.DeepEqual(got, []int{2}) {
+		t.Errorf("after moving an unindexed ID: %v", got)
+	}
+	if g.Len() != 3 {
+		t.Errorf("Len %v, want 3", g.Len())
+	}
+
+	g.Remove(0)
+	if got := g.Query(-9, 0, -1, 9, nil); !reflect.DeepEqual(got, []int{1}) {
+		t.Errorf("after Remove: %v", got)
+	}
+	g.Clear()
+	if g.Len() != 0 || len(g.Query(-100, -100, 100, 100, nil)) != 0 {
+		t.Error("Clear left IDs behind")
+	}
+}
@@ -0,0 +1,284 @@
+package state
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+
+	"rapidengine/backend"
+)
+
+//  --------------------------------------------------
+//  Cache.go contains the GL state cache. It wraps the
+//  render backend, remembers the program, VAO, texture
+//  bindings, framebuffers, capabilities, blend function,
+//  depth mask and viewport last set, and drops calls
+//  that wouldn't change any of them. Every other call
+//  is passed straight through to the backend.
+//
+//  The renderer owns the cache and installs it as
+//  backend.Current, so the whole engine goes through it.
+//  Code that changes GL state without the backend, such
+//  as text rendering, must call Invalidate afterwards.
+//  --------------------------------------------------
+
+// unknown marks a binding the cache can't vouch for
+const unknown = ^uint32(0)
+
+// Counter counts the calls made for one kind of state
+type Counter struct {
+	// Calls passed through to the backend
+	Issued int `json:"issued"`
+
+	// Redundant calls dropped by the cache
+	Skipped int `json:"skipped"`
+}
+
+func (c *Counter) count(changed bool) bool {
+	if changed {
+		c.Issued++
+	} else {
+		c.Skipped++
+	}
+	return changed
+}
+
+// Stats holds a counter for each kind of state tracked
+type Stats struct {
+	Programs     Counter `json:"programs"`
+	VertexArrays Counter `json:"vertexArrays"`
+	ActiveUnits  Counter `json:"activeUnits"`
+	Textures     Counter `json:"textures"`
+	Framebuffers Counter `json:"framebuffers"`
+	Capabilities Counter `json:"capabilities"`
+	BlendFuncs   Counter `json:"blendFuncs"`
+	DepthMasks   Counter `json:"depthMasks"`
+	Viewports    Counter `json:"viewports"`
+}
+
+// Skipped returns the total number of calls dropped
+func (s Stats) Skipped() int {
+	total := 0
+	for _, c := range s.counters() {
+		total += c.Skipped
+	}
+	return total
+}
+
+// Issued returns the total number of calls passed through
+func (s Stats) Issued() int {
+	total := 0
+	for _, c := range s.counters() {
+		total += c.Issued
+	}
+	return total
+}
+
+func (s Stats) counters() []Counter {
+	return []Counter{
+		s.Programs, s.VertexArrays, s.ActiveUnits, s.Textures, s.Framebuffers,
+		s.Capabilities, s.BlendFuncs, s.DepthMasks, s.Viewports,
+	}
+}
+
+type textureBinding struct {
+	unit   uint32
+	target uint32
+}
+
+type Cache struct {
+	backend.Backend
+
+	program     uint32
+	vertexArray uint32
+	activeUnit  uint32
+	textures    map[textureBinding]uint32
+
+	drawFramebuffer uint32
+	readFramebuffer uint32
+
+	capabilities map[uint32]bool
+
+	blendSrc uint32
+	blendDst uint32
+
+	depthMask      bool
+	depthMaskKnown bool
+
+	viewport      [4]int32
+	viewportKnown bool
+
+	// Counters for the frame in progress
+	Stats Stats
+
+	// Counters for the last complete frame, which are read by
+	// the HTTP endpoint on another goroutine, see LastFrame
+	lastFrame Stats
+	mu        sync.Mutex
+}
+
+// NewCache wraps a backend. Nothing is assumed about
+// its state until it's first set through the cache.
+func NewCache(b backend.Backend) *Cache {
+	c := &Cache{Backend: b}
+	c.Invalidate()
+	return c
+}
+
+// Invalidate forgets all tracked state, so the next call
+// for each kind of state reaches the backend
+func (c *Cache) Invalidate() {
+	c.program = unknown
+	c.vertexArray = unknown
+	c.activeUnit = unknown
+	c.textures = make(map[textureBinding]uint32)
+
+	c.drawFramebuffer = unknown
+	c.readFramebuffer = unknown
+
+	c.capabilities = make(map[uint32]bool)
+
+	c.blendSrc = unknown
+	c.blendDst = unknown
+
+	c.depthMaskKnown = false
+	c.viewportKnown = false
+}
+
+// EndFrame moves the counters of the frame in progress to LastFrame
+func (c *Cache) EndFrame() {
+	c.mu.Lock()
+	c.lastFrame = c.Stats
+	c.mu.Unlock()
+	c.Stats = Stats{}
+}
+
+// LastFrame returns the counters of the last complete frame.
+// It's safe to call from any goroutine.
+func (c *Cache) LastFrame() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lastFrame
+}
+
+// ServeHTTP serves the counters of the last frame as JSON
+func (c *Cache) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	last := c.LastFrame()
+
+	w.Header().Set("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(struct {
+		Stats
+		Issued  int `json:"issued"`
+		Skipped int `json:"skipped"`
+	}{last, last.Issued(), last.Skipped()})
+}
+
+//  --------------------------------------------------
+//  Tracked Calls
+//  --------------------------------------------------
+
+func (c *Cache) UseProgram(program uint32) {
+	if c.Stats.Programs.count(c.program != program) {
+		c.program = program
+		c.Backend.UseProgram(program)
+	}
+}
+
+func (c *Cache) BindVertexArray(vao uint32) {
+	if c.Stats.VertexArrays.count(c.vertexArray != vao) {
+		c.vertexArray = vao
+		c.Backend.BindVertexArray(vao)
+	}
+}
+
+func (c *Cache) ActiveTexture(unit uint32) {
+	if c.Stats.ActiveUnits.count(c.activeUnit != unit) {
+		c.activeUnit = unit
+		c.Backend.ActiveTexture(unit)
+	}
+}
+
+func (c *Cache) BindTexture(target, texture uint32) {
+	binding := textureBinding{unit: c.activeUnit, target: target}
+
+	// The active unit must be known to know what's bound to it
+	bound, ok := c.textures[binding]
+	if c.Stats.Textures.count(c.activeUnit == unknown || !ok || bound != texture) {
+		if c.activeUnit != unknown {
+			c.textures[binding] = texture
+		}
+		c.Backend.BindTexture(target, texture)
+	}
+}
+
+func (c *Cache) BindFramebuffer(target, framebuffer uint32) {
+	var changed bool
+	switch target {
+	case gl.DRAW_FRAMEBUFFER:
+		changed = c.drawFramebuffer != framebuffer
+		c.drawFramebuffer = framebuffer
+	case gl.READ_FRAMEBUFFER:
+		changed = c.readFramebuffer != framebuffer
+		c.readFramebuffer = framebuffer
+	default:
+		changed = c.drawFramebuffer != framebuffer || c.readFramebuffer != framebuffer
+		c.drawFramebuffer = framebuffer
+		c.readFramebuffer = framebuffer
+	}
+
+	if c.Stats.Framebuffers.count(changed) {
+		c.Backend.BindFramebuffer(target, framebuffer)
+	}
+}
+
+func (c *Cache) Enable(capability uint32) {
+	if c.Stats.Capabilities.count(c.setCapability(capability, true)) {
+		c.Backend.Enable(capability)
+	}
+}
+
+func (c *Cache) Disable(capability uint32) {
+	if c.Stats.Capabilities.count(c.setCapability(capability, false)) {
+		c.Backend.Disable(capability)
+	}
+}
+
+// setCapability records a capability, and returns whether it changed
+func (c *Cache) setCapability(capability uint32, enabled bool) bool {
+	current, ok := c.capabilities[capability]
+	c.capabilities[capability] = enabled
+	return !ok || current != enabled
+}
+
+// IsEnabled returns whether a capability is enabled, and
+// whether the cache knows its state at all
+func (c *Cache) IsEnabled(capability uint32) (enabled, known bool) {
+	enabled, known = c.capabilities[capability]
+	return
+}
+
+func (c *Cache) BlendFunc(src, dst uint32) {
+	if c.Stats.BlendFuncs.count(c.blendSrc != src || c.blendDst != dst) {
+		c.blendSrc, c.blendDst = src, dst
+		c.Backend.BlendFunc(src, dst)
+	}
+}
+
+func (c *Cache) DepthMask(flag bool) {
+	if c.Stats.DepthMasks.count(!c.depthMaskKnown || c.depthMask != flag) {
+		c.depthMask, c.depthMaskKnown = flag, true
+		c.Backend.DepthMask(flag)
+	}
+}
+
+func (c *Cache) Viewport(x, y, width, height int32) {
+	viewport := [4]int32{x, y, width, height}
+	if c.Stats.Viewports.count(!c.viewportKnown || c.viewport != viewport) {
+		c.viewport, c.viewportKnown = viewport, true
+		c.Backend.Viewport(x, y, width, height)
+	}
+}
@@ -1,9 +0,0 @@
-package state
-
-var BoundTexture0 uint32
-var BoundTexture1 uint32
-var BoundTexture2 uint32
-var BoundTexture3 uint32
-var BoundTexture4 uint32
-var BoundTexture5 uint32
-var BoundTexture6 uint32
@@ -1,6 +1,7 @@
 package terrain
 
 import (
+	"rapidengine/backend"
 	"rapidengine/camera"
 	"rapidengine/geometry"
 	"rapidengine/material"
@@ -20,13 +21,14 @@ type SkyBox struct {
 	modelMatrix      mgl32.Mat4
 }
 
-func NewSkyBox(mat *material.CubemapMaterial, vao *geometry.VertexArray, projMtx, modelMtx mgl32.Mat4, shaders []*material.ShaderProgram) *SkyBox {
+// NewSkyBox creates a skybox. It's drawn with the projection
+// of the camera passed to Render.
+func NewSkyBox(mat *material.CubemapMaterial, vao *geometry.VertexArray, modelMtx mgl32.Mat4, shaders []*material.ShaderProgram) *SkyBox {
 	return &SkyBox{
-		material:         mat,
-		vao:              vao,
-		projectionMatrix: projMtx,
-		modelMatrix:      modelMtx,
-		shaders:          shaders,
+		material:    mat,
+		vao:         vao,
+		modelMatrix: modelMtx,
+		shaders:     shaders,
 	}
 }
 
@@ -37,14 +39,16 @@ func (skyBox *SkyBox) Render(mainCamera camera.Camera) {
 	for _, shader := range skyBox.shaders {
 		shader.Bind()
 		skyBox.material.Render(0, 1, 0)
-		gl.Uniform1i(shader.GetUniform("cubeDiffuseMap"), 6)
+		backend.Current.Uniform1i(shader.GetUniform("cubeDiffuseMap"), 6)
 	}
 
-	gl.DepthMask(false)
+	backend.Current.DepthMask(false)
 
 	skyBox.material.GetShader().Bind()
 	skyBox.material.Render(0, 1, 0)
-	gl.BindVertexArray(skyBox.vao.GetID())
+	backend.Current.BindVertexArray(skyBox.vao.GetID())
+
+	skyBox.projectionMatrix = mainCamera.GetProjection()
 
 	x, y, z := mainCamera.GetPosition()
 	skyBox.modelMatrix = mgl32.Translate3D(x, y, z)
@@ -52,26 +56,26 @@ func (skyBox *SkyBox) Render(mainCamera camera.Camera) {
 	skyBox.modelMatrix = skyBox.modelMatrix.Mul4(mgl32.HomogRotate3DY(e))
 	e += 0.00001
 
-	gl.UniformMatrix4fv(
+	backend.Current.UniformMatrix4fv(
 		skyBox.material.GetShader().GetUniform("modelMtx"),
 		1, false, &skyBox.modelMatrix[0],
 	)
 
-	gl.UniformMatrix4fv(
+	backend.Current.UniformMatrix4fv(
 		skyBox.material.GetShader().GetUniform("viewMtx"),
 		1, false, mainCamera.GetFirstViewIndex(),
 	)
 
-	gl.UniformMatrix4fv(
+	backend.Current.UniformMatrix4fv(
 		skyBox.material.GetShader().GetUniform("projectionMtx"),
 		1, false, &skyBox.projectionMatrix[0],
 	)
 
-	gl.EnableVertexAttribArray(0)
-	gl.EnableVertexAttribArray(1)
+	backend.Current.EnableVertexAttribArray(0)
+	backend.Current.EnableVertexAttribArray(1)
 
-	gl.DrawElements(gl.TRIANGLES, 108, gl.UNSIGNED_INT, gl.PtrOffset(0))
-	gl.DepthMask(true)
+	backend.Current.DrawElements(gl.TRIANGLES, 108, gl.UNSIGNED_INT, 0)
+	backend.Current.DepthMask(true)
 }
 
 var SkyBoxVertices = []float32{
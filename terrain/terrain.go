@@ -1,6 +1,10 @@
 package terrain
 
 import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+
 	"rapidengine/child"
 	"rapidengine/material"
 )
@@ -9,6 +13,12 @@ type Terrain struct {
 	width  int
 	height int
 
+	// Heights of the terrain on the CPU, for picking and placing things
+	// on it, since the shader displaces it on the GPU. Heights[i][j] is
+	// the height i along the width and j along the depth, spread evenly
+	// across the terrain. Nil means the terrain is flat.
+	Heights [][]float32
+
 	TChild *child.Child3D
 }
 
@@ -22,3 +32,95 @@ func NewTerrain(width, height int) Terrain {
 func (terrain *Terrain) AttachMaterial(mat *material.TerrainMaterial) {
 	terrain.TChild.Model.Materials[0] = mat
 }
+
+// SetHeights sets the heights used on the CPU. They should match the
+// material's TerrainHeightMap, e.g. from geometry.GetHeightMapData
+// with the material's TerrainDisplacement.
+func (terrain *Terrain) SetHeights(heights [][]float32) {
+	terrain.Heights = heights
+}
+
+// HeightAt returns the height of the terrain in the world at a point,
+// and false if the point is off the terrain
+func (terrain *Terrain) HeightAt(x, z float32) (float32, bool) {
+	c := terrain.TChild
+
+	// Position across the terrain, from 0 to 1
+	scale := c.Transform.Scale
+	u := (x - c.X) / (float32(terrain.width) * scale.X())
+	v := (z - c.Z) / (float32(terrain.height) * scale.Z())
+	if u < 0 || u > 1 || v < 0 || v > 1 {
+		return 0, false
+	}
+
+	if len(terrain.Heights) == 0 || len(terrain.Heights[0]) == 0 {
+		return c.Y, true
+	}
+
+	// Interpolate between the four nearest heights
+	fx := u * float32(len(terrain.Heights)-1)
+	fz := v * float32(len(terrain.Heights[0])-1)
+	i, j := int(fx), int(fz)
+	i1, j1 := i, j
+	if i < len(terrain.Heights)-1 {
+		i1++
+	}
+	if j < len(terrain.Heights[0])-1 {
+		j1++
+	}
+	tx, tz := fx-float32(i), fz-float32(j)
+
+	h := terrain.Heights
+	top := h[i][j] + (h[i1][j]-h[i][j])*tx
+	bottom := h[i][j1] + (h[i1][j1]-h[i][j1])*tx
+	return c.Y + (top+(bottom-top)*tz)*scale.Y(), true
+}
+
+// Raycast returns the distance along a ray to the terrain, and false
+// if it isn't hit within maxDist. dir must be normalized.
+func (terrain *Terrain) Raycast(origin, dir mgl32.Vec3, maxDist float32) (float32, bool) {
+	// Points off the terrain count as above it
+	above := func(t float32) bool {
+		p := origin.Add(dir.Mul(t))
+		h, ok := terrain.HeightAt(p.X(), p.Z())
+		return !ok || p.Y() > h
+	}
+	if !above(0) {
+		return 0, false
+	}
+
+	// March along the ray in steps about the size of a height sample,
+	// then narrow down the step where the ray goes below the terrain
+	step := terrain.sampleSize()
+	for lo := float32(0); lo < maxDist; lo += step {
+		hi := float32(math.Min(float64(lo+step), float64(maxDist)))
+		if above(hi) {
+			continue
+		}
+
+		for k := 0; k < 16; k++ {
+			mid := (lo + hi) / 2
+			if above(mid) {
+				lo = mid
+			} else {
+				hi = mid
+			}
+		}
+		return hi, true
+	}
+	return 0, false
+}
+
+// sampleSize returns the distance between height samples in the
+// world, or a 64th of the terrain if the samples are further apart
+func (terrain *Terrain) sampleSize() float32 {
+	c := terrain.TChild
+	scale := c.Transform.Scale
+	size := math.Min(float64(float32(terrain.width)*scale.X()), float64(float32(terrain.height)*scale.Z()))
+
+	samples := 64.0
+	if n := len(terrain.Heights); n > 65 {
+		samples = float64(n - 1)
+	}
+	return float32(math.Max(size/samples, 0.01))
+}
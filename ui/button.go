@@ -2,6 +2,7 @@ package ui
 
 import (
 	"rapidengine/child"
+	"rapidengine/events"
 	"rapidengine/geometry"
 	"rapidengine/input"
 )
@@ -17,6 +18,14 @@ type Button struct {
 	justClicked   bool
 
 	colliding map[int]bool
+
+	// Receives ButtonClicked events
+	Events *events.Bus
+}
+
+// ButtonClicked is published when a button is clicked
+type ButtonClicked struct {
+	Button *Button
 }
 
 func NewUIButton(x, y, width, height float32) Button {
@@ -33,21 +42,23 @@ func NewUIButton(x, y, width, height float32) Button {
 func (button *Button) Initialize() {
 	button.ButtonChild.AttachCollider(
 		0, 0,
-		button.transform.SX,
-		button.transform.SY,
+		button.transform.Scale.X(),
+		button.transform.Scale.Y(),
 	)
 	button.ButtonChild.SetMouseFunc(button.MouseFunc)
 	button.ButtonChild.Static = true
-	button.SetPosition(button.transform.X, button.transform.Y)
-	button.SetDimensions(button.transform.SX, button.transform.SY)
+	button.SetPosition(button.transform.Position.X(), button.transform.Position.Y())
+	button.SetDimensions(button.transform.Scale.X(), button.transform.Scale.Y())
 }
 
 func (button *Button) SetClickCallback(f func()) {
 	button.clickCallback = f
 }
 
+// AttachText centers a text box on the button, which it moves with
 func (button *Button) AttachText(tb *TextBox) {
 	button.TextBx = tb
+	button.TextBx.Parent = button.ButtonChild
 	button.Initialize()
 }
 
@@ -67,7 +78,10 @@ func (button *Button) Update(inputs *input.Input) {
 	if button.colliding[0] {
 		if inputs.LeftMouseButton {
 			if !button.justClicked {
-				button.clickCallback()
+				if button.clickCallback != nil {
+					button.clickCallback()
+				}
+				button.Events.Publish(ButtonClicked{Button: button})
 				button.justClicked = true
 			}
 		} else {
@@ -77,18 +91,18 @@ func (button *Button) Update(inputs *input.Input) {
 }
 
 func (button *Button) SetPosition(x, y float32) {
-	button.ButtonChild.X = x
-	button.ButtonChild.Y = y
-
-	if button.TextBx != nil {
-		button.TextBx.X = button.ButtonChild.X + button.GetTransform().SX/2
-		button.TextBx.Y = button.ButtonChild.Y + button.GetTransform().SY/2
-	}
+	button.transform.SetPosition(x, y, 0)
+	button.ButtonChild.SetPosition(x, y)
 }
 
 func (button *Button) SetDimensions(width, height float32) {
-	button.ButtonChild.ScaleX = width
-	button.ButtonChild.ScaleY = height
+	button.transform.SetScale(width, height, 0)
+	button.ButtonChild.SetScale(width, height)
+
+	if button.TextBx != nil {
+		button.TextBx.X = width / 2
+		button.TextBx.Y = height / 2
+	}
 }
 
 func (button *Button) GetTransform() geometry.Transform {
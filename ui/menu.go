@@ -22,16 +22,27 @@ func NewMenu(config *configuration.EngineConfig) Menu {
 }
 
 func (m *Menu) Initialize() {
-	m.BackChild.SetPosition(m.transform.X, m.transform.Y)
-
-	m.BackChild.ScaleX = m.transform.SX
-	m.BackChild.ScaleY = m.transform.SY
+	m.BackChild.SetPosition(m.transform.Position.X(), m.transform.Position.Y())
+	m.BackChild.SetScale(m.transform.Scale.X(), m.transform.Scale.Y())
 
 	m.BackChild.Static = true
 }
 
+// AddElement adds an element to the menu, which it moves with.
+// The element's position becomes relative to the menu.
 func (m *Menu) AddElement(e Element) {
 	m.elements = append(m.elements, e)
+
+	for _, c := range e.GetChildren() {
+		if c.GetParent() == nil {
+			c.SetParent(m.BackChild)
+		}
+	}
+	for _, t := range e.GetTextBoxes() {
+		if t != nil && t.Parent == nil {
+			t.Parent = m.BackChild
+		}
+	}
 }
 
 //  --------------------------------------------------
@@ -43,14 +54,12 @@ func (m *Menu) Update(inputs *input.Input) {
 }
 
 func (m *Menu) SetPosition(x, y float32) {
-	m.transform.X = x
-	m.transform.Y = y
+	m.transform.SetPosition(x, y, 0)
 	m.Initialize()
 }
 
 func (m *Menu) SetDimensions(width, height float32) {
-	m.transform.SX = width
-	m.transform.SY = height
+	m.transform.SetScale(width, height, 0)
 	m.Initialize()
 }
 
@@ -34,8 +34,9 @@ func NewProgressBar(config *configuration.EngineConfig) ProgressBar {
 }
 
 func (pb *ProgressBar) Initialize() {
-	pb.SetPosition(pb.transform.X, pb.transform.Y)
-	pb.SetDimensions(pb.transform.SX, pb.transform.SY)
+	pb.BarChild.SetParent(pb.BackChild)
+	pb.SetPosition(pb.transform.Position.X(), pb.transform.Position.Y())
+	pb.SetDimensions(pb.transform.Scale.X(), pb.transform.Scale.Y())
 
 	pb.BackChild.Static = true
 	pb.BarChild.Static = true
@@ -47,9 +48,20 @@ func (pb *ProgressBar) SetBarScale(sx, sy float32) {
 	pb.Initialize()
 }
 
+// AttachText attaches text boxes to the bar, which they move with.
+// Their positions stay where they are on the screen.
 func (pb *ProgressBar) AttachText(left *TextBox, right *TextBox) {
 	pb.TextBxLeft = left
 	pb.TextBxRight = right
+
+	x, y := pb.BackChild.GetWorldPosition()
+	for _, t := range []*TextBox{left, right} {
+		if t != nil && t.Parent == nil {
+			t.X -= x
+			t.Y -= y
+			t.Parent = pb.BackChild
+		}
+	}
 }
 
 func (pb *ProgressBar) IncrementPercentage(delta float32) {
@@ -72,29 +84,25 @@ func (pb *ProgressBar) GetPercentage() float32 {
 //  --------------------------------------------------
 
 func (pb *ProgressBar) Update(inputs *input.Input) {
-	pb.BarChild.ScaleX = (pb.currentPercentage / 100) * (pb.transform.SX * pb.barScaleX)
+	pb.BarChild.SetScale((pb.currentPercentage/100)*(pb.transform.Scale.X()*pb.barScaleX), pb.transform.Scale.Y()*pb.barScaleY)
 }
 
 func (pb *ProgressBar) SetPosition(x, y float32) {
-	pb.transform.X = x
-	pb.transform.Y = y
-
-	pb.BackChild.X = x
-	pb.BackChild.Y = y
-
-	pb.BarChild.X = x + (pb.transform.SX-(pb.transform.SX*pb.barScaleX))/2
-	pb.BarChild.Y = y + (pb.transform.SY-(pb.transform.SY*pb.barScaleY))/2
+	pb.transform.SetPosition(x, y, 0)
+	width, height := pb.transform.Scale.X(), pb.transform.Scale.Y()
+
+	pb.BackChild.SetPosition(x, y)
+	pb.BarChild.SetPosition(
+		(width-(width*pb.barScaleX))/2,
+		(height-(height*pb.barScaleY))/2,
+	)
 }
 
 func (pb *ProgressBar) SetDimensions(width, height float32) {
-	pb.transform.SX = width
-	pb.transform.SY = height
-
-	pb.BackChild.ScaleX = pb.transform.SX
-	pb.BackChild.ScaleY = pb.transform.SY
+	pb.transform.SetScale(width, height, 0)
 
-	pb.BarChild.ScaleX = pb.transform.SX * pb.barScaleX
-	pb.BarChild.ScaleY = pb.transform.SY * pb.barScaleY
+	pb.BackChild.SetScale(width, height)
+	pb.BarChild.SetScale(width*pb.barScaleX, height*pb.barScaleY)
 }
 
 func (pb *ProgressBar) GetTransform() geometry.Transform {
@@ -1,6 +1,7 @@
 package ui
 
 import (
+	"rapidengine/child"
 	"rapidengine/configuration"
 
 	"github.com/4ydx/gltext/v4.1"
@@ -17,24 +18,43 @@ type TextBox struct {
 	X float32
 	Y float32
 
+	// Child the position is relative to, or nil for the screen
+	Parent child.Parented
+
 	Color [3]float32
 }
 
 func (t *TextBox) Update(config *configuration.EngineConfig) {
-	t.textObj.SetString(t.Text)
+	if t.textObj == nil {
+		return
+	}
+	t.textObj.SetString("%s", t.Text)
+	x, y := t.GetWorldPosition()
 	t.textObj.SetPosition(mgl32.Vec2{
-		t.X - float32(config.ScreenWidth/2),
-		t.Y - float32(config.ScreenHeight/2),
+		x - float32(config.ScreenWidth/2),
+		y - float32(config.ScreenHeight/2),
 	})
 	t.textObj.SetScale(t.Scale)
 	t.textObj.SetColor(mgl32.Vec3(t.Color))
 	t.textObj.Draw()
 }
 
+// GetWorldPosition returns the position of the text on the screen
+func (t *TextBox) GetWorldPosition() (float32, float32) {
+	if t.Parent == nil {
+		return t.X, t.Y
+	}
+	p := t.Parent.GetNode().World().Mul4x1(mgl32.Vec4{t.X, t.Y, 0, 1})
+	return p.X(), p.Y()
+}
+
 func (t *TextBox) SetV41Text(textObj *v41.Text) {
 	t.textObj = textObj
 }
 
 func (t *TextBox) GetLength() int {
+	if t.textObj == nil {
+		return 0
+	}
 	return t.textObj.GetLength()
 }